@@ -0,0 +1,323 @@
+package canvas
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/blackss2/canvas/font"
+)
+
+// GlyphAtlas packs rasterized glyphs into one or more texture pages for rendering text on the GPU. Each glyph is rasterized once per font face properties and subpixel position, and placed in a page using a skyline packer. Glyphs can be inserted incrementally, and when all pages are full the least recently used page is evicted and reused.
+type GlyphAtlas struct {
+	PageWidth, PageHeight int
+	MaxPages              int // maximum number of pages, zero is unlimited
+	Padding               int // empty pixels around each glyph to prevent bleeding when sampling
+	SubpixelPositions     int // number of horizontal subpixel positions per pixel, zero is equal to one
+	Resolution            Resolution
+	Options               font.RasterOptions
+
+	Pages []*image.Alpha
+
+	pages  []atlasPage
+	glyphs map[atlasKey]*AtlasGlyph
+	frame  uint64
+}
+
+// AtlasGlyph is a glyph in a glyph atlas. Its rectangle in the page is given in texels and in normalized texture coordinates, and its bearing is the offset in pixels of the top-left of the glyph's bitmap from the glyph's origin, with the y-axis pointing down. In LCD mode, the bitmap has three texels per pixel horizontally. Empty glyphs have a zero-sized rectangle. When the glyph's page is evicted its Page is set to -1.
+type AtlasGlyph struct {
+	Page               int
+	X, Y, W, H         int
+	U0, V0, U1, V1     float64
+	XBearing, YBearing int
+
+	key     atlasKey
+	lastUse uint64
+}
+
+// AtlasQuad is a textured quad of a glyph in pixels with the y-axis pointing down, relative to the origin of the text.
+type AtlasQuad struct {
+	X0, Y0, X1, Y1 float64
+	*AtlasGlyph
+}
+
+// AtlasSpan is the list of quads of a text span.
+type AtlasSpan struct {
+	Face  *FontFace
+	Color color.RGBA
+	Quads []AtlasQuad
+}
+
+type atlasKey struct {
+	font                 *Font
+	size                 float64
+	fauxBold, fauxItalic float64
+	glyphID              uint16
+	subpixel             int
+}
+
+type atlasPage struct {
+	skyline []skylineNode
+	dirty   image.Rectangle
+	lastUse uint64
+	glyphs  []*AtlasGlyph
+}
+
+// skylineNode is a horizontal segment of the skyline, below which the page is filled.
+type skylineNode struct {
+	x, y, w int
+}
+
+// NewGlyphAtlas returns a new glyph atlas with pages of the given size in texels, where glyphs are rasterized at the given resolution. It has a padding of one pixel and four subpixel positions.
+func NewGlyphAtlas(pageWidth, pageHeight int, resolution Resolution) *GlyphAtlas {
+	return &GlyphAtlas{
+		PageWidth:         pageWidth,
+		PageHeight:        pageHeight,
+		Padding:           1,
+		SubpixelPositions: 4,
+		Resolution:        resolution,
+		glyphs:            map[atlasKey]*AtlasGlyph{},
+	}
+}
+
+// Clear removes all glyphs and pages.
+func (a *GlyphAtlas) Clear() {
+	for _, glyph := range a.glyphs {
+		glyph.Page = -1
+	}
+	a.Pages = nil
+	a.pages = nil
+	a.glyphs = map[atlasKey]*AtlasGlyph{}
+}
+
+// NextFrame marks the start of a new frame. Glyphs used in the current frame are never evicted, so that all glyphs returned since the last call remain valid.
+func (a *GlyphAtlas) NextFrame() {
+	a.frame++
+}
+
+// Dirty returns the rectangle of each page that was modified since the last call, which should be uploaded to the GPU. Pages that were not modified have an empty rectangle.
+func (a *GlyphAtlas) Dirty() []image.Rectangle {
+	dirty := make([]image.Rectangle, len(a.pages))
+	for i := range a.pages {
+		dirty[i] = a.pages[i].dirty
+		a.pages[i].dirty = image.Rectangle{}
+	}
+	return dirty
+}
+
+// Add adds a glyph of the font face to the atlas, if not already present, and returns it.
+func (a *GlyphAtlas) Add(face *FontFace, glyphID uint16) (*AtlasGlyph, error) {
+	return a.add(face, glyphID, 0)
+}
+
+// AddText adds all glyphs of the text to the atlas.
+func (a *GlyphAtlas) AddText(t *Text) error {
+	_, err := a.TextQuads(t)
+	return err
+}
+
+// TextQuads adds all glyphs of the text to the atlas and returns the quads of each text span, in pixels with the y-axis pointing down and the text's origin at (0,0). Glyph positions are rounded to the subpixel positions horizontally and to whole pixels vertically.
+func (a *GlyphAtlas) TextQuads(t *Text) ([]AtlasSpan, error) {
+	dpmm := a.Resolution.DPMM()
+	subpixels := a.subpixels()
+	xScale := 1.0
+	if a.Options.LCD {
+		xScale = 3.0
+	}
+
+	spans := []AtlasSpan{}
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			// TODO: vertical text
+			quads := make([]AtlasQuad, 0, len(span.Glyphs))
+			x := span.x + span.Face.mmPerEm*float64(span.Face.XOffset)
+			y := -line.y + span.Face.mmPerEm*float64(span.Face.YOffset)
+			for _, g := range span.Glyphs {
+				gx := dpmm * (x + span.Face.mmPerEm*float64(g.XOffset))
+				gy := -dpmm * (y + span.Face.mmPerEm*float64(g.YOffset))
+				x += span.Face.glyphAdvance(g.XAdvance)
+
+				px := math.Floor(gx * float64(subpixels))
+				subpixel := int(px) % subpixels
+				if subpixel < 0 {
+					subpixel += subpixels
+				}
+				ox := (px - float64(subpixel)) / float64(subpixels)
+				oy := math.Floor(gy + 0.5)
+
+				glyph, err := a.add(span.Face, g.ID, subpixel)
+				if err != nil {
+					return nil, err
+				} else if glyph.W == 0 || glyph.H == 0 {
+					continue
+				}
+				x0 := ox + float64(glyph.XBearing)/xScale
+				y0 := oy + float64(glyph.YBearing)
+				quads = append(quads, AtlasQuad{x0, y0, x0 + float64(glyph.W)/xScale, y0 + float64(glyph.H), glyph})
+			}
+			spans = append(spans, AtlasSpan{span.Face, span.Face.Color, quads})
+		}
+	}
+	return spans, nil
+}
+
+func (a *GlyphAtlas) subpixels() int {
+	if a.SubpixelPositions < 1 {
+		return 1
+	}
+	return a.SubpixelPositions
+}
+
+func (a *GlyphAtlas) add(face *FontFace, glyphID uint16, subpixel int) (*AtlasGlyph, error) {
+	if a.glyphs == nil {
+		a.glyphs = map[atlasKey]*AtlasGlyph{}
+	}
+	key := atlasKey{face.Font, face.Size, face.FauxBold, face.FauxItalic, glyphID, subpixel}
+	if glyph, ok := a.glyphs[key]; ok {
+		glyph.lastUse = a.frame
+		if 0 <= glyph.Page {
+			a.pages[glyph.Page].lastUse = a.frame
+		}
+		return glyph, nil
+	}
+
+	// the sub- and superscript offsets are applied by the quads
+	plain := *face
+	plain.XOffset, plain.YOffset = 0, 0
+	xOffset := float64(subpixel) / float64(a.subpixels())
+	mask, err := plain.GlyphMask(glyphID, a.Resolution, xOffset, 0.0, a.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	glyph := &AtlasGlyph{
+		Page:    -1,
+		key:     key,
+		lastUse: a.frame,
+	}
+	if mask != nil {
+		w, h := mask.Rect.Dx()+2*a.Padding, mask.Rect.Dy()+2*a.Padding
+		page, x, y, err := a.place(w, h)
+		if err != nil {
+			return nil, err
+		}
+		glyph.Page = page
+		glyph.X, glyph.Y = x+a.Padding, y+a.Padding
+		glyph.W, glyph.H = mask.Rect.Dx(), mask.Rect.Dy()
+		glyph.U0 = float64(glyph.X) / float64(a.PageWidth)
+		glyph.V0 = float64(glyph.Y) / float64(a.PageHeight)
+		glyph.U1 = float64(glyph.X+glyph.W) / float64(a.PageWidth)
+		glyph.V1 = float64(glyph.Y+glyph.H) / float64(a.PageHeight)
+		glyph.XBearing, glyph.YBearing = mask.Rect.Min.X, mask.Rect.Min.Y
+
+		img := a.Pages[page]
+		for j := 0; j < glyph.H; j++ {
+			dst := img.Pix[img.PixOffset(glyph.X, glyph.Y+j):]
+			src := mask.Pix[j*mask.Stride:]
+			copy(dst[:glyph.W], src[:glyph.W])
+		}
+		p := &a.pages[page]
+		p.dirty = p.dirty.Union(image.Rect(x, y, x+w, y+h))
+		p.lastUse = a.frame
+		p.glyphs = append(p.glyphs, glyph)
+	}
+	a.glyphs[key] = glyph
+	return glyph, nil
+}
+
+// place finds room for a rectangle, adding a page or evicting the least recently used page when all pages are full.
+func (a *GlyphAtlas) place(w, h int) (int, int, int, error) {
+	if a.PageWidth < w || a.PageHeight < h {
+		return 0, 0, 0, fmt.Errorf("atlas: glyph of %dx%d does not fit in page", w, h)
+	}
+	for i := range a.pages {
+		if x, y, ok := a.pages[i].insert(w, h, a.PageWidth, a.PageHeight); ok {
+			return i, x, y, nil
+		}
+	}
+
+	page := len(a.pages)
+	if a.MaxPages == 0 || len(a.pages) < a.MaxPages {
+		a.pages = append(a.pages, atlasPage{})
+		a.Pages = append(a.Pages, image.NewAlpha(image.Rect(0, 0, a.PageWidth, a.PageHeight)))
+	} else {
+		for i := range a.pages {
+			if a.pages[i].lastUse < a.frame && (page == len(a.pages) || a.pages[i].lastUse < a.pages[page].lastUse) {
+				page = i
+			}
+		}
+		if page == len(a.pages) {
+			return 0, 0, 0, fmt.Errorf("atlas: pages are full")
+		}
+		a.evict(page)
+	}
+	x, y, _ := a.pages[page].insert(w, h, a.PageWidth, a.PageHeight)
+	return page, x, y, nil
+}
+
+// evict removes all glyphs from a page.
+func (a *GlyphAtlas) evict(page int) {
+	p := &a.pages[page]
+	for _, glyph := range p.glyphs {
+		glyph.Page = -1
+		delete(a.glyphs, glyph.key)
+	}
+	img := a.Pages[page]
+	for i := range img.Pix {
+		img.Pix[i] = 0
+	}
+	*p = atlasPage{dirty: img.Rect}
+}
+
+// insert places a rectangle at the lowest position of the skyline, preferring the narrowest segment for equal heights.
+func (p *atlasPage) insert(w, h, pageWidth, pageHeight int) (int, int, bool) {
+	if p.skyline == nil {
+		p.skyline = []skylineNode{{0, 0, pageWidth}}
+	}
+
+	best, bestY, bestW := -1, 0, 0
+	for i, node := range p.skyline {
+		if pageWidth < node.x+w {
+			break
+		}
+		y := 0
+		for j, remaining := i, w; 0 < remaining; j++ {
+			if y < p.skyline[j].y {
+				y = p.skyline[j].y
+			}
+			remaining -= p.skyline[j].w
+		}
+		if y+h <= pageHeight && (best == -1 || y < bestY || y == bestY && node.w < bestW) {
+			best, bestY, bestW = i, y, node.w
+		}
+	}
+	if best == -1 {
+		return 0, 0, false
+	}
+
+	// replace the covered segments by the new segment
+	x := p.skyline[best].x
+	nodes := append([]skylineNode{}, p.skyline[:best]...)
+	nodes = append(nodes, skylineNode{x, bestY + h, w})
+	for _, node := range p.skyline[best:] {
+		if node.x+node.w <= x+w {
+			continue
+		} else if node.x < x+w {
+			node.w -= x + w - node.x
+			node.x = x + w
+		}
+		nodes = append(nodes, node)
+	}
+
+	// merge adjacent segments of equal height
+	p.skyline = nodes[:1]
+	for _, node := range nodes[1:] {
+		if last := &p.skyline[len(p.skyline)-1]; last.y == node.y {
+			last.w += node.w
+		} else {
+			p.skyline = append(p.skyline, node)
+		}
+	}
+	return x, bestY, true
+}
@@ -0,0 +1,331 @@
+// Command fontinfo inspects, converts, and subsets fonts using the font package, so that the results match what the renderer sees.
+//
+// Usage:
+//
+//	fontinfo info [-index n] [-glyphs] font
+//	fontinfo convert [-index n] -o output.(ttf|otf|woff|woff2|eot) font
+//	fontinfo subset [-index n] -text file -o output.(ttf|otf|woff|woff2|eot) font
+//
+// The info command writes the tables, names, metrics, character coverage, layout features, and optionally the data of each glyph as JSON to the standard output. Input fonts can be of any format supported by font.ToSFNT.
+//
+// The subset command keeps the glyphs of the characters in the text file, along with their kerning pairs. The GSUB, GPOS, and GDEF tables are dropped, so that ligatures, contextual forms, and mark positioning are lost in the subsetted font.
+//
+// Output fonts are written in the format of the file extension. WOFF fonts are compressed with zlib, while WOFF2 and EOT fonts are stored uncompressed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blackss2/canvas/font"
+)
+
+const usage = `usage:
+  fontinfo info [-index n] [-glyphs] font
+  fontinfo convert [-index n] -o output.(ttf|otf|woff|woff2|eot) font
+  fontinfo subset [-index n] -text file -o output.(ttf|otf|woff|woff2|eot) font
+
+The subset command drops the GSUB, GPOS, and GDEF tables.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "info":
+		err = info(os.Args[2:])
+	case "convert":
+		err = convert(os.Args[2:])
+	case "subset":
+		err = subset(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fontinfo: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Info is the JSON output of the info command.
+type Info struct {
+	File     string              `json:"file"`
+	Format   string              `json:"format"`
+	Outlines string              `json:"outlines"`
+	Tables   []Table             `json:"tables"`
+	Names    font.Names          `json:"names"`
+	Metrics  Metrics             `json:"metrics"`
+	Coverage Coverage            `json:"coverage"`
+	Features map[string][]string `json:"features"`
+	Glyphs   []Glyph             `json:"glyphs,omitempty"`
+}
+
+// Table is a table of the font.
+type Table struct {
+	Tag    string `json:"tag"`
+	Length int    `json:"length"`
+}
+
+// Metrics are the font-wide metrics in font units.
+type Metrics struct {
+	UnitsPerEm   uint16   `json:"unitsPerEm"`
+	NumGlyphs    uint16   `json:"numGlyphs"`
+	Ascender     int16    `json:"ascender"`
+	Descender    int16    `json:"descender"`
+	LineGap      int16    `json:"lineGap"`
+	XHeight      int16    `json:"xHeight"`
+	CapHeight    int16    `json:"capHeight"`
+	ItalicAngle  float64  `json:"italicAngle"`
+	WeightClass  uint16   `json:"weightClass"`
+	WidthClass   uint16   `json:"widthClass"`
+	IsFixedPitch bool     `json:"isFixedPitch"`
+	BBox         [4]int16 `json:"bbox"`
+}
+
+// Coverage is the character and script coverage of the font.
+type Coverage struct {
+	NumRunes int                 `json:"numRunes"`
+	Ranges   []string            `json:"ranges"`
+	Scripts  map[string][]string `json:"scripts"`
+}
+
+// Glyph is the data of a single glyph in font units.
+type Glyph struct {
+	ID      uint16   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	Advance uint16   `json:"advance"`
+	BBox    [4]int16 `json:"bbox"`
+	Runes   []string `json:"runes,omitempty"`
+}
+
+func info(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	index := fs.Int("index", 0, "index of the font in a collection")
+	glyphs := fs.Bool("glyphs", false, "include the data of each glyph")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one font file")
+	}
+
+	b, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	format, err := font.MediaType(b)
+	if err != nil {
+		return err
+	}
+	sfnt, err := font.ParseFont(b, *index)
+	if err != nil {
+		return err
+	}
+
+	out := Info{
+		File:     fs.Arg(0),
+		Format:   format,
+		Outlines: "TrueType",
+		Names:    sfnt.Names(),
+		Features: map[string][]string{},
+	}
+	if sfnt.IsCFF {
+		out.Outlines = "CFF"
+	}
+
+	tags := make([]string, 0, len(sfnt.Tables))
+	for tag := range sfnt.Tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		out.Tables = append(out.Tables, Table{tag, len(sfnt.Tables[tag])})
+	}
+
+	out.Metrics = Metrics{
+		UnitsPerEm:   sfnt.Head.UnitsPerEm,
+		NumGlyphs:    sfnt.NumGlyphs(),
+		Ascender:     sfnt.Hhea.Ascender,
+		Descender:    sfnt.Hhea.Descender,
+		LineGap:      sfnt.Hhea.LineGap,
+		XHeight:      sfnt.OS2.SxHeight,
+		CapHeight:    sfnt.OS2.SCapHeight,
+		ItalicAngle:  float64(int32(sfnt.Post.ItalicAngle)) / 65536.0,
+		WeightClass:  sfnt.OS2.UsWeightClass,
+		WidthClass:   sfnt.OS2.UsWidthClass,
+		IsFixedPitch: sfnt.Post.IsFixedPitch != 0,
+		BBox:         [4]int16{sfnt.Head.XMin, sfnt.Head.YMin, sfnt.Head.XMax, sfnt.Head.YMax},
+	}
+
+	coverage := sfnt.Coverage()
+	out.Coverage = Coverage{
+		NumRunes: len(coverage.Runes),
+		Ranges:   runeRanges(coverage.Runes),
+		Scripts:  map[string][]string{},
+	}
+	for script, languages := range coverage.Scripts {
+		langs := []string{}
+		for _, language := range languages {
+			langs = append(langs, string(language))
+		}
+		sort.Strings(langs)
+		out.Coverage.Scripts[string(script)] = langs
+	}
+
+	for _, tag := range []string{"GSUB", "GPOS"} {
+		features, err := sfnt.LayoutFeatures(tag)
+		if err != nil {
+			return err
+		} else if features != nil {
+			out.Features[tag] = []string{}
+			for _, feature := range features {
+				out.Features[tag] = append(out.Features[tag], string(feature))
+			}
+		}
+	}
+
+	if *glyphs {
+		runes := map[uint16][]string{}
+		for _, r := range coverage.Runes {
+			glyphID := sfnt.GlyphIndex(r)
+			runes[glyphID] = append(runes[glyphID], fmt.Sprintf("U+%04X", r))
+		}
+		for glyphID := uint16(0); glyphID < sfnt.NumGlyphs(); glyphID++ {
+			glyph := Glyph{
+				ID:      glyphID,
+				Name:    sfnt.GlyphName(glyphID),
+				Advance: sfnt.GlyphAdvance(glyphID),
+				Runes:   runes[glyphID],
+			}
+			if xMin, yMin, xMax, yMax, err := sfnt.GlyphBounds(glyphID); err == nil {
+				glyph.BBox = [4]int16{xMin, yMin, xMax, yMax}
+			}
+			out.Glyphs = append(out.Glyphs, glyph)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	index := fs.Int("index", 0, "index of the font in a collection")
+	output := fs.String("o", "", "output file")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *output == "" {
+		return fmt.Errorf("expected one font file and an output file")
+	}
+
+	b, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if b, err = font.ToSFNT(b); err != nil {
+		return err
+	}
+	if 0 < *index || strings.HasPrefix(string(b), "ttcf") {
+		// select a single font from the collection
+		sfnt, err := font.ParseSFNT(b, *index)
+		if err != nil {
+			return err
+		}
+		b = sfnt.Write()
+	}
+	return write(*output, b)
+}
+
+func subset(args []string) error {
+	fs := flag.NewFlagSet("subset", flag.ExitOnError)
+	index := fs.Int("index", 0, "index of the font in a collection")
+	textFile := fs.String("text", "", "file with the text to keep")
+	output := fs.String("o", "", "output file")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *textFile == "" || *output == "" {
+		return fmt.Errorf("expected one font file, a text file, and an output file")
+	}
+
+	b, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sfnt, err := font.ParseFont(b, *index)
+	if err != nil {
+		return err
+	}
+	text, err := ioutil.ReadFile(*textFile)
+	if err != nil {
+		return err
+	} else if !utf8.Valid(text) {
+		return fmt.Errorf("text file must be UTF-8")
+	}
+
+	glyphIDs := []uint16{0}
+	seen := map[uint16]bool{0: true}
+	for _, r := range string(text) {
+		if glyphID := sfnt.GlyphIndex(r); !seen[glyphID] {
+			glyphIDs = append(glyphIDs, glyphID)
+			seen[glyphID] = true
+		}
+	}
+	sort.Slice(glyphIDs, func(i, j int) bool { return glyphIDs[i] < glyphIDs[j] })
+	if b, _, err = sfnt.SubsetChecked(glyphIDs); err != nil {
+		return err
+	}
+	return write(*output, b)
+}
+
+// write writes an SFNT font in the format of the file extension.
+func write(filename string, b []byte) error {
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".ttf", ".otf":
+		if font.Extension(b) != ext {
+			return fmt.Errorf("font must be written as %s", font.Extension(b))
+		}
+	case ".woff":
+		if b, err = font.ToWOFF(b); err != nil {
+			return err
+		}
+	case ".woff2":
+		if b, err = font.ToWOFF2(b); err != nil {
+			return err
+		}
+	case ".eot":
+		if b, err = font.ToEOT(b); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported output format %s", ext)
+	}
+	return ioutil.WriteFile(filename, b, 0644)
+}
+
+// runeRanges returns the sorted runes as ranges of consecutive runes.
+func runeRanges(runes []rune) []string {
+	ranges := []string{}
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[j-1]+1 {
+			j++
+		}
+		if j-i == 1 {
+			ranges = append(ranges, fmt.Sprintf("U+%04X", runes[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("U+%04X-U+%04X", runes[i], runes[j-1]))
+		}
+		i = j
+	}
+	return ranges
+}
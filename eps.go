@@ -0,0 +1,325 @@
+package canvas
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/blackss2/canvas/font"
+)
+
+// maxSfntsString is the maximum length of the strings of the sfnts array of Type 42 fonts, which must be even and below the string limit of PostScript.
+const maxSfntsString = 65534
+
+// Type42 returns a PostScript Type 42 font definition with the given name of the TrueType font, subsetted to the given glyphs. Glyphs are named "g" followed by their glyph ID in the original font, and can be shown with glyphshow. The encoding is empty.
+func (f *Font) Type42(name string, glyphIDs []uint16) ([]byte, error) {
+	if !f.IsTrueType {
+		return nil, fmt.Errorf("type42: font must have TrueType outlines")
+	}
+	glyphIDs = psGlyphIDs(glyphIDs)
+	subset, subsetIDs, err := f.SFNT.SubsetChecked(glyphIDs)
+	if err != nil {
+		return nil, err
+	}
+	sfnts, err := psSfnts(subset)
+	if err != nil {
+		return nil, err
+	}
+
+	toEm := 1.0 / float64(f.Head.UnitsPerEm)
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "%%!PS-TrueTypeFont-1.0-%s\n", psNum(float64(f.Head.FontRevision)/65536.0))
+	fmt.Fprintf(b, "11 dict begin\n/FontName /%s def\n/FontType 42 def\n/FontMatrix [1 0 0 1 0 0] def\n", name)
+	fmt.Fprintf(b, "/FontBBox [%s %s %s %s] def\n", psNum(float64(f.Head.XMin)*toEm), psNum(float64(f.Head.YMin)*toEm), psNum(float64(f.Head.XMax)*toEm), psNum(float64(f.Head.YMax)*toEm))
+	b.WriteString("/PaintType 0 def\n/Encoding 256 array 0 1 255 {1 index exch /.notdef put} for def\n")
+	fmt.Fprintf(b, "/CharStrings %d dict dup begin\n/.notdef 0 def\n", len(subsetIDs)+1)
+	for subsetID, glyphID := range subsetIDs {
+		fmt.Fprintf(b, "/g%d %d def\n", glyphID, subsetID)
+	}
+	b.WriteString("end readonly def\n/sfnts [\n")
+	for _, s := range sfnts {
+		// the last byte of each string is padding
+		writePSHex(b, append(s, 0))
+	}
+	b.WriteString("] def\nFontName currentdict end definefont pop\n")
+	return b.Bytes(), nil
+}
+
+// Type3 returns a PostScript Type 3 font definition with the given name from the outlines of the given glyphs, which works for both TrueType and CFF fonts. Glyphs are named "g" followed by their glyph ID, and can be shown with glyphshow. The encoding is empty. The glyphs are stroked instead of filled when PaintType is set to 2, with the width of StrokeWidth in font units. It returns an error if the font's license does not allow subsetting, see CheckEmbedding.
+func (f *Font) Type3(name string, glyphIDs []uint16) ([]byte, error) {
+	if err := f.CheckEmbedding(true); err != nil {
+		return nil, err
+	}
+	glyphIDs = psGlyphIDs(glyphIDs)
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "%%!PS-Adobe-3.0 Resource-Font\n12 dict begin\n/FontName /%s def\n/FontType 3 def\n", name)
+	fmt.Fprintf(b, "/FontMatrix [%s 0 0 %s 0 0] def\n", psNum(1.0/float64(f.Head.UnitsPerEm)), psNum(1.0/float64(f.Head.UnitsPerEm)))
+	fmt.Fprintf(b, "/FontBBox [%d %d %d %d] def\n", f.Head.XMin, f.Head.YMin, f.Head.XMax, f.Head.YMax)
+	b.WriteString("/PaintType 0 def\n/StrokeWidth 0 def\n/Encoding 256 array 0 1 255 {1 index exch /.notdef put} for def\n")
+	b.WriteString("/Paint {PaintType 2 eq {StrokeWidth setlinewidth stroke} {fill} ifelse} bind def\n")
+	fmt.Fprintf(b, "/Glyphs %d dict dup begin\n/.notdef {0 0 setcharwidth} def\n", len(glyphIDs)+1)
+	p := &psPather{w: b}
+	for _, glyphID := range glyphIDs {
+		outline, err := f.GlyphOutline(glyphID)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(b, "/g%d {%d 0 setcharwidth newpath\n", glyphID, f.GlyphAdvance(glyphID))
+		outline.Draw(p, 0.0, 0.0, 1.0)
+		b.WriteString("Paint} bind def\n")
+	}
+	b.WriteString("end def\n")
+	b.WriteString("/BuildGlyph {exch begin Glyphs exch 2 copy known not {pop /.notdef} if get exec end} bind def\n")
+	b.WriteString("/BuildChar {1 index /Encoding get exch get 1 index /BuildGlyph get exec} bind def\n")
+	b.WriteString("FontName currentdict end definefont pop\n")
+	return b.Bytes(), nil
+}
+
+// ToEPS writes the text as an encapsulated PostScript file. The fonts are embedded as Type 42 fonts for TrueType outlines and as Type 3 fonts otherwise, subsetted to the used glyphs. Each glyph is shown with glyphshow at its position, and faux bold is drawn by stroking the glyphs. A negative faux bold, which thins the glyphs in raster output, only affects the glyph positions since outlines cannot be thinned by stroking.
+func (t *Text) ToEPS(w io.Writer) error {
+	// collect the glyphs of each font
+	fonts := []*Font{}
+	glyphIDs := map[*Font][]uint16{}
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			f := span.Face.Font
+			if _, ok := glyphIDs[f]; !ok {
+				fonts = append(fonts, f)
+			}
+			for _, glyph := range span.Glyphs {
+				glyphIDs[f] = append(glyphIDs[f], glyph.ID)
+			}
+		}
+	}
+
+	names := map[*Font]string{}
+	resources := [][]byte{}
+	for _, f := range fonts {
+		ids := psGlyphIDs(glyphIDs[f])
+		name := subsetTag(ids) + "+" + pdfName(f.NameString(font.NamePostScript), f.name)
+		var resource []byte
+		var err error
+		if f.IsTrueType {
+			resource, err = f.Type42(name, ids)
+		} else {
+			resource, err = f.Type3(name, ids)
+		}
+		if err != nil {
+			return err
+		}
+		names[f] = name
+		resources = append(resources, resource)
+	}
+
+	rect := t.Bounds().Add(t.InkBounds())
+	wb := bufio.NewWriter(w)
+	fmt.Fprintf(wb, "%%!PS-Adobe-3.0 EPSF-3.0\n%%%%BoundingBox: 0 0 %d %d\n", int(math.Ceil(rect.W/mmPerPt)), int(math.Ceil(rect.H/mmPerPt)))
+	fmt.Fprintf(wb, "%%%%HiResBoundingBox: 0 0 %s %s\n%%%%LanguageLevel: 2\n", psNum(rect.W/mmPerPt), psNum(rect.H/mmPerPt))
+	for i, f := range fonts {
+		if i == 0 {
+			wb.WriteString("%%DocumentSuppliedResources: ")
+		} else {
+			wb.WriteString("%%+ ")
+		}
+		fmt.Fprintf(wb, "font %s\n", names[f])
+	}
+	wb.WriteString("%%EndComments\n%%BeginProlog\n")
+	wb.WriteString("/strokefont {exch dup length 2 add dict begin {1 index /FID ne {def} {pop pop} ifelse} forall /StrokeWidth exch def /PaintType 2 def currentdict end /StrokedFont exch definefont} bind def\n")
+	for i, f := range fonts {
+		fmt.Fprintf(wb, "%%%%BeginResource: font %s\n", names[f])
+		wb.Write(resources[i])
+		wb.WriteString("%%EndResource\n")
+	}
+	wb.WriteString("%%EndProlog\n")
+	fmt.Fprintf(wb, "gsave\n%s dup scale\n%s %s translate\n", psNum(1.0/mmPerPt), psNum(-rect.X), psNum(-rect.Y))
+
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			// TODO: vertical text
+			face := span.Face
+			x := span.x + face.mmPerEm*float64(face.XOffset) + face.fauxBold()
+			y := -line.y + face.mmPerEm*float64(face.YOffset)
+			scaledFont := fmt.Sprintf("/%s findfont [%s 0 %s %s 0 0] makefont", names[face.Font], psNum(face.Size), psNum(face.Size*face.FauxItalic), psNum(face.Size))
+			fmt.Fprintf(wb, "%s setrgbcolor %s setfont\n", psColor(face.Color), scaledFont)
+
+			glyphs := &bytes.Buffer{}
+			for _, glyph := range span.Glyphs {
+				gx := x + face.mmPerEm*float64(glyph.XOffset)
+				gy := y + face.mmPerEm*float64(glyph.YOffset)
+				fmt.Fprintf(glyphs, "%s %s moveto /g%d glyphshow\n", psNum(gx), psNum(gy), glyph.ID)
+				x += face.glyphAdvance(glyph.XAdvance)
+			}
+			wb.Write(glyphs.Bytes())
+			if 0.0 < face.FauxBold {
+				// the stroke width is in glyph space, which is in ems for Type 42 and in font units for Type 3 fonts
+				strokeWidth := 2.0 * face.fauxBold() / face.Size
+				if !face.Font.IsTrueType {
+					strokeWidth *= float64(face.Font.Head.UnitsPerEm)
+				}
+				fmt.Fprintf(wb, "%s %s strokefont setfont\n", scaledFont, psNum(strokeWidth))
+				wb.Write(glyphs.Bytes())
+			}
+		}
+	}
+	wb.WriteString("grestore\n%%EOF\n")
+	return wb.Flush()
+}
+
+// psGlyphIDs returns the sorted and unique glyph IDs, starting with the .notdef glyph.
+func psGlyphIDs(glyphIDs []uint16) []uint16 {
+	ids := []uint16{0}
+	sorted := append([]uint16{}, glyphIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, glyphID := range sorted {
+		if ids[len(ids)-1] != glyphID {
+			ids = append(ids, glyphID)
+		}
+	}
+	return ids
+}
+
+// psSfnts splits a TrueType font into strings for the sfnts array of a Type 42 font. Strings may only be split between tables or between glyphs of the glyf table.
+func psSfnts(b []byte) ([][]byte, error) {
+	if len(b) < 12 {
+		return nil, font.ErrInvalidFontData
+	}
+	numTables := int(binary.BigEndian.Uint16(b[4:]))
+	if len(b) < 12+16*numTables {
+		return nil, font.ErrInvalidFontData
+	}
+
+	type table struct {
+		tag            string
+		offset, length uint32
+	}
+	tables := make([]table, numTables)
+	for i := range tables {
+		record := b[12+16*i:]
+		tables[i].tag = string(record[:4])
+		tables[i].offset = binary.BigEndian.Uint32(record[8:])
+		tables[i].length = (binary.BigEndian.Uint32(record[12:]) + 3) &^ 3
+		if uint32(len(b)) < tables[i].offset+tables[i].length {
+			tables[i].length = uint32(len(b)) - tables[i].offset
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].offset < tables[j].offset })
+
+	sfnts := [][]byte{b[:12+16*numTables]}
+	add := func(data []byte) {
+		last := sfnts[len(sfnts)-1]
+		if len(last)+len(data) <= maxSfntsString {
+			sfnts[len(sfnts)-1] = append(last[:len(last):len(last)], data...)
+		} else {
+			sfnts = append(sfnts, data)
+		}
+	}
+	for _, table := range tables {
+		data := b[table.offset : table.offset+table.length]
+		if len(data) <= maxSfntsString {
+			add(data)
+			continue
+		} else if table.tag != "glyf" {
+			return nil, fmt.Errorf("type42: %s table too large", table.tag)
+		}
+
+		// split at glyph boundaries
+		sfnt, err := font.ParseSFNT(b, 0)
+		if err != nil {
+			return nil, err
+		}
+		start, prev := uint32(0), uint32(0)
+		for glyphID := uint16(1); glyphID <= sfnt.Maxp.NumGlyphs; glyphID++ {
+			end, ok := sfnt.Loca.Get(glyphID)
+			if !ok || end < prev || table.length < end {
+				return nil, fmt.Errorf("type42: invalid loca table")
+			} else if maxSfntsString < end-start {
+				if prev == start {
+					return nil, fmt.Errorf("type42: glyph %d too large", glyphID-1)
+				}
+				add(data[start:prev])
+				start = prev
+			}
+			prev = end
+		}
+		if maxSfntsString < table.length-start {
+			add(data[start:prev])
+			start = prev
+		}
+		add(data[start:])
+	}
+	return sfnts, nil
+}
+
+// writePSHex writes a hexadecimal string over multiple lines.
+func writePSHex(w *bytes.Buffer, b []byte) {
+	w.WriteString("<")
+	for i := 0; i < len(b); i += 32 {
+		if i != 0 {
+			w.WriteString("\n")
+		}
+		end := i + 32
+		if len(b) < end {
+			end = len(b)
+		}
+		w.WriteString(hex.EncodeToString(b[i:end]))
+	}
+	w.WriteString(">\n")
+}
+
+func psColor(c color.RGBA) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("%s %s %s", psNum(float64(n.R)/255.0), psNum(float64(n.G)/255.0), psNum(float64(n.B)/255.0))
+}
+
+func psNum(v float64) string {
+	v = math.Round(v*1e5) / 1e5
+	if v == 0.0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+////////////////////////////////////////////////////////////////
+
+// psPather writes paths as PostScript operators, converting quadratic Béziers to cubic Béziers.
+type psPather struct {
+	w    *bytes.Buffer
+	x, y float64
+	open bool
+}
+
+func (p *psPather) MoveTo(x, y float64) {
+	fmt.Fprintf(p.w, "%s %s moveto\n", psNum(x), psNum(y))
+	p.x, p.y = x, y
+	p.open = true
+}
+
+func (p *psPather) LineTo(x, y float64) {
+	fmt.Fprintf(p.w, "%s %s lineto\n", psNum(x), psNum(y))
+	p.x, p.y = x, y
+}
+
+func (p *psPather) QuadTo(cpx, cpy, x, y float64) {
+	cpx1, cpy1 := p.x+2.0/3.0*(cpx-p.x), p.y+2.0/3.0*(cpy-p.y)
+	cpx2, cpy2 := x+2.0/3.0*(cpx-x), y+2.0/3.0*(cpy-y)
+	p.CubeTo(cpx1, cpy1, cpx2, cpy2, x, y)
+}
+
+func (p *psPather) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	fmt.Fprintf(p.w, "%s %s %s %s %s %s curveto\n", psNum(cpx1), psNum(cpy1), psNum(cpx2), psNum(cpy2), psNum(x), psNum(y))
+	p.x, p.y = x, y
+}
+
+func (p *psPather) Close() {
+	if p.open {
+		p.w.WriteString("closepath\n")
+		p.open = false
+	}
+}
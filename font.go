@@ -2,7 +2,9 @@ package canvas
 
 import (
 	"fmt"
+	"image"
 	"image/color"
+	"io"
 	"io/ioutil"
 	"math"
 	"reflect"
@@ -90,7 +92,10 @@ func parseFont(name string, style FontStyle, b []byte, index int) (*Font, error)
 	if err != nil {
 		return nil, err
 	}
+	return newFont(name, style, SFNT)
+}
 
+func newFont(name string, style FontStyle, SFNT *font.SFNT) (*Font, error) {
 	shaper, err := text.NewShaperSFNT(SFNT)
 	if err != nil {
 		return nil, err
@@ -260,6 +265,20 @@ func (family *FontFamily) LoadFont(b []byte, index int, style FontStyle) error {
 	return nil
 }
 
+// LoadFontReaderAt loads a font (TTF, OTF, TTC) of the given size from a reader, such as an os.File, and uses the font at the specified index. Tables are read on demand so that the font file is not held in memory, the reader must remain valid while the font is in use.
+func (family *FontFamily) LoadFontReaderAt(r io.ReaderAt, size int64, index int, style FontStyle) error {
+	SFNT, err := font.ParseSFNTReaderAt(r, size, index)
+	if err != nil {
+		return err
+	}
+	font, err := newFont(family.name, style, SFNT)
+	if err != nil {
+		return err
+	}
+	family.fonts[style] = font
+	return nil
+}
+
 // Face gets the font face given by the font size in points and its style.
 func (family *FontFamily) Face(size float64, col color.Color, style FontStyle, variant FontVariant) *FontFace {
 	face := &FontFace{}
@@ -437,14 +456,125 @@ func (face *FontFace) TextWidth(s string) float64 {
 	return face.textWidth(glyphs)
 }
 
+// textWidth returns the width of the shaped glyphs in millimeters, using the same advances as when drawing. Kerning is included in the shaped advances.
 func (face *FontFace) textWidth(glyphs []text.Glyph) float64 {
+	w := 0.0
+	for _, glyph := range glyphs {
+		w += face.glyphAdvance(glyph.XAdvance)
+	}
+	return w
+}
+
+// fauxBold returns the distance in millimeters by which faux bold offsets the glyph outlines on each side. Glyphs are shifted to the right by the same distance to keep their left side bearing, so that advances grow by twice the distance.
+func (face *FontFace) fauxBold() float64 {
+	return face.FauxBold * face.Size
+}
+
+// glyphAdvance returns the advance in millimeters of a glyph with the given advance in font units, including faux bold. Glyphs without an advance, such as combining marks, are not widened.
+func (face *FontFace) glyphAdvance(advance int32) float64 {
+	if advance == 0 {
+		return 0.0
+	}
+	return face.mmPerEm*float64(advance) + 2.0*face.fauxBold()
+}
+
+// GlyphPath draws the outline of a glyph in millimeters to the pather with its origin at (x,y), including the sub- and superscript offsets and the faux bold and italic styles of the font face. Faux italic shears the outline, and faux bold emboldens it by FauxBold times the font size on each side and shifts it to the right by the same amount, see GlyphMetrics.
+func (face *FontFace) GlyphPath(p font.Pather, glyphID uint16, x, y float64) error {
+	outline, dx, dy, err := face.glyphOutline(glyphID)
+	if err != nil {
+		return err
+	}
+	outline.Draw(p, x+face.mmPerEm*dx, y+face.mmPerEm*dy, face.mmPerEm)
+	return nil
+}
+
+// GlyphMask returns the anti-aliased mask of a glyph at the given resolution, including the sub- and superscript offsets and the faux bold and italic styles of the font face, see GlyphPath. The offsets in pixels position the glyph at subpixel precision. The mask's rectangle is relative to the glyph's origin with the y-axis pointing down, see font.Outline.Rasterize. It returns nil for empty glyphs.
+func (face *FontFace) GlyphMask(glyphID uint16, resolution Resolution, xOffset, yOffset float64, opts font.RasterOptions) (*image.Alpha, error) {
+	outline, dx, dy, err := face.glyphOutline(glyphID)
+	if err != nil {
+		return nil, err
+	}
+	scale := resolution.DPMM() * face.mmPerEm
+	return outline.Rasterize(xOffset+scale*dx, yOffset+scale*dy, scale, opts), nil
+}
+
+// glyphOutline returns the outline of a glyph in font units with the faux styles applied, and the offset in font units at which to draw it.
+func (face *FontFace) glyphOutline(glyphID uint16) (font.Outline, float64, float64, error) {
+	outline, err := face.Font.GlyphOutline(glyphID)
+	if err != nil {
+		return nil, 0.0, 0.0, err
+	}
+	dx, dy := float64(face.XOffset), float64(face.YOffset)
+	if face.FauxItalic != 0.0 {
+		outline = outline.Oblique(face.FauxItalic)
+	}
+	if face.FauxBold != 0.0 {
+		d := face.fauxBold() / face.mmPerEm
+		outline = outline.Embolden(2.0*d, 2.0*d)
+		dx += d
+	}
+	return outline, dx, dy, nil
+}
+
+// GlyphMetrics are the metrics of a single glyph in millimeters. The ink box is the bounding box of the glyph's outline relative to the glyph's origin, with the Y axis pointing up.
+type GlyphMetrics struct {
+	Advance          float64
+	LeftSideBearing  float64
+	RightSideBearing float64
+
+	XMin, YMin float64
+	XMax, YMax float64
+}
+
+func (m GlyphMetrics) String() string {
+	return fmt.Sprintf("{Advance: %v, LeftSideBearing: %v, RightSideBearing: %v, XMin: %v, YMin: %v, XMax: %v, YMax: %v}", m.Advance, m.LeftSideBearing, m.RightSideBearing, m.XMin, m.YMin, m.XMax, m.YMax)
+}
+
+// Empty returns true if the glyph has no ink, such as for spaces.
+func (m GlyphMetrics) Empty() bool {
+	return m.XMax <= m.XMin || m.YMax <= m.YMin
+}
+
+// GlyphMetrics returns the metrics of a glyph in millimeters, including the faux bold and italic styles of the font face.
+func (face *FontFace) GlyphMetrics(glyphID uint16) GlyphMetrics {
 	sfnt := face.Font.SFNT
-	w := int32(0)
-	for i, glyph := range glyphs {
-		if i != 0 {
-			w += int32(sfnt.Kerning(glyphs[i-1].ID, glyph.ID))
+	advance := face.glyphAdvance(int32(sfnt.GlyphAdvance(glyphID)))
+	xMin, yMin, xMax, yMax, err := sfnt.GlyphBounds(glyphID)
+	if err != nil || xMax <= xMin || yMax <= yMin {
+		return GlyphMetrics{
+			Advance:          advance,
+			RightSideBearing: advance,
 		}
-		w += int32(sfnt.GlyphAdvance(glyph.ID))
 	}
-	return face.mmPerEm * float64(w)
+
+	x0, y0 := face.mmPerEm*float64(xMin), face.mmPerEm*float64(yMin)
+	x1, y1 := face.mmPerEm*float64(xMax), face.mmPerEm*float64(yMax)
+	if face.FauxItalic != 0.0 {
+		// shear the box along the X axis
+		if 0.0 < face.FauxItalic {
+			x0, x1 = x0+face.FauxItalic*y0, x1+face.FauxItalic*y1
+		} else {
+			x0, x1 = x0+face.FauxItalic*y1, x1+face.FauxItalic*y0
+		}
+	}
+	if face.FauxBold != 0.0 {
+		// the outline grows on each side and is shifted to the right, see GlyphPath
+		d := face.fauxBold()
+		y0, x1, y1 = y0-d, x1+2.0*d, y1+d
+		if x1 < x0 {
+			x0, x1 = (x0+x1)/2.0, (x0+x1)/2.0
+		}
+		if y1 < y0 {
+			y0, y1 = (y0+y1)/2.0, (y0+y1)/2.0
+		}
+	}
+	return GlyphMetrics{
+		Advance:          advance,
+		LeftSideBearing:  x0,
+		RightSideBearing: advance - x1,
+		XMin:             x0,
+		YMin:             y0,
+		XMax:             x1,
+		YMax:             y1,
+	}
 }
@@ -0,0 +1,197 @@
+package font
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// ParseBDF parses a Glyph Bitmap Distribution Format (BDF) font, see https://adobe-type-tools.github.io/font-tech-notes/pdfs/5005.BDF_Spec.pdf. Optionally parse options can be passed to limit the number of glyphs and the memory of the bitmaps.
+func ParseBDF(b []byte, opts ...ParseOptions) (*BitmapFont, error) {
+	if !bytes.HasPrefix(b, []byte("STARTFONT")) {
+		return nil, fmt.Errorf("BDF: bad header")
+	}
+	o := parseOptions(opts)
+	memory := 0 // of the bitmaps
+
+	f := &BitmapFont{
+		DefaultChar: -1,
+	}
+	var glyph *BitmapGlyph
+	var pointSize, yResolution float64
+	var bboxHeight, bboxYOffset int
+	var rows []string
+	inBitmap, inProperties := false, false
+	hasAscent, hasDescent := false, false
+
+	lines := strings.FieldsFunc(string(b), func(r rune) bool { return r == '\n' || r == '\r' })
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		keyword := fields[0]
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), keyword))
+		ints := func(n int) ([]int, error) {
+			if len(fields) < n+1 {
+				return nil, fmt.Errorf("BDF: line %d: missing values for %s", i+1, keyword)
+			}
+			vs := make([]int, n)
+			for j := range vs {
+				v, err := strconv.Atoi(fields[j+1])
+				if err != nil {
+					return nil, fmt.Errorf("BDF: line %d: bad value for %s", i+1, keyword)
+				}
+				vs[j] = v
+			}
+			return vs, nil
+		}
+
+		if inBitmap {
+			if keyword != "ENDCHAR" {
+				rows = append(rows, keyword)
+				continue
+			}
+			inBitmap = false
+		} else if inProperties {
+			if keyword == "ENDPROPERTIES" {
+				inProperties = false
+				continue
+			}
+			s, _ := strconv.Unquote(value)
+			if s == "" {
+				s = strings.Trim(value, "\"")
+			}
+			n, _ := strconv.Atoi(value)
+			switch keyword {
+			case "FAMILY_NAME":
+				f.FamilyName = s
+			case "WEIGHT_NAME":
+				f.Weight = s
+			case "SLANT":
+				f.Slant = s
+			case "COPYRIGHT":
+				f.Copyright = s
+			case "PIXEL_SIZE":
+				f.PixelSize = n
+			case "FONT_ASCENT":
+				f.Ascent, hasAscent = n, true
+			case "FONT_DESCENT":
+				f.Descent, hasDescent = n, true
+			case "DEFAULT_CHAR":
+				f.DefaultChar = n
+			case "CHARSET_REGISTRY":
+				f.Registry = s
+			case "CHARSET_ENCODING":
+				f.Encoding = s
+			}
+			continue
+		}
+
+		switch keyword {
+		case "FONT":
+			f.Name = value
+		case "SIZE":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("BDF: line %d: missing values for SIZE", i+1)
+			}
+			pointSize, _ = strconv.ParseFloat(fields[1], 64)
+			yResolution, _ = strconv.ParseFloat(fields[3], 64)
+		case "FONTBOUNDINGBOX":
+			vs, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			bboxHeight, bboxYOffset = vs[1], vs[3]
+		case "STARTPROPERTIES":
+			inProperties = true
+		case "STARTCHAR":
+			if int(o.MaxGlyphs) <= len(f.Glyphs) {
+				return nil, fmt.Errorf("BDF: too many glyphs")
+			}
+			f.Glyphs = append(f.Glyphs, BitmapGlyph{
+				Name: value,
+				Code: -1,
+			})
+			glyph = &f.Glyphs[len(f.Glyphs)-1]
+		case "ENCODING":
+			if glyph == nil {
+				return nil, fmt.Errorf("BDF: line %d: ENCODING outside of character", i+1)
+			}
+			vs, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			glyph.Code = vs[0] // -1 for glyphs that are not in the standard encoding
+		case "DWIDTH":
+			if glyph == nil {
+				return nil, fmt.Errorf("BDF: line %d: DWIDTH outside of character", i+1)
+			}
+			vs, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			glyph.Advance = vs[0]
+		case "BBX":
+			if glyph == nil {
+				return nil, fmt.Errorf("BDF: line %d: BBX outside of character", i+1)
+			}
+			vs, err := ints(4)
+			if err != nil {
+				return nil, err
+			} else if vs[0] < 0 || vs[1] < 0 || 1024 < vs[0] || 1024 < vs[1] {
+				return nil, fmt.Errorf("BDF: line %d: bad bounding box", i+1)
+			} else if memory += vs[0] * vs[1]; int64(o.MaxMemory) < int64(memory) {
+				return nil, fmt.Errorf("BDF: %w", ErrExceedsMemory)
+			}
+			glyph.Bitmap = image.NewAlpha(image.Rect(0, 0, vs[0], vs[1]))
+			glyph.XOffset, glyph.YOffset = vs[2], vs[3]
+		case "BITMAP":
+			if glyph == nil || glyph.Bitmap == nil {
+				return nil, fmt.Errorf("BDF: line %d: BITMAP without BBX", i+1)
+			}
+			inBitmap = true
+			rows = rows[:0]
+		case "ENDCHAR":
+			if glyph == nil {
+				return nil, fmt.Errorf("BDF: line %d: ENDCHAR outside of character", i+1)
+			}
+			if glyph.Bitmap != nil {
+				w, h := glyph.Bitmap.Rect.Dx(), glyph.Bitmap.Rect.Dy()
+				for y := 0; y < h && y < len(rows); y++ {
+					row, err := hex.DecodeString(rows[y])
+					if err != nil {
+						return nil, fmt.Errorf("BDF: glyph %s: bad bitmap", glyph.Name)
+					}
+					for x := 0; x < w && x/8 < len(row); x++ {
+						if row[x/8]&(0x80>>uint(x%8)) != 0 {
+							glyph.Bitmap.Pix[y*glyph.Bitmap.Stride+x] = 0xFF
+						}
+					}
+				}
+			}
+			glyph = nil
+		}
+	}
+	if inBitmap || glyph != nil {
+		return nil, fmt.Errorf("BDF: unexpected end of file")
+	} else if len(f.Glyphs) == 0 {
+		return nil, fmt.Errorf("BDF: no glyphs")
+	}
+
+	if !hasAscent {
+		f.Ascent = bboxHeight + bboxYOffset
+	}
+	if !hasDescent {
+		f.Descent = -bboxYOffset
+	}
+	if f.PixelSize == 0 && 0.0 < pointSize && 0.0 < yResolution {
+		f.PixelSize = int(pointSize*yResolution/72.0 + 0.5)
+	}
+	f.setXLFD()
+	f.setRunes()
+	return f, nil
+}
@@ -0,0 +1,395 @@
+package font
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// BitmapFont is a bitmap (pixel) font such as BDF or PCF. All metrics are in pixels, with the y-axis pointing up.
+type BitmapFont struct {
+	Name        string // XLFD name or PostScript name
+	FamilyName  string
+	Weight      string // such as Medium or Bold
+	Slant       string // R for roman, I for italic, O for oblique
+	Copyright   string
+	PixelSize   int    // size of the em
+	Ascent      int    // logical ascent above the baseline
+	Descent     int    // logical descent below the baseline, positive
+	Registry    string // character set registry, such as ISO10646 or ISO8859
+	Encoding    string // character set encoding, such as 1
+	DefaultChar int    // character code used for missing characters, -1 if not set
+	Glyphs      []BitmapGlyph
+
+	runes map[rune]int
+}
+
+// BitmapGlyph is a glyph of a bitmap font. The bitmap's lower-left corner is at (XOffset,YOffset) relative to the glyph origin on the baseline.
+type BitmapGlyph struct {
+	Name    string
+	Code    int  // character code in the font's character set, -1 if unencoded
+	Rune    rune // Unicode code point, -1 if unknown
+	Advance int
+	XOffset int
+	YOffset int
+	Bitmap  *image.Alpha // Bitmap.Rect has its origin at (0,0) and is the glyph's bounding box, the top row is the highest
+}
+
+// Width returns the bitmap width.
+func (g BitmapGlyph) Width() int {
+	if g.Bitmap == nil {
+		return 0
+	}
+	return g.Bitmap.Rect.Dx()
+}
+
+// Height returns the bitmap height.
+func (g BitmapGlyph) Height() int {
+	if g.Bitmap == nil {
+		return 0
+	}
+	return g.Bitmap.Rect.Dy()
+}
+
+// GlyphIndex returns the index into Glyphs for a rune, or -1 if the font has no glyph for it.
+func (f *BitmapFont) GlyphIndex(r rune) int {
+	if f.runes == nil {
+		f.runes = make(map[rune]int, len(f.Glyphs))
+		for i, glyph := range f.Glyphs {
+			if _, ok := f.runes[glyph.Rune]; !ok && 0 <= glyph.Rune {
+				f.runes[glyph.Rune] = i
+			}
+		}
+	}
+	if i, ok := f.runes[r]; ok {
+		return i
+	}
+	return -1
+}
+
+// TextWidth returns the width of a string in pixels, using the default character for missing runes.
+func (f *BitmapFont) TextWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if i := f.GlyphIndex(r); i != -1 {
+			width += f.Glyphs[i].Advance
+		} else if i := f.defaultGlyph(); i != -1 {
+			width += f.Glyphs[i].Advance
+		}
+	}
+	return width
+}
+
+func (f *BitmapFont) defaultGlyph() int {
+	if f.DefaultChar < 0 {
+		return -1
+	}
+	for i, glyph := range f.Glyphs {
+		if glyph.Code == f.DefaultChar {
+			return i
+		}
+	}
+	return -1
+}
+
+// setXLFD sets the missing family name, weight, slant, and character set from the XLFD font name, such as -misc-fixed-medium-r-normal--13-120-75-75-c-70-iso10646-1.
+func (f *BitmapFont) setXLFD() {
+	xlfd := strings.Split(f.Name, "-")
+	if len(xlfd) != 15 {
+		if f.FamilyName == "" {
+			f.FamilyName = f.Name
+		}
+		return
+	}
+	if f.FamilyName == "" {
+		f.FamilyName = xlfd[2]
+	}
+	if f.Weight == "" {
+		f.Weight = xlfd[3]
+	}
+	if f.Slant == "" {
+		f.Slant = xlfd[4]
+	}
+	if f.PixelSize == 0 {
+		f.PixelSize, _ = strconv.Atoi(xlfd[7])
+	}
+	if f.Registry == "" {
+		f.Registry, f.Encoding = xlfd[13], xlfd[14]
+	}
+}
+
+// setRunes sets the Unicode code point of each glyph from its character code and the font's character set, or from its name following the Adobe Glyph List if the character set is unknown.
+func (f *BitmapFont) setRunes() {
+	registry := strings.ToUpper(f.Registry)
+	charset := strings.ToUpper(f.Encoding)
+	var cm *charmap.Charmap
+	isUnicode := registry == "ISO10646" || registry == "ISO8859" && charset == "1" || registry == "ASCII"
+	if registry == "ISO8859" {
+		cm = iso8859Charmaps[charset]
+	} else if registry == "KOI8" && charset == "R" {
+		cm = charmap.KOI8R
+	} else if registry == "KOI8" && charset == "U" {
+		cm = charmap.KOI8U
+	} else if registry == "MICROSOFT" && strings.HasPrefix(charset, "CP") {
+		cm = windowsCharmaps[charset[2:]]
+	}
+
+	// two-byte character sets, where codes are given without the high bits that EUC encodings set
+	var dec *encoding.Decoder
+	euc := true
+	if strings.HasPrefix(registry, "KSC5601") {
+		dec = korean.EUCKR.NewDecoder()
+	} else if strings.HasPrefix(registry, "GB2312") {
+		dec = simplifiedchinese.GBK.NewDecoder()
+	} else if strings.HasPrefix(registry, "JISX0208") {
+		dec = japanese.EUCJP.NewDecoder()
+	} else if strings.HasPrefix(registry, "BIG5") {
+		dec, euc = traditionalchinese.Big5.NewDecoder(), false
+	}
+
+	for i, glyph := range f.Glyphs {
+		r := rune(-1)
+		if isUnicode && 0 <= glyph.Code && glyph.Code <= 0x10FFFF {
+			r = rune(glyph.Code)
+		} else if cm != nil && 0 <= glyph.Code && glyph.Code < 256 {
+			if r = cm.DecodeByte(byte(glyph.Code)); r == utf8.RuneError {
+				r = -1
+			}
+		} else if dec != nil && 0x100 <= glyph.Code && glyph.Code <= 0xFFFF {
+			b := []byte{byte(glyph.Code >> 8), byte(glyph.Code)}
+			if euc {
+				b[0] |= 0x80
+				b[1] |= 0x80
+			}
+			if s, err := dec.Bytes(b); err == nil {
+				if r2, n := utf8.DecodeRune(s); r2 != utf8.RuneError && n == len(s) {
+					r = r2
+				}
+			}
+		} else if r2, ok := glyphNameToRune(glyph.Name); ok {
+			r = r2
+		} else if 0 <= glyph.Code && registry == "" {
+			r = rune(glyph.Code)
+		}
+		f.Glyphs[i].Rune = r
+	}
+	f.runes = nil
+}
+
+var iso8859Charmaps = map[string]*charmap.Charmap{
+	"2":  charmap.ISO8859_2,
+	"3":  charmap.ISO8859_3,
+	"4":  charmap.ISO8859_4,
+	"5":  charmap.ISO8859_5,
+	"6":  charmap.ISO8859_6,
+	"7":  charmap.ISO8859_7,
+	"8":  charmap.ISO8859_8,
+	"9":  charmap.ISO8859_9,
+	"10": charmap.ISO8859_10,
+	"13": charmap.ISO8859_13,
+	"14": charmap.ISO8859_14,
+	"15": charmap.ISO8859_15,
+	"16": charmap.ISO8859_16,
+}
+
+var windowsCharmaps = map[string]*charmap.Charmap{
+	"1250": charmap.Windows1250,
+	"1251": charmap.Windows1251,
+	"1252": charmap.Windows1252,
+	"1253": charmap.Windows1253,
+	"1254": charmap.Windows1254,
+	"1255": charmap.Windows1255,
+	"1256": charmap.Windows1256,
+	"1257": charmap.Windows1257,
+	"1258": charmap.Windows1258,
+}
+
+// ToSFNT converts the bitmap font to an OpenType font with CFF outlines, where each pixel is drawn as a square so that the font renders exactly at its pixel size. Each pixel is 64 font units (less for fonts larger than 256 pixels), and glyphs without a Unicode code point are not mapped by the cmap table.
+func (f *BitmapFont) ToSFNT() ([]byte, error) {
+	if len(f.Glyphs) == 0 {
+		return nil, fmt.Errorf("bitmap: no glyphs")
+	} else if math.MaxUint16 <= len(f.Glyphs) {
+		return nil, fmt.Errorf("bitmap: too many glyphs")
+	}
+
+	em := f.PixelSize
+	if em <= 0 {
+		em = f.Ascent + f.Descent
+	}
+	if em <= 0 {
+		return nil, fmt.Errorf("bitmap: bad pixel size")
+	}
+	unit := 64
+	for 16384 < unit*em && 1 < unit {
+		unit /= 2
+	}
+	if 16384 < unit*em {
+		return nil, fmt.Errorf("bitmap: bad pixel size")
+	}
+
+	subfamilyName := f.Weight
+	if subfamilyName == "" || strings.EqualFold(subfamilyName, "Medium") || strings.EqualFold(subfamilyName, "Normal") {
+		subfamilyName = "Regular"
+	}
+	if slant := strings.ToUpper(f.Slant); slant == "I" || slant == "O" {
+		if subfamilyName == "Regular" {
+			subfamilyName = "Italic"
+		} else {
+			subfamilyName += " Italic"
+		}
+	}
+	familyName := f.FamilyName
+	if familyName == "" {
+		familyName = f.Name
+	}
+	postScriptName := strings.Map(func(r rune) rune {
+		if r <= 32 || 126 < r || strings.ContainsRune("[](){}<>/%", r) {
+			return -1
+		}
+		return r
+	}, familyName+"-"+subfamilyName)
+	if 63 < len(postScriptName) {
+		postScriptName = postScriptName[:63]
+	}
+
+	b := &sfntBuilder{
+		PostScriptName:     postScriptName,
+		FamilyName:         familyName,
+		SubfamilyName:      subfamilyName,
+		FullName:           familyName + " " + subfamilyName,
+		Copyright:          f.Copyright,
+		Weight:             f.Weight,
+		UnitsPerEm:         uint16(unit * em),
+		Ascender:           int16(unit * f.Ascent),
+		Descender:          int16(-unit * f.Descent),
+		UnderlinePosition:  int16(-unit * (f.Descent + 1) / 2),
+		UnderlineThickness: int16(unit),
+		IsFixedPitch:       true,
+	}
+
+	// .notdef is a copy of the default glyph
+	notdef := sfntBuilderGlyph{
+		Name:       ".notdef",
+		CharString: newType2CharString(0.0).Bytes(),
+	}
+	if i := f.defaultGlyph(); i != -1 {
+		notdef = f.bitmapGlyph(f.Glyphs[i], unit)
+		notdef.Name = ".notdef"
+		notdef.Runes = nil
+	}
+	b.Glyphs = append(b.Glyphs, notdef)
+
+	names := map[string]bool{".notdef": true}
+	for i, glyph := range f.Glyphs {
+		g := f.bitmapGlyph(glyph, unit)
+		if g.Name == "" || names[g.Name] {
+			g.Name = fmt.Sprintf("glyph%d", i+1)
+		}
+		names[g.Name] = true
+		if g.Advance != 0 && b.Glyphs[len(b.Glyphs)-1].Advance != 0 && g.Advance != b.Glyphs[len(b.Glyphs)-1].Advance {
+			b.IsFixedPitch = false
+		}
+		b.Glyphs = append(b.Glyphs, g)
+	}
+
+	if i := f.GlyphIndex('H'); i != -1 {
+		b.CapHeight = b.Glyphs[i+1].YMax
+	}
+	if i := f.GlyphIndex('x'); i != -1 {
+		b.XHeight = b.Glyphs[i+1].YMax
+	}
+	return b.WriteCFF(), nil
+}
+
+// bitmapGlyph returns the glyph with the pixels as outlines, horizontally adjacent pixels are merged into rectangles as are rectangles spanning the same columns in adjacent rows.
+func (f *BitmapFont) bitmapGlyph(glyph BitmapGlyph, unit int) sfntBuilderGlyph {
+	type rect struct {
+		x0, x1, y0, y1 int // in pixels relative to the bitmap's top-left, exclusive ends
+	}
+	rects := []rect{}
+	if glyph.Bitmap != nil {
+		open := map[[2]int]int{} // columns of rectangles in the previous row to their index
+		w, h := glyph.Bitmap.Rect.Dx(), glyph.Bitmap.Rect.Dy()
+		for y := 0; y < h; y++ {
+			next := map[[2]int]int{}
+			for x := 0; x < w; x++ {
+				if glyph.Bitmap.AlphaAt(x, y).A < 128 {
+					continue
+				}
+				x0 := x
+				for x+1 < w && 128 <= glyph.Bitmap.AlphaAt(x+1, y).A {
+					x++
+				}
+				span := [2]int{x0, x + 1}
+				if i, ok := open[span]; ok {
+					rects[i].y1 = y + 1
+					next[span] = i
+				} else {
+					next[span] = len(rects)
+					rects = append(rects, rect{x0, x + 1, y, y + 1})
+				}
+			}
+			open = next
+		}
+	}
+
+	g := sfntBuilderGlyph{
+		Name: glyph.Name,
+	}
+	if 0 <= glyph.Advance*unit && glyph.Advance*unit <= math.MaxUint16 {
+		g.Advance = uint16(unit * glyph.Advance)
+	}
+	if 0 <= glyph.Rune {
+		g.Runes = []rune{glyph.Rune}
+		if r, ok := glyphNameToRune(g.Name); !ok || r != glyph.Rune {
+			// use a name that maps to the same code point, instead of names such as C0041
+			if glyph.Rune < 0x10000 {
+				g.Name = fmt.Sprintf("uni%04X", glyph.Rune)
+			} else {
+				g.Name = fmt.Sprintf("u%X", glyph.Rune)
+			}
+		}
+	}
+
+	cs := newType2CharString(float64(g.Advance))
+	top := glyph.YOffset + glyph.Height() // top of the bitmap in pixels above the baseline
+	for i, r := range rects {
+		x0, x1 := float64(unit*(glyph.XOffset+r.x0)), float64(unit*(glyph.XOffset+r.x1))
+		y0, y1 := float64(unit*(top-r.y1)), float64(unit*(top-r.y0))
+		cs.MoveTo(x0, y0)
+		cs.LineTo(x1, y0)
+		cs.LineTo(x1, y1)
+		cs.LineTo(x0, y1)
+		cs.Close()
+
+		xMin, yMin, xMax, yMax := int16(x0), int16(y0), int16(x1), int16(y1)
+		if i == 0 {
+			g.XMin, g.YMin, g.XMax, g.YMax = xMin, yMin, xMax, yMax
+		} else {
+			if xMin < g.XMin {
+				g.XMin = xMin
+			}
+			if yMin < g.YMin {
+				g.YMin = yMin
+			}
+			if g.XMax < xMax {
+				g.XMax = xMax
+			}
+			if g.YMax < yMax {
+				g.YMax = yMax
+			}
+		}
+	}
+	g.CharString = cs.Bytes()
+	return g
+}
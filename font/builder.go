@@ -0,0 +1,382 @@
+package font
+
+import (
+	"fmt"
+	"math"
+)
+
+// Builder creates an OpenType font from glyph outlines, for example to generate an icon font from vector graphics. Glyphs are drawn through the Pather interface in font units with the y-axis pointing upwards. Glyph ID zero is the .notdef glyph, which is added as an empty glyph unless the first glyph added is named .notdef.
+type Builder struct {
+	PostScriptName string // derived from the family and subfamily names if empty
+	FamilyName     string
+	SubfamilyName  string // Regular if empty
+	FullName       string // derived from the family and subfamily names if empty
+	Version        string // such as "Version 1.000"
+	Copyright      string
+	Weight         string // weight name such as Light or Bold
+
+	UnitsPerEm         uint16
+	Ascender           int16 // derived from the glyph bounds if both the ascender and descender are zero
+	Descender          int16 // negative below the baseline
+	LineGap            int16
+	CapHeight          int16   // derived from the glyph for H if zero
+	XHeight            int16   // derived from the glyph for x if zero
+	ItalicAngle        float64 // in degrees counter-clockwise from the vertical, negative for forward slants
+	UnderlinePosition  int16
+	UnderlineThickness int16
+
+	glyphs []*BuilderGlyph
+}
+
+// NewBuilder returns a font builder for the given family name and units per em.
+func NewBuilder(familyName string, unitsPerEm uint16) *Builder {
+	return &Builder{
+		FamilyName:         familyName,
+		SubfamilyName:      "Regular",
+		Version:            "Version 1.000",
+		UnitsPerEm:         unitsPerEm,
+		UnderlinePosition:  -int16(unitsPerEm / 10),
+		UnderlineThickness: int16(unitsPerEm / 20),
+	}
+}
+
+// AddGlyph adds a glyph with the given name, advance width, and the code points that map to it. The glyph's outline is drawn on the returned glyph. Glyphs without a name are named after their glyph ID.
+func (b *Builder) AddGlyph(name string, advance uint16, runes ...rune) *BuilderGlyph {
+	glyph := &BuilderGlyph{
+		Name:    name,
+		Runes:   runes,
+		Advance: advance,
+	}
+	b.glyphs = append(b.glyphs, glyph)
+	return glyph
+}
+
+// NumGlyphs returns the number of glyphs added, excluding the .notdef glyph if it is added implicitly.
+func (b *Builder) NumGlyphs() int {
+	return len(b.glyphs)
+}
+
+// WriteTrueType writes an OpenType font with TrueType outlines. Cubic Béziers are approximated by quadratic Béziers and all coordinates are rounded to integers.
+func (b *Builder) WriteTrueType() ([]byte, error) {
+	sfnt, err := b.build(true)
+	if err != nil {
+		return nil, err
+	}
+	return sfnt.WriteTrueType(), nil
+}
+
+// WriteCFF writes an OpenType font with CFF outlines.
+func (b *Builder) WriteCFF() ([]byte, error) {
+	sfnt, err := b.build(false)
+	if err != nil {
+		return nil, err
+	}
+	return sfnt.WriteCFF(), nil
+}
+
+func (b *Builder) build(isTrueType bool) (*sfntBuilder, error) {
+	if b.UnitsPerEm < 16 || 16384 < b.UnitsPerEm {
+		return nil, fmt.Errorf("builder: bad units per em")
+	} else if b.FamilyName == "" {
+		return nil, fmt.Errorf("builder: missing family name")
+	}
+
+	glyphs := b.glyphs
+	if len(glyphs) == 0 || glyphs[0].Name != ".notdef" {
+		glyphs = append([]*BuilderGlyph{{Name: ".notdef", Advance: b.UnitsPerEm / 2}}, glyphs...)
+	}
+	if math.MaxUint16 < len(glyphs) {
+		return nil, fmt.Errorf("builder: too many glyphs")
+	}
+
+	subfamilyName := b.SubfamilyName
+	if subfamilyName == "" {
+		subfamilyName = "Regular"
+	}
+	fullName := b.FullName
+	if fullName == "" {
+		fullName = b.FamilyName + " " + subfamilyName
+	}
+	psName := b.PostScriptName
+	if psName == "" {
+		psName = postScriptName(b.FamilyName + "-" + subfamilyName)
+	}
+	if 63 < len(psName) {
+		psName = psName[:63]
+	}
+
+	sfnt := &sfntBuilder{
+		PostScriptName:     psName,
+		FamilyName:         b.FamilyName,
+		SubfamilyName:      subfamilyName,
+		FullName:           fullName,
+		Version:            b.Version,
+		Copyright:          b.Copyright,
+		Weight:             b.Weight,
+		UnitsPerEm:         b.UnitsPerEm,
+		Ascender:           b.Ascender,
+		Descender:          b.Descender,
+		LineGap:            b.LineGap,
+		CapHeight:          b.CapHeight,
+		XHeight:            b.XHeight,
+		ItalicAngle:        b.ItalicAngle,
+		UnderlinePosition:  b.UnderlinePosition,
+		UnderlineThickness: b.UnderlineThickness,
+		IsFixedPitch:       true,
+		Glyphs:             make([]sfntBuilderGlyph, len(glyphs)),
+	}
+
+	names := map[string]bool{}
+	fixedAdvance := uint16(0) // first non-zero advance
+	for glyphID, glyph := range glyphs {
+		g := sfntBuilderGlyph{
+			Name:    glyph.Name,
+			Advance: glyph.Advance,
+		}
+		if 0 < glyphID {
+			g.Runes = glyph.Runes
+			if g.Name == "" || g.Name == ".notdef" || names[g.Name] {
+				g.Name = fmt.Sprintf("glyph%d", glyphID)
+			}
+		}
+		names[g.Name] = true
+
+		if isTrueType {
+			p := &glyfPather{}
+			glyph.draw(p)
+			contour, err := p.Contour()
+			if err != nil {
+				return nil, fmt.Errorf("builder: glyph %s: %w", g.Name, err)
+			} else if 0 < len(contour.EndPoints) {
+				g.Glyf = writeGlyfSimple(contour)
+				g.XMin, g.YMin, g.XMax, g.YMax = glyfPointsBounds(contour)
+			}
+		} else {
+			bbox := &bboxPather{}
+			glyph.draw(bbox)
+			cs := newType2CharString(float64(glyph.Advance))
+			glyph.draw(cs)
+			g.CharString = cs.Bytes()
+			if bbox.hasPoints {
+				g.XMin = int16(math.Floor(bbox.xMin))
+				g.YMin = int16(math.Floor(bbox.yMin))
+				g.XMax = int16(math.Ceil(bbox.xMax))
+				g.YMax = int16(math.Ceil(bbox.yMax))
+			}
+		}
+		if g.Advance != 0 {
+			if fixedAdvance == 0 {
+				fixedAdvance = g.Advance
+			} else if g.Advance != fixedAdvance {
+				sfnt.IsFixedPitch = false
+			}
+		}
+		sfnt.Glyphs[glyphID] = g
+	}
+
+	// vertical metrics
+	runes := sfnt.runes()
+	if sfnt.Ascender == 0 && sfnt.Descender == 0 {
+		_, sfnt.Descender, _, sfnt.Ascender = sfnt.bounds()
+	}
+	if glyphID, ok := runes['H']; ok && sfnt.CapHeight == 0 {
+		sfnt.CapHeight = sfnt.Glyphs[glyphID].YMax
+	}
+	if glyphID, ok := runes['x']; ok && sfnt.XHeight == 0 {
+		sfnt.XHeight = sfnt.Glyphs[glyphID].YMax
+	}
+	return sfnt, nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// BuilderGlyph is a glyph of a Builder. Its outline is drawn in font units through the Pather interface, contours are closed implicitly.
+type BuilderGlyph struct {
+	Name    string
+	Runes   []rune
+	Advance uint16
+
+	cmds []builderCommand
+}
+
+type builderCommand struct {
+	op     byte // M, L, Q, C, or z
+	coords []float64
+}
+
+// MoveTo starts a new contour at (x,y).
+func (glyph *BuilderGlyph) MoveTo(x, y float64) {
+	glyph.cmds = append(glyph.cmds, builderCommand{'M', []float64{x, y}})
+}
+
+// LineTo adds a line to (x,y).
+func (glyph *BuilderGlyph) LineTo(x, y float64) {
+	glyph.cmds = append(glyph.cmds, builderCommand{'L', []float64{x, y}})
+}
+
+// QuadTo adds a quadratic Bézier with control point (cpx,cpy) to (x,y).
+func (glyph *BuilderGlyph) QuadTo(cpx, cpy, x, y float64) {
+	glyph.cmds = append(glyph.cmds, builderCommand{'Q', []float64{cpx, cpy, x, y}})
+}
+
+// CubeTo adds a cubic Bézier with control points (cpx1,cpy1) and (cpx2,cpy2) to (x,y).
+func (glyph *BuilderGlyph) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	glyph.cmds = append(glyph.cmds, builderCommand{'C', []float64{cpx1, cpy1, cpx2, cpy2, x, y}})
+}
+
+// Close closes the current contour.
+func (glyph *BuilderGlyph) Close() {
+	glyph.cmds = append(glyph.cmds, builderCommand{'z', nil})
+}
+
+func (glyph *BuilderGlyph) draw(p Pather) {
+	for _, cmd := range glyph.cmds {
+		switch cmd.op {
+		case 'M':
+			p.MoveTo(cmd.coords[0], cmd.coords[1])
+		case 'L':
+			p.LineTo(cmd.coords[0], cmd.coords[1])
+		case 'Q':
+			p.QuadTo(cmd.coords[0], cmd.coords[1], cmd.coords[2], cmd.coords[3])
+		case 'C':
+			p.CubeTo(cmd.coords[0], cmd.coords[1], cmd.coords[2], cmd.coords[3], cmd.coords[4], cmd.coords[5])
+		case 'z':
+			p.Close()
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// glyfPather converts a path to the contours of a simple TrueType glyph. Cubic Béziers are approximated by quadratic Béziers within a tolerance of a quarter font unit.
+type glyfPather struct {
+	contours [][]glyfPoint
+	x0, y0   float64 // start of the current contour
+	x, y     float64
+	closed   bool
+}
+
+type glyfPoint struct {
+	x, y    float64
+	onCurve bool
+}
+
+func (p *glyfPather) add(x, y float64, onCurve bool) {
+	if len(p.contours) == 0 || p.closed {
+		p.contours = append(p.contours, []glyfPoint{{p.x, p.y, true}})
+		p.x0, p.y0 = p.x, p.y
+		p.closed = false
+	}
+	p.contours[len(p.contours)-1] = append(p.contours[len(p.contours)-1], glyfPoint{x, y, onCurve})
+	if onCurve {
+		p.x, p.y = x, y
+	}
+}
+
+func (p *glyfPather) MoveTo(x float64, y float64) {
+	p.contours = append(p.contours, []glyfPoint{{x, y, true}})
+	p.x0, p.y0 = x, y
+	p.x, p.y = x, y
+	p.closed = false
+}
+
+func (p *glyfPather) LineTo(x float64, y float64) {
+	p.add(x, y, true)
+}
+
+func (p *glyfPather) QuadTo(cpx float64, cpy float64, x float64, y float64) {
+	p.add(cpx, cpy, false)
+	p.add(x, y, true)
+}
+
+func (p *glyfPather) CubeTo(cpx1 float64, cpy1 float64, cpx2 float64, cpy2 float64, x float64, y float64) {
+	// split into n segments so that the error of each quadratic approximation is within the tolerance, see http://www.caffeineowl.com/graphics/2d/vectorial/cubic2quad01.html
+	const tolerance = 0.25
+	x0, y0 := p.x, p.y
+	dx, dy := x-3.0*cpx2+3.0*cpx1-x0, y-3.0*cpy2+3.0*cpy1-y0
+	n := math.Ceil(math.Cbrt(math.Sqrt(3.0) / 36.0 * math.Hypot(dx, dy) / tolerance))
+	n = math.Max(1.0, math.Min(n, 64.0))
+
+	point := func(t float64) (float64, float64) {
+		mt := 1.0 - t
+		a, b, c, d := mt*mt*mt, 3.0*mt*mt*t, 3.0*mt*t*t, t*t*t
+		return a*x0 + b*cpx1 + c*cpx2 + d*x, a*y0 + b*cpy1 + c*cpy2 + d*y
+	}
+	derivative := func(t float64) (float64, float64) {
+		mt := 1.0 - t
+		a, b, c := 3.0*mt*mt, 6.0*mt*t, 3.0*t*t
+		return a*(cpx1-x0) + b*(cpx2-cpx1) + c*(x-cpx2), a*(cpy1-y0) + b*(cpy2-cpy1) + c*(y-cpy2)
+	}
+	for i := 0.0; i < n; i++ {
+		t0, t1 := i/n, (i+1.0)/n
+		ax, ay := point(t0)
+		bx, by := point(t1)
+		if i+1.0 == n {
+			bx, by = x, y
+		}
+		dax, day := derivative(t0)
+		dbx, dby := derivative(t1)
+
+		// control points of the cubic sub-segment, the quadratic control point lies between its two control points
+		c1x, c1y := ax+(t1-t0)/3.0*dax, ay+(t1-t0)/3.0*day
+		c2x, c2y := bx-(t1-t0)/3.0*dbx, by-(t1-t0)/3.0*dby
+		qx, qy := (3.0*(c1x+c2x)-ax-bx)/4.0, (3.0*(c1y+c2y)-ay-by)/4.0
+		p.QuadTo(qx, qy, bx, by)
+	}
+}
+
+func (p *glyfPather) Close() {
+	p.x, p.y = p.x0, p.y0
+	p.closed = true
+}
+
+// Contour returns the contours with rounded coordinates. Duplicate points are removed, as are on-curve points that lie halfway between two off-curve points since these are implied.
+func (p *glyfPather) Contour() (*glyfContour, error) {
+	contour := &glyfContour{}
+	for _, points := range p.contours {
+		for i := range points {
+			points[i].x = math.Round(points[i].x)
+			points[i].y = math.Round(points[i].y)
+		}
+
+		// remove the closing point and duplicate on-curve points
+		if 1 < len(points) {
+			first, last := points[0], points[len(points)-1]
+			if last.onCurve && last.x == first.x && last.y == first.y {
+				points = points[:len(points)-1]
+			}
+		}
+		for i := 1; i < len(points); i++ {
+			if points[i].onCurve && points[i-1].onCurve && points[i].x == points[i-1].x && points[i].y == points[i-1].y {
+				points = append(points[:i], points[i+1:]...)
+				i--
+			}
+		}
+
+		// remove implied on-curve points
+		for i := 0; i < len(points) && 2 < len(points); i++ {
+			prev, next := points[(i+len(points)-1)%len(points)], points[(i+1)%len(points)]
+			if points[i].onCurve && !prev.onCurve && !next.onCurve && prev.x+next.x == 2.0*points[i].x && prev.y+next.y == 2.0*points[i].y {
+				points = append(points[:i], points[i+1:]...)
+				i--
+			}
+		}
+		if len(points) < 2 {
+			continue
+		}
+
+		for _, point := range points {
+			if point.x < math.MinInt16 || math.MaxInt16 < point.x || point.y < math.MinInt16 || math.MaxInt16 < point.y {
+				return nil, fmt.Errorf("coordinates out of range")
+			}
+			contour.XCoordinates = append(contour.XCoordinates, int16(point.x))
+			contour.YCoordinates = append(contour.YCoordinates, int16(point.y))
+			contour.OnCurve = append(contour.OnCurve, point.onCurve)
+		}
+		if math.MaxUint16 < len(contour.XCoordinates) {
+			return nil, fmt.Errorf("too many points")
+		}
+		contour.EndPoints = append(contour.EndPoints, uint16(len(contour.XCoordinates)-1))
+	}
+	return contour, nil
+}
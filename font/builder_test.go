@@ -0,0 +1,33 @@
+package font
+
+import (
+	"testing"
+)
+
+func TestBuilderFixedPitch(t *testing.T) {
+	var tests = []struct {
+		advances     []uint16
+		isFixedPitch bool
+	}{
+		{[]uint16{500, 500}, true},
+		{[]uint16{500, 0, 500}, true}, // zero advances are ignored
+		{[]uint16{500, 0, 600}, false},
+		{[]uint16{0, 600}, false}, // .notdef has an advance of 500
+	}
+	for _, tt := range tests {
+		builder := NewBuilder("Test", 1000)
+		for i, advance := range tt.advances {
+			builder.AddGlyph(string(rune('a'+i)), advance, rune('a'+i))
+		}
+		b, err := builder.WriteTrueType()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sfnt, err := ParseSFNT(b, 0)
+		if err != nil {
+			t.Fatal(err)
+		} else if isFixedPitch := sfnt.Post.IsFixedPitch != 0; isFixedPitch != tt.isFixedPitch {
+			t.Fatalf("%v: isFixedPitch is %v, expected %v", tt.advances, isFixedPitch, tt.isFixedPitch)
+		}
+	}
+}
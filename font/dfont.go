@@ -0,0 +1,185 @@
+package font
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// macResourceFork returns the resource fork of a data fork suitcase (dfont), or extracts it from an AppleSingle or AppleDouble file. It returns false if no valid resource fork header is found.
+func macResourceFork(b []byte) ([]byte, bool) {
+	if 26 <= len(b) {
+		if magic := binary.BigEndian.Uint32(b); magic == 0x00051600 || magic == 0x00051607 {
+			// AppleSingle or AppleDouble, see RFC 1740
+			r := NewBinaryReader(b)
+			_ = r.ReadUint32()  // magic
+			_ = r.ReadUint32()  // version
+			_ = r.ReadBytes(16) // filler
+			numEntries := r.ReadUint16()
+			for i := 0; i < int(numEntries) && !r.EOF(); i++ {
+				entryID := r.ReadUint32()
+				offset := r.ReadUint32()
+				length := r.ReadUint32()
+				if r.EOF() || uint32(len(b)) < offset || uint32(len(b))-offset < length {
+					return nil, false
+				} else if entryID == 2 {
+					b = b[offset : offset+length]
+					break
+				}
+			}
+		}
+	}
+
+	if len(b) < 16 {
+		return nil, false
+	}
+	dataOffset := binary.BigEndian.Uint32(b[0:])
+	mapOffset := binary.BigEndian.Uint32(b[4:])
+	dataLength := binary.BigEndian.Uint32(b[8:])
+	mapLength := binary.BigEndian.Uint32(b[12:])
+	n := uint32(len(b))
+	if dataOffset < 16 || n < dataOffset || n-dataOffset < dataLength || mapOffset < 16 || n < mapOffset || n-mapOffset < mapLength || mapLength < 30 {
+		return nil, false
+	}
+
+	// the resource map starts with a copy of the header, or with zeros
+	header := b[mapOffset : mapOffset+16]
+	for i := range header {
+		if header[i] != b[i] && header[i] != 0 {
+			return nil, false
+		}
+	}
+	if typeListOffset := binary.BigEndian.Uint16(b[mapOffset+24:]); mapLength < uint32(typeListOffset)+2 {
+		return nil, false
+	}
+	return b, true
+}
+
+func isDFont(b []byte) bool {
+	_, ok := macResourceFork(b)
+	return ok
+}
+
+// ParseDFont parses a Mac resource fork font, such as a data fork suitcase (.dfont) or the resource fork of a font suitcase exported as AppleSingle or AppleDouble, and returns its contained SFNT fonts (TTF or OTF). When the suitcase contains multiple fonts, they are returned as a font collection (TTC) ordered by resource ID. Bitmap-only (FONT/NFNT) suitcases are not supported. Optionally parse options can be passed to limit the size of the returned font.
+func ParseDFont(b []byte, opts ...ParseOptions) ([]byte, error) {
+	o := parseOptions(opts)
+	b, ok := macResourceFork(b)
+	if !ok {
+		return nil, fmt.Errorf("bad resource fork header")
+	}
+
+	r := NewBinaryReader(b)
+	dataOffset := r.ReadUint32()
+	mapOffset := r.ReadUint32()
+	dataLength := r.ReadUint32()
+	_ = r.ReadUint32() // mapLength
+
+	r.Seek(mapOffset + 24)
+	typeListOffset := mapOffset + uint32(r.ReadUint16())
+	_ = r.ReadUint16() // nameListOffset
+
+	r.Seek(typeListOffset)
+	numTypes := r.ReadUint16() + 1 // stored as number of types minus one, 0xFFFF means none
+	type resource struct {
+		id   int16
+		data []byte
+	}
+	resources := []resource{}
+	for i := 0; i < int(numTypes); i++ {
+		resourceType := r.ReadString(4)
+		numResources := uint32(r.ReadUint16()) + 1
+		refListOffset := typeListOffset + uint32(r.ReadUint16())
+		if r.EOF() {
+			return nil, ErrInvalidFontData
+		} else if resourceType != "sfnt" {
+			continue
+		}
+
+		pos := r.Pos()
+		r.Seek(refListOffset)
+		for j := uint32(0); j < numResources; j++ {
+			id := r.ReadInt16()
+			_ = r.ReadUint16() // nameOffset
+			offset := r.ReadUint32() & 0x00FFFFFF
+			_ = r.ReadUint32() // handle
+			if r.EOF() || dataLength < offset || dataLength-offset < 4 {
+				return nil, ErrInvalidFontData
+			}
+
+			offset += dataOffset
+			length := binary.BigEndian.Uint32(b[offset:])
+			if dataLength-(offset-dataOffset)-4 < length {
+				return nil, ErrInvalidFontData
+			}
+			resources = append(resources, resource{id, b[offset+4 : offset+4+length : offset+4+length]})
+		}
+		r.Seek(pos)
+	}
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("no sfnt resources")
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].id < resources[j].id
+	})
+	fonts := make([][]byte, len(resources))
+	for i, resource := range resources {
+		fonts[i] = resource.data
+	}
+	return writeCollection(fonts, o.MaxMemory)
+}
+
+// writeCollection returns a font collection (TTC) of the given SFNT fonts, or the font itself if there is only one. Table offsets are rewritten to be relative to the start of the collection. The size of the collection is limited to maxMemory.
+func writeCollection(fonts [][]byte, maxMemory uint32) ([]byte, error) {
+	for _, font := range fonts {
+		if len(font) < 12 {
+			return nil, ErrInvalidFontData
+		} else if sfntVersion := string(font[:4]); sfntVersion != "OTTO" && sfntVersion != "true" && binary.BigEndian.Uint32(font) != 0x00010000 {
+			return nil, fmt.Errorf("bad SFNT version")
+		}
+	}
+	if len(fonts) == 1 {
+		return fonts[0], nil
+	}
+
+	offset := uint64(12 + 4*len(fonts))
+	offsets := make([]uint32, len(fonts))
+	for i, font := range fonts {
+		offset = (offset + 3) &^ 3
+		offsets[i] = uint32(offset)
+		if offset += uint64(len(font)); uint64(maxMemory) < offset {
+			return nil, ErrExceedsMemory
+		}
+	}
+
+	w := NewBinaryWriter(make([]byte, 0, offset))
+	w.WriteString("ttcf")
+	w.WriteUint16(1) // majorVersion
+	w.WriteUint16(0) // minorVersion
+	w.WriteUint32(uint32(len(fonts)))
+	for _, offset := range offsets {
+		w.WriteUint32(offset)
+	}
+	for i, font := range fonts {
+		for w.Len() < offsets[i] {
+			w.WriteByte(0)
+		}
+
+		numTables := uint32(binary.BigEndian.Uint16(font[4:]))
+		if uint32(len(font)) < 12+16*numTables {
+			return nil, ErrInvalidFontData
+		}
+		w.WriteBytes(font)
+		b := w.Bytes()[offsets[i]:]
+		for j := uint32(0); j < numTables; j++ {
+			record := b[12+16*j:]
+			tableOffset := binary.BigEndian.Uint32(record[8:])
+			tableLength := binary.BigEndian.Uint32(record[12:])
+			if uint32(len(font)) < tableOffset || uint32(len(font))-tableOffset < tableLength {
+				return nil, ErrInvalidFontData
+			}
+			binary.BigEndian.PutUint32(record[8:], offsets[i]+tableOffset)
+		}
+	}
+	return w.Bytes(), nil
+}
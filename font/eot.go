@@ -1,17 +1,22 @@
 package font
 
 import (
+	"encoding/binary"
 	"fmt"
+	"unicode/utf16"
 )
 
-// ParseEOT parses the EOT font format and returns its contained SFNT font format (TTF or OTF). See https://www.w3.org/Submission/EOT/
-func ParseEOT(b []byte) ([]byte, error) {
+// ParseEOT parses the EOT font format and returns its contained SFNT font format (TTF or OTF). See https://www.w3.org/Submission/EOT/. Optionally parse options can be passed to limit the size of the font data.
+func ParseEOT(b []byte, opts ...ParseOptions) ([]byte, error) {
+	o := parseOptions(opts)
 	r := NewBinaryReader(b)
 	_ = r.ReadUint32LE()             // EOTSize
 	fontDataSize := r.ReadUint32LE() // FontDataSize
 	version := r.ReadUint32LE()      // Version
 	if version != 0x00010000 && version != 0x00020001 && version != 0x00020002 {
 		return nil, fmt.Errorf("unsupported version")
+	} else if o.MaxMemory < fontDataSize {
+		return nil, ErrExceedsMemory
 	}
 	flags := r.ReadUint32LE()       // Flags
 	_ = r.ReadBytes(10)             // FontPANOSE
@@ -90,3 +95,63 @@ func ParseEOT(b []byte) ([]byte, error) {
 	//binary.BigEndian.PutUint32(buf[iCheckSumAdjustment:], checkSumAdjustment)
 	return fontData, nil
 }
+
+// ToEOT converts an SFNT font file (TTF or OTF) to the uncompressed EOT font format of version 0x00020001, which is only supported by older versions of Internet Explorer. The family, style, version, and full names are copied from the name table.
+func ToEOT(b []byte) ([]byte, error) {
+	sfnt, err := ParseSFNT(b, 0)
+	if err != nil {
+		return nil, err
+	} else if sfnt.OS2 == nil || len(sfnt.Tables["OS/2"]) < 42 {
+		return nil, fmt.Errorf("OS/2: missing table")
+	}
+	names := sfnt.Names()
+
+	italic := byte(0)
+	if sfnt.OS2.FsSelection&0x0001 != 0 {
+		italic = 1
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint32LE(0) // EOTSize
+	w.WriteUint32LE(uint32(len(b)))
+	w.WriteUint32LE(0x00020001) // Version
+	w.WriteUint32LE(0)          // Flags
+	w.WriteBytes(sfnt.Tables["OS/2"][32:42])
+	w.WriteByte(0x01) // Charset, DEFAULT_CHARSET
+	w.WriteByte(italic)
+	w.WriteUint32LE(uint32(sfnt.OS2.UsWeightClass))
+	w.WriteUint16LE(sfnt.OS2.FsType)
+	w.WriteUint16LE(0x504C) // MagicNumber
+	w.WriteUint32LE(sfnt.OS2.UlUnicodeRange1)
+	w.WriteUint32LE(sfnt.OS2.UlUnicodeRange2)
+	w.WriteUint32LE(sfnt.OS2.UlUnicodeRange3)
+	w.WriteUint32LE(sfnt.OS2.UlUnicodeRange4)
+	w.WriteUint32LE(sfnt.OS2.UlCodePageRange1)
+	w.WriteUint32LE(sfnt.OS2.UlCodePageRange2)
+	checkSumAdjustment := binary.BigEndian.Uint32(sfnt.Tables["head"][8:])
+	w.WriteUint32LE(checkSumAdjustment)
+	w.WriteBytes(make([]byte, 16)) // Reserved
+	for _, name := range []string{names.Family, names.Subfamily, names.Version, names.FullName} {
+		w.WriteUint16LE(0) // Padding
+		writeEOTString(w, name)
+	}
+	w.WriteUint16LE(0) // Padding5
+	w.WriteUint16LE(0) // RootStringSize
+	w.WriteBytes(b)
+
+	buf := w.Bytes()
+	binary.LittleEndian.PutUint32(buf, uint32(len(buf)))
+	return buf, nil
+}
+
+// writeEOTString writes the size in bytes and the UTF-16LE encoding of a string.
+func writeEOTString(w *BinaryWriter, s string) {
+	chars := utf16.Encode([]rune(s))
+	if 0x7FFF < len(chars) {
+		chars = chars[:0x7FFF]
+	}
+	w.WriteUint16LE(uint16(2 * len(chars)))
+	for _, c := range chars {
+		w.WriteUint16LE(c)
+	}
+}
@@ -31,6 +31,14 @@ func MediaType(b []byte) (string, error) {
 		return "font/opentype", nil
 	} else if 36 < len(b) && binary.LittleEndian.Uint16(b[34:36]) == 0x504C {
 		return "font/eot", nil
+	} else if isType1(b) {
+		return "font/type1", nil
+	} else if bytes.HasPrefix(b, []byte("STARTFONT")) {
+		return "font/bdf", nil
+	} else if isPCF(b) {
+		return "font/pcf", nil
+	} else if isDFont(b) {
+		return "font/dfont", nil
 	}
 	return "", fmt.Errorf("unrecognized font file format")
 }
@@ -52,12 +60,26 @@ func Extension(b []byte) string {
 		return ".woff2"
 	case "font/eot":
 		return ".eot"
+	case "font/type1":
+		if b[0] == 0x80 {
+			return ".pfb"
+		}
+		return ".pfa"
+	case "font/bdf":
+		return ".bdf"
+	case "font/pcf":
+		if b[0] == 0x1F {
+			return ".pcf.gz"
+		}
+		return ".pcf"
+	case "font/dfont":
+		return ".dfont"
 	}
 	return ""
 }
 
-// ToSFNT takes a byte slice and transforms it into an SFNT byte slice. That is, given TTF/OTF/WOFF/WOFF2/EOT input, it will return TTF/OTF output.
-func ToSFNT(b []byte) ([]byte, error) {
+// ToSFNT takes a byte slice and transforms it into an SFNT byte slice. That is, given TTF/OTF/WOFF/WOFF2/EOT/PFA/PFB/BDF/PCF/dfont input, it will return TTF/OTF/TTC output. Type 1 fonts (PFA/PFB) and bitmap fonts (BDF/PCF) are converted to OpenType with CFF outlines, and Mac resource fork suitcases (dfont) with multiple fonts are returned as a font collection. Optionally, parse options can be passed to set the limits used for decoding.
+func ToSFNT(b []byte, opts ...ParseOptions) ([]byte, error) {
 	mediatype, err := MediaType(b)
 	if err != nil {
 		return nil, err
@@ -68,43 +90,66 @@ func ToSFNT(b []byte) ([]byte, error) {
 	case "font/opentype":
 		return b, nil
 	case "font/woff":
-		if b, err = ParseWOFF(b); err != nil {
+		if b, err = ParseWOFF(b, opts...); err != nil {
 			return nil, fmt.Errorf("WOFF: %w", err)
 		}
 		return b, nil
 	case "font/woff2":
-		if b, err = ParseWOFF2(b); err != nil {
+		if b, err = ParseWOFF2(b, opts...); err != nil {
 			return nil, fmt.Errorf("WOFF2: %w", err)
 		}
 		return b, nil
 	case "font/eot":
-		if b, err = ParseEOT(b); err != nil {
+		if b, err = ParseEOT(b, opts...); err != nil {
 			return nil, fmt.Errorf("EOT: %w", err)
 		}
 		return b, nil
+	case "font/type1":
+		t1, err := ParseType1(b, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return t1.ToSFNT()
+	case "font/bdf":
+		bitmap, err := ParseBDF(b, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return bitmap.ToSFNT()
+	case "font/pcf":
+		bitmap, err := ParsePCF(b, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return bitmap.ToSFNT()
+	case "font/dfont":
+		if b, err = ParseDFont(b, opts...); err != nil {
+			return nil, fmt.Errorf("dfont: %w", err)
+		}
+		return b, nil
 	}
 	return nil, fmt.Errorf("unrecognized font file format")
 }
 
-// NewSFNTReader takes an io.Reader and transforms it into an SFNT reader. That is, given TTF/OTF/WOFF/WOFF2/EOT input, it will return TTF/OTF output.
-func NewSFNTReader(r io.Reader) (*bytes.Reader, error) {
+// NewSFNTReader takes an io.Reader and transforms it into an SFNT reader. That is, given TTF/OTF/WOFF/WOFF2/EOT/PFA/PFB/BDF/PCF/dfont input, it will return TTF/OTF/TTC output. Optionally, parse options can be passed to set the limits used for decoding.
+func NewSFNTReader(r io.Reader, opts ...ParseOptions) (*bytes.Reader, error) {
 	b, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	if b, err = ToSFNT(b); err != nil {
+	if b, err = ToSFNT(b, opts...); err != nil {
 		return nil, err
 	}
 	return bytes.NewReader(b), nil
 }
 
-// ParseFont parses a byte slice and of a TTF, OTF, WOFF, WOFF2, or EOT font format. It will return the parsed font and its mimetype.
-func ParseFont(b []byte, index int) (*SFNT, error) {
-	sfntBytes, err := ToSFNT(b)
+// ParseFont parses a byte slice and of a TTF, OTF, WOFF, WOFF2, EOT, PFA, PFB, BDF, PCF, or dfont font format. The index is used for font collections (TTC and dfont) to select a single font. It will return the parsed font and its mimetype. Optionally, parse options can be passed to set limits and required tables.
+func ParseFont(b []byte, index int, opts ...ParseOptions) (*SFNT, error) {
+	sfntBytes, err := ToSFNT(b, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return ParseSFNT(sfntBytes, index)
+	return ParseSFNT(sfntBytes, index, opts...)
 }
 
 // FromGoFreetype parses a structure from truetype.Font to a valid SFNT byte slice.
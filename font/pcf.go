@@ -0,0 +1,398 @@
+package font
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	pcfProperties      = 1 << 0
+	pcfAccelerators    = 1 << 1
+	pcfMetrics         = 1 << 2
+	pcfBitmaps         = 1 << 3
+	pcfInkMetrics      = 1 << 4
+	pcfBDFEncodings    = 1 << 5
+	pcfSWidths         = 1 << 6
+	pcfGlyphNames      = 1 << 7
+	pcfBDFAccelerators = 1 << 8
+
+	pcfCompressedMetrics = 0x00000100
+	pcfByteMSBFirst      = 1 << 2
+	pcfBitMSBFirst       = 1 << 3
+)
+
+// isPCF returns true if the file is a PCF font, optionally compressed with gzip.
+func isPCF(b []byte) bool {
+	if 2 <= len(b) && b[0] == 0x1F && b[1] == 0x8B {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return false
+		}
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(zr, header); err != nil {
+			return false
+		}
+		b = header
+	}
+	return 4 <= len(b) && string(b[:4]) == "\x01fcp"
+}
+
+type pcfTableRecord struct {
+	format uint32
+	size   uint32
+	offset uint32
+}
+
+// pcfReader reads the values of a PCF table, whose byte order depends on the table's format.
+type pcfReader struct {
+	b     []byte
+	pos   uint32
+	order binary.ByteOrder
+	err   error
+}
+
+func (r *pcfReader) read(n uint32) []byte {
+	if r.err != nil || uint32(len(r.b))-r.pos < n || uint32(len(r.b)) < r.pos {
+		r.err = ErrInvalidFontData
+		return make([]byte, n)
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *pcfReader) Uint8() uint8 {
+	return r.read(1)[0]
+}
+
+func (r *pcfReader) Int16() int16 {
+	return int16(r.order.Uint16(r.read(2)))
+}
+
+func (r *pcfReader) Uint16() uint16 {
+	return r.order.Uint16(r.read(2))
+}
+
+func (r *pcfReader) Int32() int32 {
+	return int32(r.order.Uint32(r.read(4)))
+}
+
+func (r *pcfReader) Uint32() uint32 {
+	return r.order.Uint32(r.read(4))
+}
+
+type pcfMetric struct {
+	leftSideBearing, rightSideBearing int
+	width                             int
+	ascent, descent                   int
+}
+
+// ParsePCF parses a Portable Compiled Format (PCF) font as used by X11, which may be compressed with gzip (.pcf.gz), see https://fontforge.org/docs/techref/pcf-format.html. Optionally parse options can be passed to limit the number of glyphs and the memory of the decompressed font and the bitmaps.
+func ParsePCF(b []byte, opts ...ParseOptions) (*BitmapFont, error) {
+	o := parseOptions(opts)
+	if 2 <= len(b) && b[0] == 0x1F && b[1] == 0x8B {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("PCF: %w", err)
+		}
+		if b, err = ioutil.ReadAll(io.LimitReader(zr, int64(o.MaxMemory)+1)); err != nil {
+			return nil, fmt.Errorf("PCF: %w", err)
+		} else if int64(o.MaxMemory) < int64(len(b)) {
+			return nil, fmt.Errorf("PCF: %w", ErrExceedsMemory)
+		}
+	}
+	if len(b) < 8 || string(b[:4]) != "\x01fcp" {
+		return nil, fmt.Errorf("PCF: bad header")
+	}
+
+	rh := NewBinaryReader(b[4:])
+	tableCount := rh.ReadUint32LE()
+	if rh.Len()/16 < tableCount {
+		return nil, ErrInvalidFontData
+	}
+	tables := map[uint32]pcfTableRecord{}
+	for i := uint32(0); i < tableCount; i++ {
+		typ := rh.ReadUint32LE()
+		record := pcfTableRecord{
+			format: rh.ReadUint32LE(),
+			size:   rh.ReadUint32LE(),
+			offset: rh.ReadUint32LE(),
+		}
+		if uint32(len(b)) < record.offset {
+			return nil, ErrInvalidFontData
+		} else if uint32(len(b))-record.offset < record.size {
+			// the size of the last table may include padding beyond the end of the file
+			record.size = uint32(len(b)) - record.offset
+		}
+		if record.size < 4 {
+			return nil, ErrInvalidFontData
+		}
+		tables[typ] = record
+	}
+	table := func(typ uint32) (*pcfReader, uint32, bool) {
+		record, ok := tables[typ]
+		if !ok {
+			return nil, 0, false
+		}
+		data := b[record.offset : record.offset+record.size]
+		format := binary.LittleEndian.Uint32(data) // always little endian
+		order := binary.ByteOrder(binary.LittleEndian)
+		if format&pcfByteMSBFirst != 0 {
+			order = binary.BigEndian
+		}
+		return &pcfReader{b: data, pos: 4, order: order}, format, true
+	}
+
+	f := &BitmapFont{
+		DefaultChar: -1,
+	}
+
+	// properties
+	if r, _, ok := table(pcfProperties); ok {
+		n := r.Uint32()
+		if r.err != nil || uint32(len(r.b))/9 < n {
+			return nil, fmt.Errorf("PCF: bad properties")
+		}
+		type property struct {
+			name     uint32
+			isString bool
+			value    int32
+		}
+		props := make([]property, n)
+		for i := range props {
+			props[i].name = r.Uint32()
+			props[i].isString = r.Uint8() != 0
+			props[i].value = r.Int32()
+		}
+		if n&3 != 0 {
+			r.read(4 - n&3) // padding
+		}
+		stringsSize := r.Uint32()
+		strs := r.read(stringsSize)
+		if r.err != nil {
+			return nil, fmt.Errorf("PCF: bad properties")
+		}
+		str := func(offset uint32) string {
+			if uint32(len(strs)) <= offset {
+				return ""
+			}
+			s := strs[offset:]
+			if i := bytes.IndexByte(s, 0); i != -1 {
+				s = s[:i]
+			}
+			return string(s)
+		}
+		hasAscent, hasDescent := false, false
+		for _, prop := range props {
+			s := ""
+			if prop.isString {
+				s = str(uint32(prop.value))
+			}
+			switch str(prop.name) {
+			case "FONT":
+				f.Name = s
+			case "FAMILY_NAME":
+				f.FamilyName = s
+			case "WEIGHT_NAME":
+				f.Weight = s
+			case "SLANT":
+				f.Slant = s
+			case "COPYRIGHT":
+				f.Copyright = s
+			case "PIXEL_SIZE":
+				f.PixelSize = int(prop.value)
+			case "FONT_ASCENT":
+				f.Ascent, hasAscent = int(prop.value), true
+			case "FONT_DESCENT":
+				f.Descent, hasDescent = int(prop.value), true
+			case "DEFAULT_CHAR":
+				f.DefaultChar = int(prop.value)
+			case "CHARSET_REGISTRY":
+				f.Registry = s
+			case "CHARSET_ENCODING":
+				f.Encoding = s
+			}
+		}
+
+		// accelerators hold the ascent and descent when not given as properties
+		if !hasAscent || !hasDescent {
+			r, _, ok := table(pcfBDFAccelerators)
+			if !ok {
+				r, _, ok = table(pcfAccelerators)
+			}
+			if ok {
+				_ = r.read(8) // flags, drawDirection, and padding
+				ascent, descent := r.Int32(), r.Int32()
+				if r.err == nil {
+					if !hasAscent {
+						f.Ascent = int(ascent)
+					}
+					if !hasDescent {
+						f.Descent = int(descent)
+					}
+				}
+			}
+		}
+	}
+
+	// metrics
+	r, format, ok := table(pcfMetrics)
+	if !ok {
+		return nil, fmt.Errorf("PCF: missing metrics")
+	}
+	var metrics []pcfMetric
+	if format&pcfCompressedMetrics != 0 {
+		n := int(r.Uint16())
+		metrics = make([]pcfMetric, n)
+		for i := range metrics {
+			metrics[i] = pcfMetric{
+				leftSideBearing:  int(r.Uint8()) - 0x80,
+				rightSideBearing: int(r.Uint8()) - 0x80,
+				width:            int(r.Uint8()) - 0x80,
+				ascent:           int(r.Uint8()) - 0x80,
+				descent:          int(r.Uint8()) - 0x80,
+			}
+		}
+	} else {
+		n := r.Uint32()
+		if uint32(len(r.b))/12 < n {
+			return nil, fmt.Errorf("PCF: bad metrics")
+		}
+		metrics = make([]pcfMetric, n)
+		for i := range metrics {
+			metrics[i] = pcfMetric{
+				leftSideBearing:  int(r.Int16()),
+				rightSideBearing: int(r.Int16()),
+				width:            int(r.Int16()),
+				ascent:           int(r.Int16()),
+				descent:          int(r.Int16()),
+			}
+			_ = r.Uint16() // attributes
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("PCF: bad metrics")
+	}
+
+	// bitmaps
+	r, format, ok = table(pcfBitmaps)
+	if !ok {
+		return nil, fmt.Errorf("PCF: missing bitmaps")
+	}
+	n := r.Uint32()
+	if n != uint32(len(metrics)) || uint32(len(r.b))/4 < n {
+		return nil, fmt.Errorf("PCF: bad bitmaps")
+	} else if uint32(o.MaxGlyphs) < n {
+		return nil, fmt.Errorf("PCF: too many glyphs")
+	}
+	offsets := make([]uint32, n)
+	for i := range offsets {
+		offsets[i] = r.Uint32()
+	}
+	sizes := [4]uint32{r.Uint32(), r.Uint32(), r.Uint32(), r.Uint32()}
+	bitmapData := r.read(sizes[format&3])
+	if r.err != nil {
+		return nil, fmt.Errorf("PCF: bad bitmaps")
+	}
+	pad := 1 << (format & 3)             // row padding in bytes
+	scanUnit := 1 << ((format >> 4) & 3) // bytes per unit for byte swapping
+	msbBytes, msbBits := format&pcfByteMSBFirst != 0, format&pcfBitMSBFirst != 0
+
+	f.Glyphs = make([]BitmapGlyph, n)
+	memory := 0 // of the bitmaps
+	for i, m := range metrics {
+		w, h := m.rightSideBearing-m.leftSideBearing, m.ascent+m.descent
+		if w < 0 || h < 0 || 1024 < w || 1024 < h {
+			return nil, fmt.Errorf("PCF: bad glyph metrics")
+		} else if memory += w * h; int64(o.MaxMemory) < int64(memory) {
+			return nil, fmt.Errorf("PCF: %w", ErrExceedsMemory)
+		}
+		glyph := BitmapGlyph{
+			Code:    -1,
+			Advance: m.width,
+			XOffset: m.leftSideBearing,
+			YOffset: -m.descent,
+			Bitmap:  image.NewAlpha(image.Rect(0, 0, w, h)),
+		}
+
+		stride := (w + 8*pad - 1) / (8 * pad) * pad
+		offset := offsets[i]
+		if uint32(len(bitmapData)) < offset || uint32(len(bitmapData))-offset < uint32(stride*h) {
+			return nil, fmt.Errorf("PCF: bad bitmap offset")
+		}
+		for y := 0; y < h; y++ {
+			row := bitmapData[int(offset)+y*stride : int(offset)+(y+1)*stride]
+			for x := 0; x < w; x++ {
+				k := x / 8
+				if msbBytes != msbBits && 1 < scanUnit {
+					// swap bytes within each scan unit
+					k = k - k%scanUnit + scanUnit - 1 - k%scanUnit
+				}
+				bit := uint(x % 8)
+				if msbBits {
+					bit = 7 - bit
+				}
+				if row[k]&(1<<bit) != 0 {
+					glyph.Bitmap.Pix[y*glyph.Bitmap.Stride+x] = 0xFF
+				}
+			}
+		}
+		f.Glyphs[i] = glyph
+	}
+
+	// encodings
+	if r, _, ok := table(pcfBDFEncodings); ok {
+		minByte2, maxByte2 := int(r.Uint16()), int(r.Uint16())
+		minByte1, maxByte1 := int(r.Uint16()), int(r.Uint16())
+		defaultChar := int(r.Uint16())
+		if r.err != nil || maxByte2 < minByte2 || maxByte1 < minByte1 || 255 < maxByte2 || 255 < maxByte1 {
+			return nil, fmt.Errorf("PCF: bad encodings")
+		}
+		if f.DefaultChar == -1 {
+			f.DefaultChar = defaultChar
+		}
+		for byte1 := minByte1; byte1 <= maxByte1; byte1++ {
+			for byte2 := minByte2; byte2 <= maxByte2; byte2++ {
+				i := r.Uint16()
+				if r.err != nil {
+					return nil, fmt.Errorf("PCF: bad encodings")
+				} else if i != 0xFFFF && int(i) < len(f.Glyphs) && f.Glyphs[i].Code == -1 {
+					f.Glyphs[i].Code = byte1<<8 | byte2
+				}
+			}
+		}
+	}
+
+	// glyph names
+	if r, _, ok := table(pcfGlyphNames); ok {
+		n := r.Uint32()
+		if n == uint32(len(f.Glyphs)) {
+			offsets := make([]uint32, n)
+			for i := range offsets {
+				offsets[i] = r.Uint32()
+			}
+			size := r.Uint32()
+			strs := r.read(size)
+			if r.err == nil {
+				for i, offset := range offsets {
+					if offset < uint32(len(strs)) {
+						s := strs[offset:]
+						if j := bytes.IndexByte(s, 0); j != -1 {
+							s = s[:j]
+						}
+						f.Glyphs[i].Name = string(s)
+					}
+				}
+			}
+		}
+	}
+
+	f.setXLFD()
+	f.setRunes()
+	return f, nil
+}
@@ -0,0 +1,279 @@
+package font
+
+import (
+	"image"
+	"math"
+)
+
+// RasterTolerance is the maximum deviation in pixels when flattening Béziers into lines for rasterization.
+var RasterTolerance = 0.1
+
+// lcdFilter are the weights of the FIR filter applied to LCD subpixels to reduce color fringes, the same as FreeType's default filter. They sum to 256.
+var lcdFilter = [5]uint32{0x08, 0x4D, 0x56, 0x4D, 0x08}
+
+// RasterOptions are options for rasterizing glyphs.
+type RasterOptions struct {
+	Gamma float64 // gamma of the coverage, zero is equal to one and linear
+	LCD   bool    // rasterize for horizontal RGB subpixels
+}
+
+// Rasterizer is an anti-aliased rasterizer that fills paths using the non-zero winding rule and implements the Pather interface. Coordinates are in pixels with the y-axis pointing up, so that the pixel (x,y) of the mask covers the area from (x,-y-1) to (x+1,-y) and glyph paths can be drawn directly. In LCD mode, each pixel consists of three horizontal subpixels and the horizontal coordinates of the mask are in subpixels.
+type Rasterizer struct {
+	rect   image.Rectangle
+	lcd    bool
+	stride int
+	acc    []float32 // signed area of each cell of the rect
+
+	x0, y0 float64 // start of the contour in cell coordinates
+	x, y   float64 // current position in cell coordinates
+	open   bool
+}
+
+// NewRasterizer returns a rasterizer for the given mask rectangle, where the y-axis points down. For LCD rasterizers, the horizontal coordinates of the rectangle are in subpixels, and usually a multiple of three.
+func NewRasterizer(rect image.Rectangle, lcd bool) *Rasterizer {
+	r := &Rasterizer{}
+	r.Reset(rect, lcd)
+	return r
+}
+
+// Reset clears the rasterizer and sets a new mask rectangle, reusing the allocated memory.
+func (r *Rasterizer) Reset(rect image.Rectangle, lcd bool) {
+	r.rect = rect.Canon()
+	r.lcd = lcd
+	r.stride = r.rect.Dx() + 2
+	n := r.stride * r.rect.Dy()
+	if cap(r.acc) < n {
+		r.acc = make([]float32, n)
+	} else {
+		r.acc = r.acc[:n]
+		for i := range r.acc {
+			r.acc[i] = 0.0
+		}
+	}
+	r.open = false
+}
+
+// cell returns the coordinates relative to the mask rectangle with the y-axis pointing down.
+func (r *Rasterizer) cell(x, y float64) (float64, float64) {
+	if r.lcd {
+		x *= 3.0
+	}
+	return x - float64(r.rect.Min.X), -y - float64(r.rect.Min.Y)
+}
+
+// MoveTo starts a new contour at (x,y), closing the current one.
+func (r *Rasterizer) MoveTo(x, y float64) {
+	r.Close()
+	r.x0, r.y0 = r.cell(x, y)
+	r.x, r.y = r.x0, r.y0
+	r.open = true
+}
+
+// LineTo adds a line to (x,y).
+func (r *Rasterizer) LineTo(x, y float64) {
+	x, y = r.cell(x, y)
+	r.line(r.x, r.y, x, y)
+	r.x, r.y = x, y
+}
+
+// QuadTo adds a quadratic Bézier with control point (cpx,cpy) to (x,y).
+func (r *Rasterizer) QuadTo(cpx, cpy, x, y float64) {
+	cpx, cpy = r.cell(cpx, cpy)
+	x, y = r.cell(x, y)
+	dev := math.Hypot(r.x-2.0*cpx+x, r.y-2.0*cpy+y)
+	n := int(math.Ceil(math.Sqrt(dev / (8.0 * RasterTolerance))))
+	if n < 1 {
+		n = 1
+	}
+	x0, y0 := r.x, r.y
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1.0 - t
+		px := mt*mt*x0 + 2.0*mt*t*cpx + t*t*x
+		py := mt*mt*y0 + 2.0*mt*t*cpy + t*t*y
+		r.line(r.x, r.y, px, py)
+		r.x, r.y = px, py
+	}
+}
+
+// CubeTo adds a cubic Bézier with control points (cpx1,cpy1) and (cpx2,cpy2) to (x,y).
+func (r *Rasterizer) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	cpx1, cpy1 = r.cell(cpx1, cpy1)
+	cpx2, cpy2 = r.cell(cpx2, cpy2)
+	x, y = r.cell(x, y)
+	dev := math.Max(math.Hypot(r.x-2.0*cpx1+cpx2, r.y-2.0*cpy1+cpy2), math.Hypot(cpx1-2.0*cpx2+x, cpy1-2.0*cpy2+y))
+	n := int(math.Ceil(math.Sqrt(0.75 * dev / RasterTolerance)))
+	if n < 1 {
+		n = 1
+	}
+	x0, y0 := r.x, r.y
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1.0 - t
+		px := mt*mt*mt*x0 + 3.0*mt*mt*t*cpx1 + 3.0*mt*t*t*cpx2 + t*t*t*x
+		py := mt*mt*mt*y0 + 3.0*mt*mt*t*cpy1 + 3.0*mt*t*t*cpy2 + t*t*t*y
+		r.line(r.x, r.y, px, py)
+		r.x, r.y = px, py
+	}
+}
+
+// Close closes the current contour.
+func (r *Rasterizer) Close() {
+	if r.open {
+		r.line(r.x, r.y, r.x0, r.y0)
+		r.x, r.y = r.x0, r.y0
+		r.open = false
+	}
+}
+
+// line accumulates the signed area of a line in cell coordinates to the cells it crosses and to the cell to its right. Summing the cells of a row from left to right gives the coverage.
+func (r *Rasterizer) line(x0, y0, x1, y1 float64) {
+	if y0 == y1 {
+		return
+	}
+	w, h := float64(r.rect.Dx()), float64(r.rect.Dy())
+	for _, xe := range []float64{0.0, w} {
+		if x0 < xe && xe < x1 || x1 < xe && xe < x0 {
+			// split at the left and right edges so that each part is either inside or outside the rectangle
+			ye := y0 + (xe-x0)*(y1-y0)/(x1-x0)
+			r.line(x0, y0, xe, ye)
+			r.line(xe, ye, x1, y1)
+			return
+		}
+	}
+
+	dir := float32(1.0)
+	if y1 < y0 {
+		dir = -1.0
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+
+	// follow the unclamped line and clamp per row, everything left of the rectangle covers the first column entirely
+	dxdy := (x1 - x0) / (y1 - y0)
+	x := x0
+	if y0 < 0.0 {
+		x -= y0 * dxdy
+	}
+
+	yEnd := int(math.Min(math.Ceil(y1), h))
+	for yi := int(math.Max(0.0, y0)); yi < yEnd; yi++ {
+		row := r.acc[yi*r.stride : (yi+1)*r.stride]
+		dy := math.Min(float64(yi+1), y1) - math.Max(float64(yi), y0)
+		xNext := x + dxdy*dy
+		d := dir * float32(dy)
+
+		xa, xb := math.Max(0.0, math.Min(x, w)), math.Max(0.0, math.Min(xNext, w))
+		if xb < xa {
+			xa, xb = xb, xa
+		}
+		xaFloor := math.Floor(xa)
+		xai, xbi := int(xaFloor), int(math.Ceil(xb))
+		if xbi <= xai+1 {
+			// within a single cell
+			xm := float32(0.5*(xa+xb) - xaFloor)
+			row[xai] += d - d*xm
+			row[xai+1] += d * xm
+		} else {
+			s := float32(1.0 / (xb - xa))
+			xaf := float32(xa - xaFloor)
+			a0 := 0.5 * s * (1.0 - xaf) * (1.0 - xaf)
+			xbf := float32(xb - float64(xbi) + 1.0)
+			am := 0.5 * s * xbf * xbf
+			row[xai] += d * a0
+			if xbi == xai+2 {
+				row[xai+1] += d * (1.0 - a0 - am)
+			} else {
+				a1 := s * (1.5 - xaf)
+				row[xai+1] += d * (a1 - a0)
+				for xi := xai + 2; xi < xbi-1; xi++ {
+					row[xi] += d * s
+				}
+				a2 := a1 + float32(xbi-xai-3)*s
+				row[xbi-1] += d * (1.0 - a2 - am)
+			}
+			row[xbi] += d * am
+		}
+		x = xNext
+	}
+}
+
+// Mask returns the coverage of the filled paths, where the coverage is raised to the power 1/gamma. A gamma of zero is equal to one. In LCD mode the mask holds the coverage of each subpixel, filtered to reduce color fringes, with the pixels' red, green, and blue subpixels next to each other.
+func (r *Rasterizer) Mask(gamma float64) *image.Alpha {
+	r.Close()
+
+	var table [256]uint8
+	for i := range table {
+		if gamma == 0.0 || gamma == 1.0 {
+			table[i] = uint8(i)
+		} else {
+			table[i] = uint8(math.Pow(float64(i)/255.0, 1.0/gamma)*255.0 + 0.5)
+		}
+	}
+
+	img := image.NewAlpha(r.rect)
+	w := r.rect.Dx()
+	coverage := make([]uint8, w)
+	for y := 0; y < r.rect.Dy(); y++ {
+		acc := float32(0.0)
+		row := r.acc[y*r.stride : (y+1)*r.stride]
+		for x := 0; x < w; x++ {
+			acc += row[x]
+			a := acc
+			if a < 0.0 {
+				a = -a
+			}
+			if 1.0 < a {
+				a = 1.0
+			}
+			coverage[x] = uint8(a*255.0 + 0.5)
+		}
+
+		pix := img.Pix[y*img.Stride : y*img.Stride+w]
+		if r.lcd {
+			for x := 0; x < w; x++ {
+				c := uint32(0)
+				for i, weight := range lcdFilter {
+					if xi := x + i - 2; 0 <= xi && xi < w {
+						c += weight * uint32(coverage[xi])
+					}
+				}
+				pix[x] = table[c>>8]
+			}
+		} else {
+			for x, c := range coverage {
+				pix[x] = table[c]
+			}
+		}
+	}
+	return img
+}
+
+////////////////////////////////////////////////////////////////
+
+// Rasterize returns the anti-aliased mask of the outline, where each point (x,y) is placed at (xOffset+scale*x, yOffset+scale*y) in pixels. The offsets position the outline at subpixel precision. The mask's rectangle is the bounding box of the outline in pixels with the origin at the glyph's origin and the y-axis pointing down. In LCD mode, the horizontal coordinates of the rectangle are in subpixels and padded for the filter, see Rasterizer.Mask. It returns nil for empty outlines.
+func (o Outline) Rasterize(xOffset, yOffset, scale float64, opts RasterOptions) *image.Alpha {
+	bounds := &bboxPather{}
+	o.Draw(bounds, xOffset, yOffset, scale)
+	if !bounds.hasPoints || bounds.xMax <= bounds.xMin || bounds.yMax <= bounds.yMin {
+		return nil
+	}
+
+	rect := image.Rect(int(math.Floor(bounds.xMin)), int(math.Floor(-bounds.yMax)), int(math.Ceil(bounds.xMax)), int(math.Ceil(-bounds.yMin)))
+	if opts.LCD {
+		// pad with two subpixels for the filter, and align to whole pixels
+		rect.Min.X = 3*rect.Min.X - 3
+		rect.Max.X = 3*rect.Max.X + 3
+	}
+	r := NewRasterizer(rect, opts.LCD)
+	o.Draw(r, xOffset, yOffset, scale)
+	return r.Mask(opts.Gamma)
+}
+
+// GlyphMask returns the anti-aliased mask of the unhinted glyph at the given size in pixels per em. The offsets in pixels position the glyph at subpixel precision, see Outline.Rasterize. It returns nil for empty glyphs.
+func (sfnt *SFNT) GlyphMask(glyphID uint16, size, xOffset, yOffset float64, opts RasterOptions) (*image.Alpha, error) {
+	outline, err := sfnt.GlyphOutline(glyphID)
+	if err != nil {
+		return nil, err
+	}
+	return outline.Rasterize(xOffset, yOffset, size/float64(sfnt.Head.UnitsPerEm), opts), nil
+}
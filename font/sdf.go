@@ -0,0 +1,604 @@
+package font
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// DistanceFieldOptions are options for generating signed distance fields.
+type DistanceFieldOptions struct {
+	Range   float64 // distance in pixels between the lowest and highest value, zero is equal to four
+	Padding int     // empty pixels around the glyph's bounding box, usually half the range
+}
+
+func (opts DistanceFieldOptions) distanceRange() float64 {
+	if opts.Range <= 0.0 {
+		return 4.0
+	}
+	return opts.Range
+}
+
+// SDF returns the single-channel signed distance field of the outline, where each point (x,y) is placed at (xOffset+scale*x, yOffset+scale*y) in pixels. Each pixel holds the distance from its center to the nearest point on the outline, mapped from [-Range/2,Range/2] to [0,255] and positive inside, so that the outline is at the value of 127.5. Distances are calculated exactly to lines and Béziers and the inside is determined using the non-zero winding rule. The image's rectangle is the bounding box of the outline in pixels with the origin at the glyph's origin and the y-axis pointing down, extended by the padding. It returns nil for empty outlines.
+func (o Outline) SDF(xOffset, yOffset, scale float64, opts DistanceFieldOptions) *image.Alpha {
+	edges, rect := o.distanceFieldEdges(xOffset, yOffset, scale, opts.Padding)
+	if edges == nil {
+		return nil
+	}
+
+	distanceRange := opts.distanceRange()
+	img := image.NewAlpha(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			p := sdfVec{float64(x) + 0.5, -float64(y) - 0.5}
+			dist := math.Inf(1)
+			for _, contour := range edges {
+				for _, edge := range contour {
+					if d, _, _ := edge.distance(p); d < dist {
+						dist = d
+					}
+				}
+			}
+			if !sdfInside(edges, p) {
+				dist = -dist
+			}
+			img.Pix[img.PixOffset(x, y)] = distanceFieldValue(dist / distanceRange)
+		}
+	}
+	return img
+}
+
+// MSDF returns the multi-channel signed distance field of the outline, where the edges of the outline are assigned to two of the three color channels such that corners are preserved at the median of the channels. Each channel holds the signed pseudo-distance to the nearest edge of its color, see Outline.SDF for the mapping of distances and the image's rectangle. It returns nil for empty outlines.
+func (o Outline) MSDF(xOffset, yOffset, scale float64, opts DistanceFieldOptions) *image.RGBA {
+	edges, rect := o.distanceFieldEdges(xOffset, yOffset, scale, opts.Padding)
+	if edges == nil {
+		return nil
+	}
+	for i := range edges {
+		edges[i] = colorEdges(edges[i])
+	}
+
+	// orientation of the outer contours to obtain the sign of the distances
+	area := 0.0
+	for _, contour := range edges {
+		for _, edge := range contour {
+			area += edge.p[0].cross(edge.p[edge.n])
+		}
+	}
+	orientation := 1.0
+	if area < 0.0 {
+		orientation = -1.0
+	}
+
+	distanceRange := opts.distanceRange()
+	w, h := rect.Dx(), rect.Dy()
+	field := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := sdfVec{float64(rect.Min.X+x) + 0.5, -float64(rect.Min.Y+y) - 0.5}
+			var nearest [3]*sdfEdge
+			var dists, ortho, ts [3]float64
+			for c := range dists {
+				dists[c] = math.Inf(1)
+			}
+			for i := range edges {
+				for j := range edges[i] {
+					edge := &edges[i][j]
+					d, t, orthogonality := edge.distance(p)
+					for c := uint8(0); c < 3; c++ {
+						if edge.color&(1<<c) != 0 && (d < dists[c] || d == dists[c] && orthogonality < ortho[c]) {
+							nearest[c], dists[c], ortho[c], ts[c] = edge, d, orthogonality, t
+						}
+					}
+				}
+			}
+
+			var v [3]float64
+			for c := range v {
+				if nearest[c] == nil {
+					v[c] = -math.Inf(1)
+				} else {
+					v[c] = orientation * nearest[c].pseudoDistance(p, ts[c], dists[c]) / distanceRange
+				}
+			}
+
+			// correct the sign using the winding rule, which supports overlapping contours
+			if (0.0 < median(v[0], v[1], v[2])) != sdfInside(edges, p) {
+				v[0], v[1], v[2] = -v[0], -v[1], -v[2]
+			}
+			field[y*w+x] = v
+		}
+	}
+	correctDistanceFieldClashes(field, w, h, 1.001/distanceRange)
+
+	img := image.NewRGBA(rect)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := field[y*w+x]
+			img.SetRGBA(rect.Min.X+x, rect.Min.Y+y, color.RGBA{distanceFieldValue(v[0]), distanceFieldValue(v[1]), distanceFieldValue(v[2]), 255})
+		}
+	}
+	return img
+}
+
+// GlyphSDF returns the single-channel signed distance field of the glyph at the given size in pixels per em, see Outline.SDF. It returns nil for empty glyphs.
+func (sfnt *SFNT) GlyphSDF(glyphID uint16, size float64, opts DistanceFieldOptions) (*image.Alpha, error) {
+	outline, err := sfnt.GlyphOutline(glyphID)
+	if err != nil {
+		return nil, err
+	}
+	return outline.SDF(0.0, 0.0, size/float64(sfnt.Head.UnitsPerEm), opts), nil
+}
+
+// GlyphMSDF returns the multi-channel signed distance field of the glyph at the given size in pixels per em, see Outline.MSDF. It returns nil for empty glyphs.
+func (sfnt *SFNT) GlyphMSDF(glyphID uint16, size float64, opts DistanceFieldOptions) (*image.RGBA, error) {
+	outline, err := sfnt.GlyphOutline(glyphID)
+	if err != nil {
+		return nil, err
+	}
+	return outline.MSDF(0.0, 0.0, size/float64(sfnt.Head.UnitsPerEm), opts), nil
+}
+
+// distanceFieldValue maps a distance relative to the range to a byte value.
+func distanceFieldValue(v float64) uint8 {
+	v = math.Max(0.0, math.Min(1.0, v+0.5))
+	return uint8(v*255.0 + 0.5)
+}
+
+// distanceFieldEdges returns the edges of each contour in pixels and the rectangle of the distance field.
+func (o Outline) distanceFieldEdges(xOffset, yOffset, scale float64, padding int) ([][]sdfEdge, image.Rectangle) {
+	var start, cur sdfVec
+	edges := [][]sdfEdge{}
+	add := func(n int, p ...sdfVec) {
+		if len(edges) == 0 {
+			edges = append(edges, []sdfEdge{})
+		}
+		edge := sdfEdge{n: n}
+		edge.p[0] = cur
+		copy(edge.p[1:], p)
+		if n == 1 && edge.p[0] == edge.p[1] {
+			return
+		}
+		edges[len(edges)-1] = append(edges[len(edges)-1], edge)
+		cur = p[len(p)-1]
+	}
+	closeContour := func() {
+		if cur != start {
+			add(1, start)
+		}
+	}
+
+	bounds := &bboxPather{}
+	for _, seg := range o {
+		var p [3]sdfVec
+		for i := range p {
+			p[i] = sdfVec{xOffset + scale*seg.Args[2*i], yOffset + scale*seg.Args[2*i+1]}
+		}
+		switch seg.Type {
+		case MoveToSegment:
+			closeContour()
+			edges = append(edges, []sdfEdge{})
+			start, cur = p[0], p[0]
+			bounds.add(p[0].x, p[0].y)
+		case LineToSegment:
+			add(1, p[0])
+			bounds.add(p[0].x, p[0].y)
+		case QuadToSegment:
+			add(2, p[0], p[1])
+			bounds.add(p[0].x, p[0].y)
+			bounds.add(p[1].x, p[1].y)
+		case CubeToSegment:
+			add(3, p[0], p[1], p[2])
+			bounds.add(p[0].x, p[0].y)
+			bounds.add(p[1].x, p[1].y)
+			bounds.add(p[2].x, p[2].y)
+		case CloseSegment:
+			closeContour()
+		}
+	}
+	closeContour()
+
+	contours := edges[:0]
+	for _, contour := range edges {
+		if len(contour) != 0 {
+			contours = append(contours, contour)
+		}
+	}
+	if len(contours) == 0 || bounds.xMax <= bounds.xMin || bounds.yMax <= bounds.yMin {
+		return nil, image.Rectangle{}
+	}
+	rect := image.Rect(int(math.Floor(bounds.xMin)), int(math.Floor(-bounds.yMax)), int(math.Ceil(bounds.xMax)), int(math.Ceil(-bounds.yMin)))
+	return contours, rect.Inset(-padding)
+}
+
+// sdfInside returns true if the point is inside the contours using the non-zero winding rule, by counting the crossings of the edges with a ray to the right.
+func sdfInside(contours [][]sdfEdge, p sdfVec) bool {
+	winding := 0
+	for _, contour := range contours {
+		for _, edge := range contour {
+			winding += edge.crossings(p)
+		}
+	}
+	return winding != 0
+}
+
+////////////////////////////////////////////////////////////////
+
+// sdfVec is a point or vector.
+type sdfVec struct {
+	x, y float64
+}
+
+func (a sdfVec) add(b sdfVec) sdfVec             { return sdfVec{a.x + b.x, a.y + b.y} }
+func (a sdfVec) sub(b sdfVec) sdfVec             { return sdfVec{a.x - b.x, a.y - b.y} }
+func (a sdfVec) mul(f float64) sdfVec            { return sdfVec{f * a.x, f * a.y} }
+func (a sdfVec) dot(b sdfVec) float64            { return a.x*b.x + a.y*b.y }
+func (a sdfVec) cross(b sdfVec) float64          { return a.x*b.y - a.y*b.x }
+func (a sdfVec) length() float64                 { return math.Hypot(a.x, a.y) }
+func (a sdfVec) lerp(b sdfVec, t float64) sdfVec { return a.add(b.sub(a).mul(t)) }
+
+func (a sdfVec) norm() sdfVec {
+	if l := a.length(); l != 0.0 {
+		return a.mul(1.0 / l)
+	}
+	return sdfVec{}
+}
+
+// color channels of edges
+const (
+	sdfRed     uint8 = 1
+	sdfGreen   uint8 = 2
+	sdfBlue    uint8 = 4
+	sdfYellow        = sdfRed | sdfGreen
+	sdfMagenta       = sdfRed | sdfBlue
+	sdfCyan          = sdfGreen | sdfBlue
+	sdfWhite         = sdfRed | sdfGreen | sdfBlue
+)
+
+// sdfEdge is a line (n=1), quadratic Bézier (n=2), or cubic Bézier (n=3) with its color channels.
+type sdfEdge struct {
+	n     int
+	p     [4]sdfVec
+	color uint8
+}
+
+func (e sdfEdge) point(t float64) sdfVec {
+	mt := 1.0 - t
+	switch e.n {
+	case 1:
+		return e.p[0].lerp(e.p[1], t)
+	case 2:
+		return e.p[0].mul(mt * mt).add(e.p[1].mul(2.0 * mt * t)).add(e.p[2].mul(t * t))
+	}
+	return e.p[0].mul(mt * mt * mt).add(e.p[1].mul(3.0 * mt * mt * t)).add(e.p[2].mul(3.0 * mt * t * t)).add(e.p[3].mul(t * t * t))
+}
+
+func (e sdfEdge) direction(t float64) sdfVec {
+	mt := 1.0 - t
+	var d sdfVec
+	switch e.n {
+	case 1:
+		d = e.p[1].sub(e.p[0])
+	case 2:
+		d = e.p[1].sub(e.p[0]).mul(2.0 * mt).add(e.p[2].sub(e.p[1]).mul(2.0 * t))
+	default:
+		d = e.p[1].sub(e.p[0]).mul(3.0 * mt * mt).add(e.p[2].sub(e.p[1]).mul(6.0 * mt * t)).add(e.p[3].sub(e.p[2]).mul(3.0 * t * t))
+	}
+	if d.x == 0.0 && d.y == 0.0 && e.n != 1 {
+		// degenerate control points at the endpoints
+		if t < 0.5 {
+			return e.p[2].sub(e.p[0])
+		}
+		return e.p[e.n].sub(e.p[e.n-2])
+	}
+	return d
+}
+
+// split splits the edge into three parts.
+func (e sdfEdge) split() [3]sdfEdge {
+	var parts [3]sdfEdge
+	for i := range parts {
+		parts[i] = e.sub(float64(i)/3.0, float64(i+1)/3.0)
+	}
+	return parts
+}
+
+// sub returns the part of the edge between t0 and t1.
+func (e sdfEdge) sub(t0, t1 float64) sdfEdge {
+	r := sdfEdge{n: e.n, color: e.color}
+	d := (t1 - t0) / 3.0
+	switch e.n {
+	case 1:
+		r.p[0], r.p[1] = e.point(t0), e.point(t1)
+	case 2:
+		r.p[0], r.p[2] = e.point(t0), e.point(t1)
+		r.p[1] = r.p[0].add(e.direction(t0).mul((t1 - t0) / 2.0))
+	default:
+		r.p[0], r.p[3] = e.point(t0), e.point(t1)
+		r.p[1] = r.p[0].add(e.direction(t0).mul(d))
+		r.p[2] = r.p[3].sub(e.direction(t1).mul(d))
+	}
+	return r
+}
+
+// distance returns the distance from the point to the edge, the parameter of the nearest point, and the orthogonality of the nearest point as the cosine between the edge's direction and the vector to the point. Orthogonality breaks ties for points nearest to the joint of two edges.
+func (e sdfEdge) distance(p sdfVec) (float64, float64, float64) {
+	var candidates []float64
+	switch e.n {
+	case 1:
+		ab := e.p[1].sub(e.p[0])
+		t := p.sub(e.p[0]).dot(ab) / ab.dot(ab)
+		candidates = []float64{math.Max(0.0, math.Min(1.0, t))}
+	case 2:
+		// roots of the derivative of the squared distance
+		qa := e.p[0].sub(p)
+		ab := e.p[1].sub(e.p[0])
+		br := e.p[2].sub(e.p[1]).sub(ab)
+		candidates = solveCubic(br.dot(br), 3.0*ab.dot(br), 2.0*ab.dot(ab)+qa.dot(br), qa.dot(ab))
+		candidates = append(candidates, 0.0, 1.0)
+	default:
+		// Newton's method from several starting points on the roots of the derivative of the squared distance
+		const starts = 8
+		candidates = append(candidates, 0.0, 1.0)
+		for i := 0; i <= starts; i++ {
+			t := float64(i) / starts
+			for step := 0; step < 8; step++ {
+				qe := e.point(t).sub(p)
+				d1 := e.direction(t)
+				mt := 1.0 - t
+				d2 := e.p[2].sub(e.p[1].mul(2.0)).add(e.p[0]).mul(6.0 * mt).add(e.p[3].sub(e.p[2].mul(2.0)).add(e.p[1]).mul(6.0 * t))
+				denom := d1.dot(d1) + qe.dot(d2)
+				if denom == 0.0 {
+					break
+				}
+				t -= qe.dot(d1) / denom
+				if t < 0.0 || 1.0 < t {
+					break
+				}
+			}
+			candidates = append(candidates, t)
+		}
+	}
+
+	dist, tNearest := math.Inf(1), 0.0
+	for _, t := range candidates {
+		if t < 0.0 || 1.0 < t || math.IsNaN(t) {
+			continue
+		}
+		if d := e.point(t).sub(p).length(); d < dist {
+			dist, tNearest = d, t
+		}
+	}
+	orthogonality := 0.0
+	if v := p.sub(e.point(tNearest)); v.x != 0.0 || v.y != 0.0 {
+		orthogonality = math.Abs(e.direction(tNearest).norm().dot(v.norm()))
+	}
+	return dist, tNearest, orthogonality
+}
+
+// pseudoDistance returns the signed distance to the edge extended along its tangents at the endpoints, given the parameter and distance of the nearest point on the edge. The distance is positive to the left of the edge.
+func (e sdfEdge) pseudoDistance(p sdfVec, t, dist float64) float64 {
+	q := e.point(t)
+	dir := e.direction(t).norm()
+	sign := 1.0
+	if dir.cross(p.sub(q)) < 0.0 {
+		sign = -1.0
+	}
+	if t == 0.0 || t == 1.0 {
+		// beyond an endpoint, use the perpendicular distance to the tangent line if closer
+		if v := p.sub(q); (t == 0.0 && v.dot(dir) < 0.0) || (t == 1.0 && 0.0 < v.dot(dir)) {
+			if pseudo := dir.cross(v); math.Abs(pseudo) <= dist {
+				return pseudo
+			}
+		}
+	}
+	return sign * dist
+}
+
+// crossings returns the signed number of times the edge crosses the horizontal ray from the point to the right, positive for upwards crossings. Upwards crossings include the start and exclude the end of the edge, and downwards crossings vice versa, so that vertices on the ray are counted once when passing through and not when touching.
+func (e sdfEdge) crossings(p sdfVec) int {
+	var roots []float64
+	switch e.n {
+	case 1:
+		if e.p[0].y != e.p[1].y {
+			roots = []float64{(p.y - e.p[0].y) / (e.p[1].y - e.p[0].y)}
+		}
+	case 2:
+		a := e.p[0].y - 2.0*e.p[1].y + e.p[2].y
+		b := 2.0 * (e.p[1].y - e.p[0].y)
+		roots = solveQuadratic(a, b, e.p[0].y-p.y)
+	default:
+		a := -e.p[0].y + 3.0*e.p[1].y - 3.0*e.p[2].y + e.p[3].y
+		b := 3.0*e.p[0].y - 6.0*e.p[1].y + 3.0*e.p[2].y
+		c := -3.0*e.p[0].y + 3.0*e.p[1].y
+		roots = solveCubic(a, b, c, e.p[0].y-p.y)
+	}
+
+	n := 0
+	for i, t := range roots {
+		if t < 0.0 || 1.0 < t || math.IsNaN(t) {
+			continue
+		}
+		duplicate := false
+		for _, prev := range roots[:i] {
+			duplicate = duplicate || prev == t
+		}
+		if duplicate || e.point(t).x <= p.x {
+			continue
+		}
+
+		dy := e.direction(t).y
+		if dy == 0.0 && (t == 0.0 || t == 1.0) {
+			// horizontal tangent at an endpoint
+			dy = e.point(math.Min(1.0, t+1e-6)).y - e.point(math.Max(0.0, t-1e-6)).y
+		}
+		if 0.0 < dy && t < 1.0 {
+			n++
+		} else if dy < 0.0 && 0.0 < t {
+			n--
+		}
+	}
+	return n
+}
+
+// colorEdges assigns color channels to the edges of a contour so that edges meeting at a corner share only one channel, similar to the simple edge coloring of msdfgen.
+func colorEdges(edges []sdfEdge) []sdfEdge {
+	const crossThreshold = 0.1411200080598672 // sin(3.0)
+	corners := []int{}
+	for i := range edges {
+		a := edges[(i+len(edges)-1)%len(edges)].direction(1.0).norm()
+		b := edges[i].direction(0.0).norm()
+		if a.dot(b) <= 0.0 || crossThreshold < math.Abs(a.cross(b)) {
+			corners = append(corners, i)
+		}
+	}
+
+	seed := 0
+	if len(corners) == 0 {
+		// smooth contour
+		for i := range edges {
+			edges[i].color = sdfWhite
+		}
+	} else if len(corners) == 1 {
+		// teardrop, split into three parts of different colors
+		colors := [3]uint8{sdfWhite, sdfWhite, sdfWhite}
+		colors[0] = switchEdgeColor(sdfWhite, &seed, 0)
+		colors[2] = switchEdgeColor(colors[0], &seed, 0)
+
+		corner := corners[0]
+		rotated := append(append([]sdfEdge{}, edges[corner:]...), edges[:corner]...)
+		if len(rotated) < 3 {
+			parts := []sdfEdge{}
+			for _, edge := range rotated {
+				split := edge.split()
+				parts = append(parts, split[:]...)
+			}
+			rotated = parts
+		}
+		m := len(rotated)
+		for i := range rotated {
+			third := int(3.0+2.875*float64(i)/float64(m-1)-1.4375+0.5) - 3
+			rotated[i].color = colors[1+third]
+		}
+		return rotated
+	} else {
+		spline := 0
+		start := corners[0]
+		c := switchEdgeColor(sdfWhite, &seed, 0)
+		initial := c
+		for i := range edges {
+			index := (start + i) % len(edges)
+			if spline+1 < len(corners) && corners[spline+1] == index {
+				spline++
+				banned := uint8(0)
+				if spline == len(corners)-1 {
+					banned = initial
+				}
+				c = switchEdgeColor(c, &seed, banned)
+			}
+			edges[index].color = c
+		}
+	}
+	return edges
+}
+
+// switchEdgeColor returns a different color of two channels, avoiding the channels of the banned color.
+func switchEdgeColor(c uint8, seed *int, banned uint8) uint8 {
+	combined := c & banned
+	if combined == sdfRed || combined == sdfGreen || combined == sdfBlue {
+		return combined ^ sdfWhite
+	} else if c == 0 || c == sdfWhite {
+		start := [3]uint8{sdfCyan, sdfMagenta, sdfYellow}
+		c = start[*seed%3]
+		*seed /= 3
+		return c
+	}
+	shifted := c << uint(1+*seed&1)
+	*seed >>= 1
+	return (shifted | shifted>>3) & sdfWhite
+}
+
+// correctDistanceFieldClashes replaces texels by the median of their channels where interpolation with a neighboring texel would produce artifacts, similar to the error correction of msdfgen.
+func correctDistanceFieldClashes(field [][3]float64, w, h int, threshold float64) {
+	clashes := []int{}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := field[y*w+x]
+			if 0 < x && detectDistanceFieldClash(a, field[y*w+x-1], threshold) ||
+				x < w-1 && detectDistanceFieldClash(a, field[y*w+x+1], threshold) ||
+				0 < y && detectDistanceFieldClash(a, field[(y-1)*w+x], threshold) ||
+				y < h-1 && detectDistanceFieldClash(a, field[(y+1)*w+x], threshold) {
+				clashes = append(clashes, y*w+x)
+			}
+		}
+	}
+	for _, i := range clashes {
+		m := median(field[i][0], field[i][1], field[i][2])
+		field[i] = [3]float64{m, m, m}
+	}
+}
+
+func detectDistanceFieldClash(a, b [3]float64, threshold float64) bool {
+	// sort the channels by decreasing difference between both texels
+	if math.Abs(b[0]-a[0]) < math.Abs(b[1]-a[1]) {
+		a[0], a[1], b[0], b[1] = a[1], a[0], b[1], b[0]
+	}
+	if math.Abs(b[1]-a[1]) < math.Abs(b[2]-a[2]) {
+		a[1], a[2], b[1], b[2] = a[2], a[1], b[2], b[1]
+		if math.Abs(b[0]-a[0]) < math.Abs(b[1]-a[1]) {
+			a[0], a[1], b[0], b[1] = a[1], a[0], b[1], b[0]
+		}
+	}
+	// flag only the texel farther from the edge, and ignore texels that have been equalized
+	return threshold <= math.Abs(b[1]-a[1]) && !(b[0] == b[1] && b[0] == b[2]) && math.Abs(b[2]) <= math.Abs(a[2])
+}
+
+func median(a, b, c float64) float64 {
+	return math.Max(math.Min(a, b), math.Min(math.Max(a, b), c))
+}
+
+// solveQuadratic returns the real roots of ax^2+bx+c = 0.
+func solveQuadratic(a, b, c float64) []float64 {
+	if math.Abs(a) < 1e-12 {
+		if b == 0.0 {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	discriminant := b*b - 4.0*a*c
+	if discriminant < 0.0 {
+		return nil
+	} else if discriminant == 0.0 {
+		return []float64{-b / (2.0 * a)}
+	}
+	sqrt := math.Sqrt(discriminant)
+	return []float64{(-b + sqrt) / (2.0 * a), (-b - sqrt) / (2.0 * a)}
+}
+
+// solveCubic returns the real roots of ax^3+bx^2+cx+d = 0.
+func solveCubic(a, b, c, d float64) []float64 {
+	if math.Abs(a) < 1e-12*math.Max(1.0, math.Max(math.Abs(b), math.Max(math.Abs(c), math.Abs(d)))) {
+		return solveQuadratic(b, c, d)
+	}
+	b, c, d = b/a, c/a, d/a
+
+	// depressed cubic t^3+pt+q = 0 with x = t-b/3
+	p := c - b*b/3.0
+	q := 2.0*b*b*b/27.0 - b*c/3.0 + d
+	shift := -b / 3.0
+	discriminant := q*q/4.0 + p*p*p/27.0
+	if 0.0 < discriminant {
+		sqrt := math.Sqrt(discriminant)
+		return []float64{math.Cbrt(-q/2.0+sqrt) + math.Cbrt(-q/2.0-sqrt) + shift}
+	} else if discriminant == 0.0 {
+		u := math.Cbrt(-q / 2.0)
+		return []float64{2.0*u + shift, -u + shift}
+	}
+	r := 2.0 * math.Sqrt(-p/3.0)
+	phi := math.Acos(math.Max(-1.0, math.Min(1.0, 3.0*q/(p*r))))
+	return []float64{
+		r*math.Cos(phi/3.0) + shift,
+		r*math.Cos((phi+2.0*math.Pi)/3.0) + shift,
+		r*math.Cos((phi+4.0*math.Pi)/3.0) + shift,
+	}
+}
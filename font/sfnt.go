@@ -3,17 +3,24 @@ package font
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
 	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/language"
 	"golang.org/x/text/transform"
 )
 
-// MaxCmapSegments is the maximum number of cmap segments that will be accepted.
+// MaxCmapSegments is the default maximum number of cmap segments that will be accepted.
 const MaxCmapSegments = 20000
 
 // Pather is an interface to append a glyph's path to canvas.Path.
@@ -35,10 +42,11 @@ const (
 
 // SFNT is a parsed OpenType font.
 type SFNT struct {
-	Data              []byte
+	Data              []byte // nil for fonts parsed with ParseSFNTReaderAt
 	Version           string
 	IsCFF, IsTrueType bool // only one can be true
 	Tables            map[string][]byte
+	EnforceEmbedding  bool // return errors from SubsetChecked and other embedding when not allowed by the license, see EmbeddingPermissions
 
 	// required
 	Cmap *cmapTable
@@ -63,9 +71,20 @@ type SFNT struct {
 	//Hdmx *hdmxTable // TODO
 	Vmtx *vmtxTable
 	Gpos *gposTable // TODO
+	Fvar *fvarTable
 	//Gsub *gsubTable // TODO
 	//Gasp *gaspTable // TODO
 	//Base *baseTable // TODO
+
+	// lazily parsed fonts, see ParseSFNTReaderAt
+	mu      sync.Mutex
+	src     io.ReaderAt
+	size    int64
+	index   int
+	records map[string]tableRecord
+
+	opts     ParseOptions
+	outlines outlineCache
 }
 
 // NumGlyphs returns the number of glyphs the font contains.
@@ -75,11 +94,17 @@ func (sfnt *SFNT) NumGlyphs() uint16 {
 
 // GlyphIndex returns the glyphID for a given rune.
 func (sfnt *SFNT) GlyphIndex(r rune) uint16 {
+	if sfnt.Cmap == nil {
+		return 0
+	}
 	return sfnt.Cmap.Get(r)
 }
 
 // GlyphName returns the name of the glyph.
 func (sfnt *SFNT) GlyphName(glyphID uint16) string {
+	if sfnt.Post == nil {
+		return ""
+	}
 	return sfnt.Post.Get(glyphID)
 }
 
@@ -88,7 +113,11 @@ func (sfnt *SFNT) GlyphPath(p Pather, glyphID, ppem uint16, x, y int32, scale fl
 	if sfnt.IsTrueType {
 		return sfnt.Glyf.ToPath(p, glyphID, ppem, x, y, scale, hinting)
 	} else if sfnt.IsCFF {
-		return sfnt.CFF.ToPath(p, glyphID, ppem, x, y, scale, hinting)
+		cff, err := sfnt.loadCFF()
+		if err != nil {
+			return err
+		}
+		return cff.ToPath(p, glyphID, ppem, x, y, scale, hinting)
 	}
 	return fmt.Errorf("only TrueType and CFF are supported")
 }
@@ -114,8 +143,9 @@ func (sfnt *SFNT) Kerning(left, right uint16) int16 {
 	return sfnt.Kern.Get(left, right)
 }
 
-// ParseSFNT parses an OpenType file format (TTF, OTF, TTC). The index is used for font collections to select a single font.
-func ParseSFNT(b []byte, index int) (*SFNT, error) {
+// ParseSFNT parses an OpenType file format (TTF, OTF, TTC). The index is used for font collections to select a single font. Optionally, parse options can be passed to set limits and required tables.
+func ParseSFNT(b []byte, index int, opts ...ParseOptions) (*SFNT, error) {
+	o := parseOptions(opts)
 	if len(b) < 12 || math.MaxUint32 < len(b) {
 		return nil, ErrInvalidFontData
 	}
@@ -146,7 +176,7 @@ func ParseSFNT(b []byte, index int) (*SFNT, error) {
 		} else {
 			length = r.ReadUint32() - offset
 		}
-		if uint32(len(b))-8 < offset || uint32(len(b))-8-offset < length {
+		if uint32(len(b)) < offset || uint32(len(b))-offset < length || length < 12 {
 			return nil, ErrInvalidFontData
 		}
 
@@ -155,7 +185,7 @@ func ParseSFNT(b []byte, index int) (*SFNT, error) {
 	} else if index != 0 {
 		return nil, fmt.Errorf("bad font index %d", index)
 	}
-	if sfntVersion != "OTTO" && binary.BigEndian.Uint32([]byte(sfntVersion)) != 0x00010000 {
+	if sfntVersion != "OTTO" && sfntVersion != "true" && binary.BigEndian.Uint32([]byte(sfntVersion)) != 0x00010000 {
 		return nil, fmt.Errorf("bad SFNT version")
 	}
 	numTables := r.ReadUint16()
@@ -176,6 +206,8 @@ func ParseSFNT(b []byte, index int) (*SFNT, error) {
 		padding := (4 - length&3) & 3
 		if uint32(len(b)) <= offset || uint32(len(b))-offset < length || uint32(len(b))-offset-length < padding {
 			return nil, ErrInvalidFontData
+		} else if o.MaxMemory < length {
+			return nil, fmt.Errorf("%s: %w", tag, ErrExceedsMemory)
 		}
 
 		if tag == "head" {
@@ -201,17 +233,14 @@ func ParseSFNT(b []byte, index int) (*SFNT, error) {
 	sfnt.Data = b
 	sfnt.Version = sfntVersion
 	sfnt.IsCFF = sfntVersion == "OTTO"
-	sfnt.IsTrueType = binary.BigEndian.Uint32([]byte(sfntVersion)) == 0x00010000
+	sfnt.IsTrueType = sfntVersion == "true" || binary.BigEndian.Uint32([]byte(sfntVersion)) == 0x00010000
 	sfnt.Tables = tables
+	sfnt.opts = o
 	if isCollection {
 		sfnt.Data = sfnt.Write()
 	}
 
-	requiredTables := []string{"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post"}
-	if sfnt.IsTrueType {
-		requiredTables = append(requiredTables, "glyf", "loca")
-	}
-	for _, requiredTable := range requiredTables {
+	for _, requiredTable := range o.requiredTables(sfnt.IsTrueType) {
 		if _, ok := tables[requiredTable]; !ok {
 			return nil, fmt.Errorf("%s: missing table", requiredTable)
 		}
@@ -246,43 +275,52 @@ func ParseSFNT(b []byte, index int) (*SFNT, error) {
 	}
 	sort.Strings(tableNames)
 	for _, tableName := range tableNames {
-		var err error
-		switch tableName {
-		case "CFF ":
-			err = sfnt.parseCFF()
-		case "CFF2":
-			err = sfnt.parseCFF2()
-		case "cmap":
-			err = sfnt.parseCmap()
-		case "glyf":
-			err = sfnt.parseGlyf()
-		case "GPOS":
-			err = sfnt.parseGPOS()
-		case "hmtx":
-			err = sfnt.parseHmtx()
-		case "kern":
-			err = sfnt.parseKern()
-		case "name":
-			err = sfnt.parseName()
-		case "OS/2":
-			err = sfnt.parseOS2()
-		case "post":
-			err = sfnt.parsePost()
-		case "vhea":
-			err = sfnt.parseVhea()
-		case "vmtx":
-			err = sfnt.parseVmtx()
-		}
-		if err != nil {
+		if err := sfnt.parseTable(tableName); err != nil {
 			return nil, err
 		}
 	}
+	if sfnt.OS2 == nil {
+		sfnt.OS2 = &os2Table{}
+	}
 	if sfnt.OS2.Version <= 1 {
 		sfnt.estimateOS2()
 	}
 	return sfnt, nil
 }
 
+// parseTable parses the table with the given tag, tables that are not parsed are ignored. The head, maxp, hhea, and loca tables must be parsed first.
+func (sfnt *SFNT) parseTable(tag string) error {
+	switch tag {
+	case "CFF ":
+		return sfnt.parseCFF()
+	case "CFF2":
+		return sfnt.parseCFF2()
+	case "cmap":
+		return sfnt.parseCmap()
+	case "glyf":
+		return sfnt.parseGlyf()
+	case "fvar":
+		return sfnt.parseFvar()
+	case "GPOS":
+		return sfnt.parseGPOS()
+	case "hmtx":
+		return sfnt.parseHmtx()
+	case "kern":
+		return sfnt.parseKern()
+	case "name":
+		return sfnt.parseName()
+	case "OS/2":
+		return sfnt.parseOS2()
+	case "post":
+		return sfnt.parsePost()
+	case "vhea":
+		return sfnt.parseVhea()
+	case "vmtx":
+		return sfnt.parseVmtx()
+	}
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////
 
 type cmapFormat0 struct {
@@ -551,7 +589,7 @@ func (sfnt *SFNT) parseCmap() error {
 					return fmt.Errorf("cmap: bad segCount in subtable %d", j)
 				}
 				segCount /= 2
-				if MaxCmapSegments < segCount {
+				if sfnt.opts.MaxCmapSegments < uint32(segCount) {
 					return fmt.Errorf("cmap: too many segments in subtable %d", j)
 				}
 				_ = rs.ReadUint16() // searchRange
@@ -644,7 +682,7 @@ func (sfnt *SFNT) parseCmap() error {
 				}
 				_ = rs.ReadUint32() // language
 				numGroups := rs.ReadUint32()
-				if MaxCmapSegments < numGroups {
+				if sfnt.opts.MaxCmapSegments < numGroups {
 					return fmt.Errorf("cmap: too many segments in subtable %d", j)
 				} else if rs.Len() < 12*numGroups {
 					return fmt.Errorf("cmap: bad subtable %d", j)
@@ -1102,6 +1140,9 @@ func (sfnt *SFNT) parseMaxp() error {
 	r := NewBinaryReader(b)
 	version := r.ReadBytes(4)
 	sfnt.Maxp.NumGlyphs = r.ReadUint16()
+	if sfnt.opts.MaxGlyphs < sfnt.Maxp.NumGlyphs {
+		return fmt.Errorf("maxp: too many glyphs")
+	}
 	if binary.BigEndian.Uint32(version) == 0x00005000 && !sfnt.IsTrueType && len(b) == 6 {
 		return nil
 	} else if binary.BigEndian.Uint32(version) == 0x00010000 && !sfnt.IsCFF && len(b) == 32 {
@@ -1134,13 +1175,56 @@ type nameRecord struct {
 }
 
 func (record nameRecord) String() string {
+	value := record.Value
 	var decoder *encoding.Decoder
-	if record.Platform == PlatformUnicode || record.Platform == PlatformWindows {
+	switch record.Platform {
+	case PlatformUnicode:
 		decoder = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
-	} else if record.Platform == PlatformMacintosh && record.Encoding == EncodingMacintoshRoman {
-		decoder = charmap.Macintosh.NewDecoder()
+	case PlatformMacintosh:
+		switch record.Encoding {
+		case EncodingMacintoshRoman:
+			decoder = charmap.Macintosh.NewDecoder()
+		case EncodingMacintoshJapanese:
+			decoder = japanese.ShiftJIS.NewDecoder()
+		case EncodingMacintoshChineseTraditional:
+			decoder = traditionalchinese.Big5.NewDecoder()
+		case EncodingMacintoshKorean:
+			decoder = korean.EUCKR.NewDecoder()
+		case EncodingMacintoshRussian:
+			decoder = charmap.MacintoshCyrillic.NewDecoder()
+		case EncodingMacintoshChineseSimplified:
+			decoder = simplifiedchinese.GBK.NewDecoder()
+		}
+	case PlatformWindows:
+		switch record.Encoding {
+		case EncodingWindowsShiftJIS, EncodingWindowsPRC, EncodingWindowsBig5, EncodingWindowsWansung:
+			// multi-byte characters are stored as 16-bit values, remove the zero high bytes of single-byte characters
+			value = make([]byte, 0, len(record.Value))
+			for i := 0; i+1 < len(record.Value); i += 2 {
+				if record.Value[i] != 0 {
+					value = append(value, record.Value[i])
+				}
+				value = append(value, record.Value[i+1])
+			}
+			switch record.Encoding {
+			case EncodingWindowsShiftJIS:
+				decoder = japanese.ShiftJIS.NewDecoder()
+			case EncodingWindowsPRC:
+				decoder = simplifiedchinese.GBK.NewDecoder()
+			case EncodingWindowsBig5:
+				decoder = traditionalchinese.Big5.NewDecoder()
+			case EncodingWindowsWansung:
+				decoder = korean.EUCKR.NewDecoder()
+			}
+		case EncodingWindowsJohab:
+		default:
+			decoder = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+		}
 	}
-	s, _, err := transform.String(decoder, string(record.Value))
+	if decoder == nil {
+		return string(record.Value)
+	}
+	s, _, err := transform.String(decoder, string(value))
 	if err == nil {
 		return s
 	}
@@ -1232,6 +1316,143 @@ func (sfnt *SFNT) parseName() error {
 	return nil
 }
 
+// LanguageTag returns the BCP-47 language tag of a name record, using the language-tag records for format 1 name tables. It returns an empty string if the language is unknown.
+func (t *nameTable) LanguageTag(record nameRecord) string {
+	if 0x8000 <= record.Language {
+		if i := int(record.Language - 0x8000); i < len(t.LangTag) {
+			return t.LangTag[i].String()
+		}
+		return ""
+	}
+	switch record.Platform {
+	case PlatformMacintosh:
+		return macintoshLanguageTags[record.Language]
+	case PlatformWindows:
+		return windowsLanguageTags[record.Language]
+	}
+	return ""
+}
+
+// Languages returns the BCP-47 language tags for which the font has names, in the order they appear in the name table.
+func (sfnt *SFNT) Languages() []string {
+	if sfnt.Name == nil {
+		return nil
+	}
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, record := range sfnt.Name.NameRecord {
+		if tag := sfnt.Name.LanguageTag(record); tag != "" && !seen[tag] {
+			tags = append(tags, tag)
+			seen[tag] = true
+		}
+	}
+	return tags
+}
+
+// NameString returns the decoded name for the given name identifier. The language is selected by the preferred BCP-47 language tags in order, falling back to English and then to any language. It returns an empty string if the font does not have the name.
+func (sfnt *SFNT) NameString(name NameID, languages ...string) string {
+	s, _ := sfnt.nameString(name, languages)
+	return s
+}
+
+// nameString returns the decoded name and its language tag.
+func (sfnt *SFNT) nameString(name NameID, languages []string) (string, string) {
+	if sfnt.Name == nil {
+		return "", ""
+	}
+
+	// collect a record for each language, preferring Windows over Unicode over Macintosh platforms
+	platformRank := func(platform PlatformID) int {
+		switch platform {
+		case PlatformWindows:
+			return 0
+		case PlatformUnicode:
+			return 1
+		case PlatformMacintosh:
+			return 2
+		}
+		return 3
+	}
+	tags := []string{}
+	records := map[string]nameRecord{}
+	for _, record := range sfnt.Name.Get(name) {
+		tag := sfnt.Name.LanguageTag(record)
+		if prev, ok := records[tag]; !ok {
+			tags = append(tags, tag)
+			records[tag] = record
+		} else if platformRank(record.Platform) < platformRank(prev.Platform) {
+			records[tag] = record
+		}
+	}
+	if len(tags) == 0 {
+		return "", ""
+	}
+
+	// the first supported tag is the fallback when no language matches: English or else the first record
+	supported := make([]language.Tag, 0, len(tags))
+	supportedTags := make([]string, 0, len(tags))
+	for _, fallback := range []string{"en-US", "en"} {
+		if _, ok := records[fallback]; ok {
+			supported = append(supported, language.Make(fallback))
+			supportedTags = append(supportedTags, fallback)
+			break
+		}
+	}
+	for _, tag := range tags {
+		if 0 < len(supportedTags) && supportedTags[0] == tag {
+			continue
+		}
+		supported = append(supported, language.Make(tag))
+		supportedTags = append(supportedTags, tag)
+	}
+
+	preferred := make([]language.Tag, 0, len(languages))
+	for _, lang := range languages {
+		if tag, err := language.Parse(lang); err == nil {
+			preferred = append(preferred, tag)
+		}
+	}
+	i := 0
+	if 0 < len(preferred) {
+		_, index, confidence := language.NewMatcher(supported).Match(preferred...)
+		if confidence != language.No {
+			i = index
+		}
+	}
+	return records[supportedTags[i]].String(), supportedTags[i]
+}
+
+// Names are the decoded names of a font.
+type Names struct {
+	Language             string // BCP-47 language tag of the family name, empty if unknown
+	Family               string
+	Subfamily            string
+	TypographicFamily    string
+	TypographicSubfamily string
+	FullName             string
+	PostScriptName       string
+	Version              string
+	Copyright            string
+	License              string
+	Designer             string
+}
+
+// Names returns the decoded names of the font. The language is selected by the preferred BCP-47 language tags in order, such as "ja" or "zh-Hant-TW", falling back to English and then to any language. Each name is selected independently, so that names that are not localized are still returned.
+func (sfnt *SFNT) Names(languages ...string) Names {
+	names := Names{}
+	names.Family, names.Language = sfnt.nameString(NameFontFamily, languages)
+	names.Subfamily, _ = sfnt.nameString(NameFontSubfamily, languages)
+	names.TypographicFamily, _ = sfnt.nameString(NamePreferredFamily, languages)
+	names.TypographicSubfamily, _ = sfnt.nameString(NamePreferredSubfamily, languages)
+	names.FullName, _ = sfnt.nameString(NameFull, languages)
+	names.PostScriptName, _ = sfnt.nameString(NamePostScript, languages)
+	names.Version, _ = sfnt.nameString(NameVersion, languages)
+	names.Copyright, _ = sfnt.nameString(NameCopyrightNotice, languages)
+	names.License, _ = sfnt.nameString(NameLicense, languages)
+	names.Designer, _ = sfnt.nameString(NameDesigner, languages)
+	return names
+}
+
 ////////////////////////////////////////////////////////////////
 
 type os2Table struct {
@@ -1367,39 +1588,108 @@ func (sfnt *SFNT) parseOS2() error {
 
 type bboxPather struct {
 	xMin, xMax, yMin, yMax float64
+	x, y                   float64
+	hasPoints              bool
+}
+
+func (p *bboxPather) add(x, y float64) {
+	if !p.hasPoints {
+		p.xMin, p.xMax, p.yMin, p.yMax = x, x, y, y
+		p.hasPoints = true
+	} else {
+		p.xMin = math.Min(p.xMin, x)
+		p.xMax = math.Max(p.xMax, x)
+		p.yMin = math.Min(p.yMin, y)
+		p.yMax = math.Max(p.yMax, y)
+	}
+	p.x, p.y = x, y
 }
 
 func (p *bboxPather) MoveTo(x float64, y float64) {
-	p.xMin = math.Min(p.xMin, x)
-	p.xMax = math.Max(p.xMax, x)
-	p.yMin = math.Min(p.yMin, y)
-	p.yMax = math.Max(p.yMax, y)
+	p.add(x, y)
 }
 
 func (p *bboxPather) LineTo(x float64, y float64) {
-	p.xMin = math.Min(p.xMin, x)
-	p.xMax = math.Max(p.xMax, x)
-	p.yMin = math.Min(p.yMin, y)
-	p.yMax = math.Max(p.yMax, y)
+	p.add(x, y)
 }
 
 func (p *bboxPather) QuadTo(cpx float64, cpy float64, x float64, y float64) {
-	p.xMin = math.Min(math.Min(p.xMin, cpx), x)
-	p.xMax = math.Max(math.Max(p.xMax, cpx), x)
-	p.yMin = math.Min(math.Min(p.yMin, cpy), y)
-	p.yMax = math.Max(math.Max(p.yMax, cpy), y)
+	// add the extrema of the curve, where its derivative is zero
+	x0, y0 := p.x, p.y
+	for _, t := range []float64{quadExtremum(x0, cpx, x), quadExtremum(y0, cpy, y)} {
+		if 0.0 < t && t < 1.0 {
+			p.add((1-t)*(1-t)*x0+2*(1-t)*t*cpx+t*t*x, (1-t)*(1-t)*y0+2*(1-t)*t*cpy+t*t*y)
+		}
+	}
+	p.add(x, y)
 }
 
 func (p *bboxPather) CubeTo(cpx1 float64, cpy1 float64, cpx2 float64, cpy2 float64, x float64, y float64) {
-	p.xMin = math.Min(math.Min(math.Min(p.xMin, cpx1), cpx2), x)
-	p.xMax = math.Max(math.Max(math.Max(p.xMax, cpx1), cpx2), x)
-	p.yMin = math.Min(math.Min(math.Min(p.yMin, cpy1), cpy2), y)
-	p.yMax = math.Max(math.Max(math.Max(p.yMax, cpy1), cpy2), y)
+	// add the extrema of the curve, where its derivative is zero
+	x0, y0 := p.x, p.y
+	ts := append(cubeExtrema(x0, cpx1, cpx2, x), cubeExtrema(y0, cpy1, cpy2, y)...)
+	for _, t := range ts {
+		if 0.0 < t && t < 1.0 {
+			u := 1 - t
+			p.add(u*u*u*x0+3*u*u*t*cpx1+3*u*t*t*cpx2+t*t*t*x, u*u*u*y0+3*u*u*t*cpy1+3*u*t*t*cpy2+t*t*t*y)
+		}
+	}
+	p.add(x, y)
 }
 
 func (p *bboxPather) Close() {
 }
 
+// quadExtremum returns the parameter t of the extremum of a quadratic Bézier in one dimension, or -1 if there is none.
+func quadExtremum(p0, p1, p2 float64) float64 {
+	div := p0 - 2*p1 + p2
+	if div == 0.0 {
+		return -1.0
+	}
+	return (p0 - p1) / div
+}
+
+// cubeExtrema returns the parameters t of the extrema of a cubic Bézier in one dimension.
+func cubeExtrema(p0, p1, p2, p3 float64) []float64 {
+	// derivative is a*t^2 + b*t + c
+	a := 3 * (-p0 + 3*p1 - 3*p2 + p3)
+	b := 6 * (p0 - 2*p1 + p2)
+	c := 3 * (p1 - p0)
+	if math.Abs(a) < 1e-12 {
+		if b == 0.0 {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	discriminant := b*b - 4*a*c
+	if discriminant < 0.0 {
+		return nil
+	}
+	sqrt := math.Sqrt(discriminant)
+	return []float64{(-b + sqrt) / (2 * a), (-b - sqrt) / (2 * a)}
+}
+
+// GlyphBounds returns the bounding box of the glyph's outline in font units. For TrueType fonts the bounds are taken from the glyph header, for CFF fonts they are calculated from the outline. Glyphs without an outline, such as spaces, have zero bounds.
+func (sfnt *SFNT) GlyphBounds(glyphID uint16) (xMin, yMin, xMax, yMax int16, err error) {
+	if sfnt.NumGlyphs() <= glyphID {
+		return 0, 0, 0, 0, fmt.Errorf("bad glyphID %v", glyphID)
+	} else if sfnt.IsTrueType {
+		contour, err := sfnt.Glyf.Contour(glyphID, 0)
+		if err != nil {
+			return 0, 0, 0, 0, err
+		}
+		return contour.XMin, contour.YMin, contour.XMax, contour.YMax, nil
+	}
+
+	p := &bboxPather{}
+	if err := sfnt.GlyphPath(p, glyphID, 0, 0, 0, 1.0, NoHinting); err != nil {
+		return 0, 0, 0, 0, err
+	} else if !p.hasPoints {
+		return 0, 0, 0, 0, nil
+	}
+	return int16(math.Floor(p.xMin)), int16(math.Floor(p.yMin)), int16(math.Ceil(p.xMax)), int16(math.Ceil(p.yMax)), nil
+}
+
 func (sfnt *SFNT) estimateOS2() {
 	if sfnt.IsTrueType {
 		contour, err := sfnt.Glyf.Contour(sfnt.GlyphIndex('x'), 0)
@@ -1413,17 +1703,93 @@ func (sfnt *SFNT) estimateOS2() {
 		}
 	} else if sfnt.IsCFF {
 		p := &bboxPather{}
-		if err := sfnt.CFF.ToPath(p, sfnt.GlyphIndex('x'), 0, 0, 0, 1.0, NoHinting); err == nil {
+		if err := sfnt.GlyphPath(p, sfnt.GlyphIndex('x'), 0, 0, 0, 1.0, NoHinting); err == nil {
 			sfnt.OS2.SxHeight = int16(p.yMax)
 		}
 
 		p = &bboxPather{}
-		if err := sfnt.CFF.ToPath(p, sfnt.GlyphIndex('H'), 0, 0, 0, 1.0, NoHinting); err == nil {
+		if err := sfnt.GlyphPath(p, sfnt.GlyphIndex('H'), 0, 0, 0, 1.0, NoHinting); err == nil {
 			sfnt.OS2.SCapHeight = int16(p.yMax)
 		}
 	}
 }
 
+// ErrEmbeddingRestricted is returned when the font's license does not allow embedding.
+var ErrEmbeddingRestricted = fmt.Errorf("font license does not allow embedding")
+
+// ErrSubsettingRestricted is returned when the font's license does not allow subsetting.
+var ErrSubsettingRestricted = fmt.Errorf("font license does not allow subsetting")
+
+// ErrBitmapEmbeddingOnly is returned when the font's license only allows embedding bitmaps and not outlines.
+var ErrBitmapEmbeddingOnly = fmt.Errorf("font license only allows bitmap embedding")
+
+// EmbeddingPermissions are the licensing rights for embedding the font, as specified by the fsType field of the OS/2 table. When none of Restricted, PreviewPrint, or Editable is set, the font is installable and may be embedded without restrictions.
+type EmbeddingPermissions struct {
+	Restricted   bool // must not be embedded or exchanged
+	PreviewPrint bool // may be embedded in documents that are opened read-only
+	Editable     bool // may be embedded in documents that can be edited
+	NoSubsetting bool // must be embedded as a whole
+	BitmapOnly   bool // only bitmaps may be embedded, not outlines
+}
+
+// Installable returns true if the font may be embedded and installed permanently.
+func (p EmbeddingPermissions) Installable() bool {
+	return !p.Restricted && !p.PreviewPrint && !p.Editable
+}
+
+// Check returns an error if the permissions do not allow embedding the font's outlines, or subsetting the font if subset is true.
+func (p EmbeddingPermissions) Check(subset bool) error {
+	if p.Restricted {
+		return ErrEmbeddingRestricted
+	} else if p.BitmapOnly {
+		return ErrBitmapEmbeddingOnly
+	} else if subset && p.NoSubsetting {
+		return ErrSubsettingRestricted
+	}
+	return nil
+}
+
+func (p EmbeddingPermissions) String() string {
+	s := "installable"
+	if p.Restricted {
+		s = "restricted"
+	} else if p.PreviewPrint {
+		s = "preview&print"
+	} else if p.Editable {
+		s = "editable"
+	}
+	if p.NoSubsetting {
+		s += ", no subsetting"
+	}
+	if p.BitmapOnly {
+		s += ", bitmap only"
+	}
+	return s
+}
+
+// EmbeddingPermissions returns the licensing rights for embedding the font from the fsType field of the OS/2 table. When multiple usage permissions are set, the least restrictive takes precedence.
+func (sfnt *SFNT) EmbeddingPermissions() EmbeddingPermissions {
+	if sfnt.OS2 == nil {
+		return EmbeddingPermissions{}
+	}
+	fsType := sfnt.OS2.FsType
+	return EmbeddingPermissions{
+		Restricted:   fsType&0x000E == 0x0002,
+		PreviewPrint: fsType&0x000C == 0x0004,
+		Editable:     fsType&0x0008 != 0,
+		NoSubsetting: fsType&0x0100 != 0,
+		BitmapOnly:   fsType&0x0200 != 0,
+	}
+}
+
+// CheckEmbedding returns an error if EnforceEmbedding is set and the font's license does not allow embedding its outlines, or subsetting the font if subset is true. It should be called by every code path that embeds the font in a document.
+func (sfnt *SFNT) CheckEmbedding(subset bool) error {
+	if !sfnt.EnforceEmbedding {
+		return nil
+	}
+	return sfnt.EmbeddingPermissions().Check(subset)
+}
+
 ////////////////////////////////////////////////////////////////
 
 type postTable struct {
@@ -1461,7 +1827,7 @@ func (sfnt *SFNT) parsePost() error {
 		return fmt.Errorf("post: bad table")
 	}
 
-	_, isCFF2 := sfnt.Tables["CFF2"]
+	isCFF2 := sfnt.hasTable("CFF2")
 
 	sfnt.Post = &postTable{}
 	r := NewBinaryReader(b)
@@ -0,0 +1,1094 @@
+package font
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// sfntBuilder assembles an SFNT font file from glyph outlines and font-wide metadata. It is used to convert other font formats to OpenType.
+type sfntBuilder struct {
+	PostScriptName     string
+	FamilyName         string
+	SubfamilyName      string
+	FullName           string
+	Version            string
+	Copyright          string
+	Weight             string
+	UnitsPerEm         uint16
+	Ascender           int16
+	Descender          int16
+	LineGap            int16
+	CapHeight          int16
+	XHeight            int16
+	ItalicAngle        float64
+	UnderlinePosition  int16
+	UnderlineThickness int16
+	IsFixedPitch       bool
+	FontMatrix         [6]float64 // CFF font matrix, zero for the default of 1/UnitsPerEm
+
+	Glyphs  []sfntBuilderGlyph // the first glyph must be .notdef
+	Kerning []kernPair         // kerning pairs by glyph ID
+}
+
+// sfntBuilderGlyph is a glyph of an sfntBuilder.
+type sfntBuilderGlyph struct {
+	Name       string
+	Runes      []rune
+	Advance    uint16
+	XMin, YMin int16
+	XMax, YMax int16
+	CharString []byte // Type 2 charstring
+	Glyf       []byte // TrueType glyph data
+}
+
+// WriteCFF writes an OpenType font with CFF outlines.
+func (b *sfntBuilder) WriteCFF() []byte {
+	tables := b.tables()
+	tables["CFF "] = b.cff()
+	sfnt := &SFNT{
+		Version: "OTTO",
+		IsCFF:   true,
+		Tables:  tables,
+	}
+	return sfnt.Write()
+}
+
+// WriteTrueType writes an OpenType font with TrueType outlines. The bounds of composite glyphs are recalculated from their components.
+func (b *sfntBuilder) WriteTrueType() []byte {
+	sfnt := &SFNT{
+		Version:    "\x00\x01\x00\x00",
+		IsTrueType: true,
+		Tables:     b.trueTypeTables(),
+	}
+	return sfnt.Write()
+}
+
+// trueTypeTables returns all tables including glyf and loca.
+func (b *sfntBuilder) trueTypeTables() map[string][]byte {
+	glyf, loca, locaFormat := b.glyf()
+	tables := b.tables()
+	tables["glyf"] = glyf
+	tables["loca"] = loca
+	tables["maxp"] = b.maxpTrueType(glyf, loca, locaFormat)
+	binary.BigEndian.PutUint16(tables["head"][50:], uint16(locaFormat))
+	return tables
+}
+
+// tables returns all tables except those that hold the glyph outlines.
+func (b *sfntBuilder) tables() map[string][]byte {
+	tables := map[string][]byte{
+		"cmap": b.cmap(),
+		"head": b.head(),
+		"hhea": b.hhea(),
+		"hmtx": b.hmtx(),
+		"maxp": b.maxp(),
+		"name": b.name(),
+		"OS/2": b.os2(),
+		"post": b.post(),
+	}
+	if 0 < len(b.Kerning) {
+		tables["kern"] = b.kern()
+	}
+	return tables
+}
+
+func (b *sfntBuilder) bounds() (xMin, yMin, xMax, yMax int16) {
+	first := true
+	for _, glyph := range b.Glyphs {
+		if glyph.XMin == glyph.XMax && glyph.YMin == glyph.YMax {
+			continue
+		} else if first {
+			xMin, yMin, xMax, yMax = glyph.XMin, glyph.YMin, glyph.XMax, glyph.YMax
+			first = false
+			continue
+		}
+		if glyph.XMin < xMin {
+			xMin = glyph.XMin
+		}
+		if glyph.YMin < yMin {
+			yMin = glyph.YMin
+		}
+		if xMax < glyph.XMax {
+			xMax = glyph.XMax
+		}
+		if yMax < glyph.YMax {
+			yMax = glyph.YMax
+		}
+	}
+	return
+}
+
+func (b *sfntBuilder) runes() map[rune]uint16 {
+	runes := map[rune]uint16{}
+	for glyphID, glyph := range b.Glyphs {
+		for _, r := range glyph.Runes {
+			if _, ok := runes[r]; !ok && 0 < glyphID {
+				runes[r] = uint16(glyphID)
+			}
+		}
+	}
+	return runes
+}
+
+func (b *sfntBuilder) isBold() bool {
+	return 700 <= weightClass(b.Weight)
+}
+
+func (b *sfntBuilder) isItalic() bool {
+	return b.ItalicAngle != 0.0
+}
+
+func (b *sfntBuilder) head() []byte {
+	xMin, yMin, xMax, yMax := b.bounds()
+	macStyle := uint16(0)
+	if b.isBold() {
+		macStyle |= 0x0001
+	}
+	if b.isItalic() {
+		macStyle |= 0x0002
+	}
+	now := int64(time.Now().UTC().Sub(time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)) / 1e9)
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(1)                         // majorVersion
+	w.WriteUint16(0)                         // minorVersion
+	w.WriteUint32(versionToFixed(b.Version)) // fontRevision
+	w.WriteUint32(0)                         // checksumAdjustment
+	w.WriteUint32(0x5F0F3CF5)                // magicNumber
+	w.WriteUint16(0x0003)                    // flags
+	w.WriteUint16(b.UnitsPerEm)              // unitsPerEm
+	w.WriteInt64(now)                        // created
+	w.WriteInt64(now)                        // modified
+	w.WriteInt16(xMin)                       // xMin
+	w.WriteInt16(yMin)                       // yMin
+	w.WriteInt16(xMax)                       // xMax
+	w.WriteInt16(yMax)                       // yMax
+	w.WriteUint16(macStyle)                  // macStyle
+	w.WriteUint16(8)                         // lowestRecPPEM
+	w.WriteInt16(2)                          // fontDirectionHint
+	w.WriteInt16(0)                          // indexToLocFormat
+	w.WriteInt16(0)                          // glyphDataFormat
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) hhea() []byte {
+	advanceWidthMax := uint16(0)
+	minLeftSideBearing, minRightSideBearing, xMaxExtent := int16(math.MaxInt16), int16(math.MaxInt16), int16(0)
+	for _, glyph := range b.Glyphs {
+		if advanceWidthMax < glyph.Advance {
+			advanceWidthMax = glyph.Advance
+		}
+		if glyph.XMin == glyph.XMax && glyph.YMin == glyph.YMax {
+			continue
+		}
+		if glyph.XMin < minLeftSideBearing {
+			minLeftSideBearing = glyph.XMin
+		}
+		if rsb := int16(glyph.Advance) - glyph.XMax; rsb < minRightSideBearing {
+			minRightSideBearing = rsb
+		}
+		if xMaxExtent < glyph.XMax {
+			xMaxExtent = glyph.XMax
+		}
+	}
+	if minLeftSideBearing == math.MaxInt16 {
+		minLeftSideBearing, minRightSideBearing = 0, 0
+	}
+
+	caretSlopeRise, caretSlopeRun := int16(1), int16(0)
+	if b.isItalic() {
+		caretSlopeRise = int16(b.UnitsPerEm)
+		caretSlopeRun = int16(math.Round(-float64(b.UnitsPerEm) * math.Tan(b.ItalicAngle*math.Pi/180.0)))
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(1)                     // majorVersion
+	w.WriteUint16(0)                     // minorVersion
+	w.WriteInt16(b.Ascender)             // ascender
+	w.WriteInt16(b.Descender)            // descender
+	w.WriteInt16(b.LineGap)              // lineGap
+	w.WriteUint16(advanceWidthMax)       // advanceWidthMax
+	w.WriteInt16(minLeftSideBearing)     // minLeftSideBearing
+	w.WriteInt16(minRightSideBearing)    // minRightSideBearing
+	w.WriteInt16(xMaxExtent)             // xMaxExtent
+	w.WriteInt16(caretSlopeRise)         // caretSlopeRise
+	w.WriteInt16(caretSlopeRun)          // caretSlopeRun
+	w.WriteInt16(0)                      // caretOffset
+	w.WriteInt64(0)                      // reserved
+	w.WriteInt16(0)                      // metricDataFormat
+	w.WriteUint16(uint16(len(b.Glyphs))) // numberOfHMetrics
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) hmtx() []byte {
+	w := NewBinaryWriter([]byte{})
+	for _, glyph := range b.Glyphs {
+		w.WriteUint16(glyph.Advance) // advanceWidth
+		w.WriteInt16(glyph.XMin)     // lsb
+	}
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) maxp() []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint32(0x00005000)            // version
+	w.WriteUint16(uint16(len(b.Glyphs))) // numGlyphs
+	return w.Bytes()
+}
+
+// glyf writes the glyf and loca tables and updates the bounds of composite glyphs.
+func (b *sfntBuilder) glyf() ([]byte, []byte, int16) {
+	w := NewBinaryWriter([]byte{})
+	offsets := make([]uint32, len(b.Glyphs)+1)
+	for glyphID, glyph := range b.Glyphs {
+		offsets[glyphID] = w.Len()
+		w.WriteBytes(glyph.Glyf)
+		if len(glyph.Glyf)%2 == 1 {
+			w.WriteByte(0)
+		}
+	}
+	offsets[len(b.Glyphs)] = w.Len()
+
+	locaFormat := int16(0)
+	if math.MaxUint16 < offsets[len(b.Glyphs)]/2 {
+		locaFormat = 1
+	}
+	loca := NewBinaryWriter([]byte{})
+	for _, offset := range offsets {
+		if locaFormat == 0 {
+			loca.WriteUint16(uint16(offset / 2))
+		} else {
+			loca.WriteUint32(offset)
+		}
+	}
+
+	glyf := &glyfTable{
+		data: w.Bytes(),
+		loca: &locaTable{
+			format: locaFormat,
+			data:   loca.Bytes(),
+		},
+	}
+	for glyphID := range b.Glyphs {
+		data := glyf.data[offsets[glyphID]:offsets[glyphID+1]]
+		if len(data) < 10 {
+			continue
+		}
+		if int16(binary.BigEndian.Uint16(data)) < 0 {
+			if contour, err := glyf.Contour(uint16(glyphID), 0); err == nil && 0 < len(contour.XCoordinates) {
+				xMin, yMin, xMax, yMax := glyfPointsBounds(contour)
+				binary.BigEndian.PutUint16(data[2:], uint16(xMin))
+				binary.BigEndian.PutUint16(data[4:], uint16(yMin))
+				binary.BigEndian.PutUint16(data[6:], uint16(xMax))
+				binary.BigEndian.PutUint16(data[8:], uint16(yMax))
+			}
+		}
+		glyph := &b.Glyphs[glyphID]
+		glyph.XMin = int16(binary.BigEndian.Uint16(data[2:]))
+		glyph.YMin = int16(binary.BigEndian.Uint16(data[4:]))
+		glyph.XMax = int16(binary.BigEndian.Uint16(data[6:]))
+		glyph.YMax = int16(binary.BigEndian.Uint16(data[8:]))
+	}
+	return glyf.data, glyf.loca.data, locaFormat
+}
+
+// maxpTrueType writes a version 1.0 maxp table, the glyphs are expected to have no instructions.
+func (b *sfntBuilder) maxpTrueType(data, loca []byte, locaFormat int16) []byte {
+	glyf := &glyfTable{
+		data: data,
+		loca: &locaTable{
+			format: locaFormat,
+			data:   loca,
+		},
+	}
+
+	var maxPoints, maxContours, maxCompositePoints, maxCompositeContours, maxComponentElements, maxComponentDepth uint16
+	var depth func(uint16, int) uint16
+	depth = func(glyphID uint16, level int) uint16 {
+		components, err := parseGlyfComponents(glyf.Get(glyphID))
+		if err != nil || 7 < level {
+			return 0
+		}
+		d := uint16(0)
+		for _, component := range components {
+			if sub := glyf.Get(component.glyphID); 10 <= len(sub) && int16(binary.BigEndian.Uint16(sub)) < 0 {
+				if dd := depth(component.glyphID, level+1); d < dd {
+					d = dd
+				}
+			}
+		}
+		return d + 1
+	}
+	for glyphID := range b.Glyphs {
+		data := b.Glyphs[glyphID].Glyf
+		if len(data) < 10 {
+			continue
+		}
+		contour, err := glyf.Contour(uint16(glyphID), 0)
+		if err != nil {
+			continue
+		}
+		numPoints, numContours := uint16(len(contour.XCoordinates)), uint16(len(contour.EndPoints))
+		if 0 <= int16(binary.BigEndian.Uint16(data)) {
+			if maxPoints < numPoints {
+				maxPoints = numPoints
+			}
+			if maxContours < numContours {
+				maxContours = numContours
+			}
+		} else {
+			if maxCompositePoints < numPoints {
+				maxCompositePoints = numPoints
+			}
+			if maxCompositeContours < numContours {
+				maxCompositeContours = numContours
+			}
+			if components, err := parseGlyfComponents(data); err == nil && maxComponentElements < uint16(len(components)) {
+				maxComponentElements = uint16(len(components))
+			}
+			if d := depth(uint16(glyphID), 0); maxComponentDepth < d {
+				maxComponentDepth = d
+			}
+		}
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint32(0x00010000)            // version
+	w.WriteUint16(uint16(len(b.Glyphs))) // numGlyphs
+	w.WriteUint16(maxPoints)             // maxPoints
+	w.WriteUint16(maxContours)           // maxContours
+	w.WriteUint16(maxCompositePoints)    // maxCompositePoints
+	w.WriteUint16(maxCompositeContours)  // maxCompositeContours
+	w.WriteUint16(1)                     // maxZones
+	w.WriteUint16(0)                     // maxTwilightPoints
+	w.WriteUint16(0)                     // maxStorage
+	w.WriteUint16(0)                     // maxFunctionDefs
+	w.WriteUint16(0)                     // maxInstructionDefs
+	w.WriteUint16(0)                     // maxStackElements
+	w.WriteUint16(0)                     // maxSizeOfInstructions
+	w.WriteUint16(maxComponentElements)  // maxComponentElements
+	w.WriteUint16(maxComponentDepth)     // maxComponentDepth
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) os2() []byte {
+	runes := b.runes()
+	firstCharIndex, lastCharIndex := uint16(0xFFFF), uint16(0)
+	unicodeRanges := [4]uint32{}
+	for r := range runes {
+		if 0xFFFF <= r {
+			lastCharIndex = 0xFFFF
+		} else {
+			if uint16(r) < firstCharIndex {
+				firstCharIndex = uint16(r)
+			}
+			if lastCharIndex < uint16(r) {
+				lastCharIndex = uint16(r)
+			}
+		}
+		for _, unicodeRange := range os2UnicodeRanges {
+			if unicodeRange.start <= r && r <= unicodeRange.end {
+				unicodeRanges[unicodeRange.bit/32] |= 1 << uint(unicodeRange.bit%32)
+			}
+		}
+	}
+	if 0xFFFF <= firstCharIndex {
+		firstCharIndex = 0
+	}
+	codePageRanges := [2]uint32{}
+	if unicodeRanges[0]&0x3 == 0x3 {
+		codePageRanges[0] |= 0x00000001 // Latin 1
+	}
+	if unicodeRanges[1]&0x10000000 != 0 {
+		codePageRanges[0] |= 0x80000000 // symbol character set
+	}
+
+	avgCharWidth, n := 0, 0
+	for _, glyph := range b.Glyphs {
+		if 0 < glyph.Advance {
+			avgCharWidth += int(glyph.Advance)
+			n++
+		}
+	}
+	if 0 < n {
+		avgCharWidth /= n
+	}
+
+	fsSelection := uint16(0)
+	if b.isItalic() {
+		fsSelection |= 0x0001
+	}
+	if b.isBold() {
+		fsSelection |= 0x0020
+	}
+	if fsSelection == 0 {
+		fsSelection |= 0x0040 // regular
+	}
+
+	_, yMin, _, yMax := b.bounds()
+	winAscent, winDescent := b.Ascender, -b.Descender
+	if winAscent < yMax {
+		winAscent = yMax
+	}
+	if winDescent < -yMin {
+		winDescent = -yMin
+	}
+	em := float64(b.UnitsPerEm)
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(4)                     // version
+	w.WriteInt16(int16(avgCharWidth))    // xAvgCharWidth
+	w.WriteUint16(weightClass(b.Weight)) // usWeightClass
+	w.WriteUint16(5)                     // usWidthClass
+	w.WriteUint16(0)                     // fsType
+	w.WriteInt16(int16(0.65 * em))       // ySubscriptXSize
+	w.WriteInt16(int16(0.60 * em))       // ySubscriptYSize
+	w.WriteInt16(0)                      // ySubscriptXOffset
+	w.WriteInt16(int16(0.075 * em))      // ySubscriptYOffset
+	w.WriteInt16(int16(0.65 * em))       // ySuperscriptXSize
+	w.WriteInt16(int16(0.60 * em))       // ySuperscriptYSize
+	w.WriteInt16(0)                      // ySuperscriptXOffset
+	w.WriteInt16(int16(0.35 * em))       // ySuperscriptYOffset
+	w.WriteInt16(b.UnderlineThickness)   // yStrikeoutSize
+	w.WriteInt16(int16(0.25 * em))       // yStrikeoutPosition
+	w.WriteInt16(0)                      // sFamilyClass
+	w.WriteBytes(make([]byte, 10))       // panose
+	w.WriteUint32(unicodeRanges[0])      // ulUnicodeRange1
+	w.WriteUint32(unicodeRanges[1])      // ulUnicodeRange2
+	w.WriteUint32(unicodeRanges[2])      // ulUnicodeRange3
+	w.WriteUint32(unicodeRanges[3])      // ulUnicodeRange4
+	w.WriteString("NONE")                // achVendID
+	w.WriteUint16(fsSelection)           // fsSelection
+	w.WriteUint16(firstCharIndex)        // usFirstCharIndex
+	w.WriteUint16(lastCharIndex)         // usLastCharIndex
+	w.WriteInt16(b.Ascender)             // sTypoAscender
+	w.WriteInt16(b.Descender)            // sTypoDescender
+	w.WriteInt16(b.LineGap)              // sTypoLineGap
+	w.WriteUint16(uint16(winAscent))     // usWinAscent
+	w.WriteUint16(uint16(winDescent))    // usWinDescent
+	w.WriteUint32(codePageRanges[0])     // ulCodePageRange1
+	w.WriteUint32(codePageRanges[1])     // ulCodePageRange2
+	w.WriteInt16(b.XHeight)              // sxHeight
+	w.WriteInt16(b.CapHeight)            // sCapHeight
+	w.WriteUint16(0)                     // usDefaultChar
+	w.WriteUint16(32)                    // usBreakChar
+	w.WriteUint16(0)                     // usMaxContext
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) post() []byte {
+	isFixedPitch := uint32(0)
+	if b.IsFixedPitch {
+		isFixedPitch = 1
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint32(0x00030000)                                  // version
+	w.WriteInt32(int32(math.Round(b.ItalicAngle * (1 << 16)))) // italicAngle
+	w.WriteInt16(b.UnderlinePosition)                          // underlinePosition
+	w.WriteInt16(b.UnderlineThickness)                         // underlineThickness
+	w.WriteUint32(isFixedPitch)                                // isFixedPitch
+	w.WriteUint32(0)                                           // minMemType42
+	w.WriteUint32(0)                                           // maxMemType42
+	w.WriteUint32(0)                                           // minMemType1
+	w.WriteUint32(0)                                           // maxMemType1
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) name() []byte {
+	subfamilyName := b.SubfamilyName
+	if subfamilyName == "" {
+		if b.isBold() && b.isItalic() {
+			subfamilyName = "Bold Italic"
+		} else if b.isBold() {
+			subfamilyName = "Bold"
+		} else if b.isItalic() {
+			subfamilyName = "Italic"
+		} else {
+			subfamilyName = "Regular"
+		}
+	}
+	version := b.Version
+	if version != "" && !strings.HasPrefix(version, "Version ") {
+		version = "Version " + version
+	}
+	records := []struct {
+		nameID NameID
+		value  string
+	}{
+		{NameCopyrightNotice, b.Copyright},
+		{NameFontFamily, b.FamilyName},
+		{NameFontSubfamily, subfamilyName},
+		{NameUniqueIdentifier, strings.TrimSpace(b.PostScriptName + " " + b.Version)},
+		{NameFull, b.FullName},
+		{NameVersion, version},
+		{NamePostScript, b.PostScriptName},
+	}
+
+	n := 0
+	for _, record := range records {
+		if record.value != "" {
+			n++
+		}
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(0)                // version
+	w.WriteUint16(uint16(n))        // count
+	w.WriteUint16(uint16(6 + 12*n)) // storageOffset
+	storage := NewBinaryWriter([]byte{})
+	for _, record := range records {
+		if record.value == "" {
+			continue
+		}
+		offset := storage.Len()
+		for _, c := range utf16.Encode([]rune(record.value)) {
+			storage.WriteUint16(c)
+		}
+		w.WriteUint16(3)                              // platformID
+		w.WriteUint16(1)                              // encodingID
+		w.WriteUint16(0x0409)                         // languageID
+		w.WriteUint16(uint16(record.nameID))          // nameID
+		w.WriteUint16(uint16(storage.Len() - offset)) // length
+		w.WriteUint16(uint16(offset))                 // stringOffset
+	}
+	w.WriteBytes(storage.Bytes())
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) cmap() []byte {
+	runeMap := b.runes()
+	runes := make([]rune, 0, len(runeMap))
+	for r := range runeMap {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	// segments of consecutive runes mapping to consecutive glyph IDs
+	type segment struct {
+		start, end rune
+		glyphID    uint16
+	}
+	segments := []segment{}
+	for _, r := range runes {
+		glyphID := runeMap[r]
+		if 0 < len(segments) {
+			last := &segments[len(segments)-1]
+			if last.end+1 == r && uint32(last.glyphID)+uint32(r-last.start) == uint32(glyphID) {
+				last.end = r
+				continue
+			}
+		}
+		segments = append(segments, segment{r, r, glyphID})
+	}
+
+	// format 4 for the Basic Multilingual Plane
+	bmp := []segment{}
+	for _, seg := range segments {
+		if 0xFFFF <= seg.start {
+			break
+		} else if 0xFFFF <= seg.end {
+			seg.end = 0xFFFE
+		}
+		bmp = append(bmp, seg)
+	}
+	bmp = append(bmp, segment{0xFFFF, 0xFFFF, 0})
+
+	segCountX2 := uint16(2 * len(bmp))
+	entrySelector := uint16(math.Log2(float64(len(bmp))))
+	searchRange := uint16(2 * (1 << entrySelector))
+
+	format4 := NewBinaryWriter([]byte{})
+	format4.WriteUint16(4)                         // format
+	format4.WriteUint16(uint16(16 + 4*segCountX2)) // length
+	format4.WriteUint16(0)                         // language
+	format4.WriteUint16(segCountX2)                // segCountX2
+	format4.WriteUint16(searchRange)               // searchRange
+	format4.WriteUint16(entrySelector)             // entrySelector
+	format4.WriteUint16(segCountX2 - searchRange)  // rangeShift
+	for _, seg := range bmp {
+		format4.WriteUint16(uint16(seg.end)) // endCode
+	}
+	format4.WriteUint16(0) // reservedPad
+	for _, seg := range bmp {
+		format4.WriteUint16(uint16(seg.start)) // startCode
+	}
+	for _, seg := range bmp {
+		if seg.start == 0xFFFF {
+			format4.WriteUint16(1) // idDelta
+		} else {
+			format4.WriteUint16(seg.glyphID - uint16(seg.start)) // idDelta
+		}
+	}
+	for range bmp {
+		format4.WriteUint16(0) // idRangeOffset
+	}
+
+	// format 12 for all planes
+	format12 := NewBinaryWriter([]byte{})
+	format12.WriteUint16(12)                            // format
+	format12.WriteUint16(0)                             // reserved
+	format12.WriteUint32(uint32(16 + 12*len(segments))) // length
+	format12.WriteUint32(0)                             // language
+	format12.WriteUint32(uint32(len(segments)))         // numGroups
+	for _, seg := range segments {
+		format12.WriteUint32(uint32(seg.start))   // startCharCode
+		format12.WriteUint32(uint32(seg.end))     // endCharCode
+		format12.WriteUint32(uint32(seg.glyphID)) // startGlyphID
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(0)                       // version
+	w.WriteUint16(2)                       // numTables
+	w.WriteUint16(3)                       // platformID
+	w.WriteUint16(1)                       // encodingID
+	w.WriteUint32(4 + 2*8)                 // subtableOffset
+	w.WriteUint16(3)                       // platformID
+	w.WriteUint16(10)                      // encodingID
+	w.WriteUint32(4 + 2*8 + format4.Len()) // subtableOffset
+	w.WriteBytes(format4.Bytes())
+	w.WriteBytes(format12.Bytes())
+	return w.Bytes()
+}
+
+func (b *sfntBuilder) kern() []byte {
+	pairs := append(b.Kerning[:0:0], b.Kerning...)
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	if 10920 < len(pairs) {
+		pairs = pairs[:10920] // subtable length must fit in 16 bits
+	}
+
+	nPairs := uint16(len(pairs))
+	entrySelector := uint16(math.Log2(float64(nPairs)))
+	searchRange := uint16(1 << entrySelector * 6)
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(0)                // version
+	w.WriteUint16(1)                // nTables
+	w.WriteUint16(0)                // version
+	w.WriteUint16(6 + 8 + 6*nPairs) // length
+	w.WriteUint8(0)                 // format
+	w.WriteUint8(0x01)              // coverage
+	w.WriteUint16(nPairs)
+	w.WriteUint16(searchRange)
+	w.WriteUint16(entrySelector)
+	w.WriteUint16(nPairs*6 - searchRange)
+	for _, pair := range pairs {
+		w.WriteUint32(pair.Key)
+		w.WriteInt16(pair.Value)
+	}
+	return w.Bytes()
+}
+
+// cff writes a CFF table with a single font, a standard or custom charset, and no hints or subroutines.
+func (b *sfntBuilder) cff() []byte {
+	strs := []string{}
+	standardSIDs := make(map[string]int, len(cffStandardStrings))
+	for sid, s := range cffStandardStrings {
+		standardSIDs[s] = sid
+	}
+	sid := func(s string) int {
+		if sid, ok := standardSIDs[s]; ok {
+			return sid
+		}
+		for i, str := range strs {
+			if str == s {
+				return len(cffStandardStrings) + i
+			}
+		}
+		strs = append(strs, s)
+		return len(cffStandardStrings) + len(strs) - 1
+	}
+
+	// charset format 0
+	charset := NewBinaryWriter([]byte{})
+	charset.WriteUint8(0) // format
+	for _, glyph := range b.Glyphs[1:] {
+		charset.WriteUint16(uint16(sid(glyph.Name)))
+	}
+
+	charStrings := make([][]byte, len(b.Glyphs))
+	for i, glyph := range b.Glyphs {
+		charStrings[i] = glyph.CharString
+	}
+	charStringsINDEX := writeINDEX(charStrings)
+
+	private := &cffDICTWriter{}
+	private.Int(0)
+	private.Op(20) // defaultWidthX
+	private.Int(0)
+	private.Op(21) // nominalWidthX
+
+	xMin, yMin, xMax, yMax := b.bounds()
+	topDICT := func(charsetOffset, charStringsOffset, privateOffset int) []byte {
+		top := &cffDICTWriter{}
+		if b.Version != "" {
+			top.Int(sid(b.Version))
+			top.Op(0) // version
+		}
+		if b.Copyright != "" {
+			top.Int(sid(b.Copyright))
+			top.Op(1) // Notice
+		}
+		if b.FullName != "" {
+			top.Int(sid(b.FullName))
+			top.Op(2) // FullName
+		}
+		if b.FamilyName != "" {
+			top.Int(sid(b.FamilyName))
+			top.Op(3) // FamilyName
+		}
+		if b.Weight != "" {
+			top.Int(sid(b.Weight))
+			top.Op(4) // Weight
+		}
+		if b.IsFixedPitch {
+			top.Int(1)
+			top.Op(256 + 1) // isFixedPitch
+		}
+		if b.ItalicAngle != 0.0 {
+			top.Real(b.ItalicAngle)
+			top.Op(256 + 2) // ItalicAngle
+		}
+		top.Int(int(b.UnderlinePosition))
+		top.Op(256 + 3) // UnderlinePosition
+		top.Int(int(b.UnderlineThickness))
+		top.Op(256 + 4) // UnderlineThickness
+		fontMatrix := b.FontMatrix
+		if fontMatrix == [6]float64{} && b.UnitsPerEm != 0 {
+			// CFF defaults to 1/1000, which is wrong for fonts with other units per em
+			fontMatrix = [6]float64{1.0 / float64(b.UnitsPerEm), 0.0, 0.0, 1.0 / float64(b.UnitsPerEm), 0.0, 0.0}
+		}
+		if fontMatrix != [6]float64{} && fontMatrix != [6]float64{0.001, 0.0, 0.0, 0.001, 0.0, 0.0} {
+			for _, v := range fontMatrix {
+				top.Real(v)
+			}
+			top.Op(256 + 7) // FontMatrix
+		}
+		top.Int(int(xMin))
+		top.Int(int(yMin))
+		top.Int(int(xMax))
+		top.Int(int(yMax))
+		top.Op(5) // FontBBox
+		top.Int32(charsetOffset)
+		top.Op(15) // charset
+		top.Int32(charStringsOffset)
+		top.Op(17) // CharStrings
+		top.Int32(len(private.b))
+		top.Int32(privateOffset)
+		top.Op(18) // Private
+		return top.b
+	}
+
+	// the Top DICT has a fixed size regardless of the offsets, which allows to calculate the offsets beforehand
+	nameINDEX := writeINDEX([][]byte{[]byte(b.PostScriptName)})
+	topINDEX := writeINDEX([][]byte{topDICT(0, 0, 0)})
+	stringData := make([][]byte, len(strs))
+	for i, s := range strs {
+		stringData[i] = []byte(s)
+	}
+	stringINDEX := writeINDEX(stringData)
+	globalSubrsINDEX := writeINDEX(nil)
+
+	charsetOffset := 4 + len(nameINDEX) + len(topINDEX) + len(stringINDEX) + len(globalSubrsINDEX)
+	charStringsOffset := charsetOffset + int(charset.Len())
+	privateOffset := charStringsOffset + len(charStringsINDEX)
+	topINDEX = writeINDEX([][]byte{topDICT(charsetOffset, charStringsOffset, privateOffset)})
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint8(1) // major
+	w.WriteUint8(0) // minor
+	w.WriteUint8(4) // hdrSize
+	w.WriteUint8(4) // offSize
+	w.WriteBytes(nameINDEX)
+	w.WriteBytes(topINDEX)
+	w.WriteBytes(stringINDEX)
+	w.WriteBytes(globalSubrsINDEX)
+	w.WriteBytes(charset.Bytes())
+	w.WriteBytes(charStringsINDEX)
+	w.WriteBytes(private.b)
+	return w.Bytes()
+}
+
+// writeINDEX writes a CFF INDEX structure.
+func writeINDEX(items [][]byte) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(uint16(len(items))) // count
+	if len(items) == 0 {
+		return w.Bytes()
+	}
+
+	n := 1
+	for _, item := range items {
+		n += len(item)
+	}
+	offSize := uint8(1)
+	if 1<<24 <= n {
+		offSize = 4
+	} else if 1<<16 <= n {
+		offSize = 3
+	} else if 1<<8 <= n {
+		offSize = 2
+	}
+	w.WriteUint8(offSize) // offSize
+
+	offset := uint32(1)
+	writeOffset := func() {
+		for i := int(offSize) - 1; 0 <= i; i-- {
+			w.WriteUint8(uint8(offset >> (8 * uint(i))))
+		}
+	}
+	writeOffset()
+	for _, item := range items {
+		offset += uint32(len(item))
+		writeOffset()
+	}
+	for _, item := range items {
+		w.WriteBytes(item)
+	}
+	return w.Bytes()
+}
+
+// cffDICTWriter writes the operands and operators of a CFF DICT.
+type cffDICTWriter struct {
+	b []byte
+}
+
+// Int writes an integer operand in its shortest form.
+func (w *cffDICTWriter) Int(v int) {
+	if -107 <= v && v <= 107 {
+		w.b = append(w.b, byte(v+139))
+	} else if 108 <= v && v <= 1131 {
+		v -= 108
+		w.b = append(w.b, byte(v>>8+247), byte(v))
+	} else if -1131 <= v && v <= -108 {
+		v = -v - 108
+		w.b = append(w.b, byte(v>>8+251), byte(v))
+	} else if math.MinInt16 <= v && v <= math.MaxInt16 {
+		w.b = append(w.b, 28, byte(v>>8), byte(v))
+	} else {
+		w.Int32(v)
+	}
+}
+
+// Int32 writes an integer operand using five bytes.
+func (w *cffDICTWriter) Int32(v int) {
+	w.b = append(w.b, 29, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(w.b[len(w.b)-4:], uint32(int32(v)))
+}
+
+// Real writes a real operand, or an integer operand if it has no fraction.
+func (w *cffDICTWriter) Real(v float64) {
+	if v == math.Trunc(v) && math.Abs(v) < math.MaxInt32 {
+		w.Int(int(v))
+		return
+	}
+
+	nibbles := []byte{}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '.':
+			nibbles = append(nibbles, 0x0A)
+		case 'e':
+			if i+1 < len(s) && s[i+1] == '-' {
+				nibbles = append(nibbles, 0x0C)
+				i++
+			} else {
+				nibbles = append(nibbles, 0x0B)
+				if i+1 < len(s) && s[i+1] == '+' {
+					i++
+				}
+			}
+		case '-':
+			nibbles = append(nibbles, 0x0E)
+		default:
+			nibbles = append(nibbles, c-'0')
+		}
+	}
+	nibbles = append(nibbles, 0x0F)
+	if len(nibbles)%2 == 1 {
+		nibbles = append(nibbles, 0x0F)
+	}
+
+	w.b = append(w.b, 30)
+	for i := 0; i < len(nibbles); i += 2 {
+		w.b = append(w.b, nibbles[i]<<4|nibbles[i+1])
+	}
+}
+
+// Op writes an operator, two-byte operators are given as 256 plus the second byte.
+func (w *cffDICTWriter) Op(op int) {
+	if 256 <= op {
+		w.b = append(w.b, 12, byte(op-256))
+	} else {
+		w.b = append(w.b, byte(op))
+	}
+}
+
+// type2CharString is a Pather that encodes a glyph outline as a Type 2 charstring without hints, see https://adobe-type-tools.github.io/font-tech-notes/pdfs/5177.Type2.pdf. Coordinates are in font units and are rounded to 1/65536. The width is written as the first operand, which requires the Private DICT to have zero defaultWidthX and nominalWidthX.
+type type2CharString struct {
+	b       []byte
+	width   float64
+	x, y    float64
+	started bool
+}
+
+func newType2CharString(width float64) *type2CharString {
+	return &type2CharString{
+		width: width,
+	}
+}
+
+func (cs *type2CharString) number(v float64) {
+	if v == math.Trunc(v) && math.MinInt16 <= v && v <= math.MaxInt16 {
+		i := int(v)
+		if -107 <= i && i <= 107 {
+			cs.b = append(cs.b, byte(i+139))
+		} else if 108 <= i && i <= 1131 {
+			i -= 108
+			cs.b = append(cs.b, byte(i>>8+247), byte(i))
+		} else if -1131 <= i && i <= -108 {
+			i = -i - 108
+			cs.b = append(cs.b, byte(i>>8+251), byte(i))
+		} else {
+			cs.b = append(cs.b, 28, byte(i>>8), byte(i))
+		}
+		return
+	}
+	fixed := int32(math.Round(v * (1 << 16)))
+	cs.b = append(cs.b, 255, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(cs.b[len(cs.b)-4:], uint32(fixed))
+}
+
+func (cs *type2CharString) operator(op byte, coords ...float64) {
+	if !cs.started {
+		if cs.width != 0.0 {
+			cs.number(cs.width)
+		}
+		cs.started = true
+	}
+	for i := 0; i < len(coords); i += 2 {
+		// round to 16.16 fixed point to prevent accumulating errors
+		x := math.Round(coords[i]*(1<<16)) / (1 << 16)
+		y := math.Round(coords[i+1]*(1<<16)) / (1 << 16)
+		cs.number(x - cs.x)
+		cs.number(y - cs.y)
+		cs.x, cs.y = x, y
+	}
+	cs.b = append(cs.b, op)
+}
+
+func (cs *type2CharString) MoveTo(x float64, y float64) {
+	cs.operator(21, x, y) // rmoveto
+}
+
+func (cs *type2CharString) LineTo(x float64, y float64) {
+	cs.operator(5, x, y) // rlineto
+}
+
+func (cs *type2CharString) QuadTo(cpx float64, cpy float64, x float64, y float64) {
+	cpx1, cpy1 := cs.x+2.0/3.0*(cpx-cs.x), cs.y+2.0/3.0*(cpy-cs.y)
+	cpx2, cpy2 := x+2.0/3.0*(cpx-x), y+2.0/3.0*(cpy-y)
+	cs.operator(8, cpx1, cpy1, cpx2, cpy2, x, y) // rrcurveto
+}
+
+func (cs *type2CharString) CubeTo(cpx1 float64, cpy1 float64, cpx2 float64, cpy2 float64, x float64, y float64) {
+	cs.operator(8, cpx1, cpy1, cpx2, cpy2, x, y) // rrcurveto
+}
+
+func (cs *type2CharString) Close() {
+	// paths are closed implicitly
+}
+
+// Bytes ends the charstring and returns it.
+func (cs *type2CharString) Bytes() []byte {
+	cs.operator(14) // endchar
+	return cs.b
+}
+
+// os2UnicodeRanges are the Unicode blocks of common bits of ulUnicodeRange in the OS/2 table.
+var os2UnicodeRanges = []struct {
+	bit        int
+	start, end rune
+}{
+	{0, 0x0000, 0x007F},     // Basic Latin
+	{1, 0x0080, 0x00FF},     // Latin-1 Supplement
+	{2, 0x0100, 0x017F},     // Latin Extended-A
+	{3, 0x0180, 0x024F},     // Latin Extended-B
+	{4, 0x0250, 0x02AF},     // IPA Extensions
+	{5, 0x02B0, 0x02FF},     // Spacing Modifier Letters
+	{6, 0x0300, 0x036F},     // Combining Diacritical Marks
+	{7, 0x0370, 0x03FF},     // Greek and Coptic
+	{9, 0x0400, 0x04FF},     // Cyrillic
+	{11, 0x0590, 0x05FF},    // Hebrew
+	{13, 0x0600, 0x06FF},    // Arabic
+	{24, 0x0E00, 0x0E7F},    // Thai
+	{29, 0x1E00, 0x1EFF},    // Latin Extended Additional
+	{31, 0x2000, 0x206F},    // General Punctuation
+	{32, 0x2070, 0x209F},    // Superscripts And Subscripts
+	{33, 0x20A0, 0x20CF},    // Currency Symbols
+	{35, 0x2100, 0x214F},    // Letterlike Symbols
+	{36, 0x2150, 0x218F},    // Number Forms
+	{37, 0x2190, 0x21FF},    // Arrows
+	{38, 0x2200, 0x22FF},    // Mathematical Operators
+	{39, 0x2300, 0x23FF},    // Miscellaneous Technical
+	{43, 0x2500, 0x257F},    // Box Drawing
+	{44, 0x2580, 0x259F},    // Block Elements
+	{45, 0x25A0, 0x25FF},    // Geometric Shapes
+	{46, 0x2600, 0x26FF},    // Miscellaneous Symbols
+	{47, 0x2700, 0x27BF},    // Dingbats
+	{48, 0x3000, 0x303F},    // CJK Symbols And Punctuation
+	{49, 0x3040, 0x309F},    // Hiragana
+	{50, 0x30A0, 0x30FF},    // Katakana
+	{56, 0xAC00, 0xD7AF},    // Hangul Syllables
+	{59, 0x4E00, 0x9FFF},    // CJK Unified Ideographs
+	{60, 0xE000, 0xF8FF},    // Private Use Area
+	{62, 0xFB00, 0xFB4F},    // Alphabetic Presentation Forms
+	{65, 0xFE20, 0xFE2F},    // Combining Half Marks
+	{68, 0xFF00, 0xFFEF},    // Halfwidth And Fullwidth Forms
+	{69, 0xFFF0, 0xFFFF},    // Specials
+	{57, 0x10000, 0x10FFFF}, // Non-Plane 0
+}
+
+// weightClass returns the usWeightClass of the OS/2 table for a weight name such as "Bold".
+func weightClass(weight string) uint16 {
+	weight = strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(weight))
+	switch weight {
+	case "thin", "hairline":
+		return 100
+	case "extralight", "ultralight":
+		return 200
+	case "light":
+		return 300
+	case "medium":
+		return 500
+	case "semibold", "demibold", "demi":
+		return 600
+	case "bold":
+		return 700
+	case "extrabold", "ultrabold":
+		return 800
+	case "black", "heavy":
+		return 900
+	}
+	return 400
+}
+
+// versionToFixed converts a version string such as "1.002" or "Version 001.002" to the 16.16 fixed-point fontRevision of the head table.
+func versionToFixed(version string) uint32 {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "Version ")
+	if i := strings.IndexFunc(version, func(r rune) bool { return (r < '0' || '9' < r) && r != '.' }); i != -1 {
+		version = version[:i]
+	}
+	f, err := strconv.ParseFloat(version, 64)
+	if err != nil || f < 0.0 || math.MaxInt16 < f {
+		return 0x00010000
+	}
+	return uint32(math.Round(f * (1 << 16)))
+}
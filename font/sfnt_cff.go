@@ -6,7 +6,6 @@ import (
 	"strconv"
 )
 
-// TODO: use FDSelect for Font DICTs
 // TODO: CFF has winding rule even-odd? CFF2 has winding rule nonzero
 
 type cffTable struct {
@@ -14,6 +13,14 @@ type cffTable struct {
 	globalSubrs *cffINDEX
 	localSubrs  *cffINDEX
 	charStrings *cffINDEX
+	maxNesting  int
+
+	// CFF2
+	fdSelect     []uint16 // Font DICT index per glyph, nil for a single Font DICT
+	fdLocalSubrs []*cffINDEX
+	fdVsindex    []int
+	vstore       *itemVariationStore
+	coords       []float64 // normalized variation coordinates for blend, nil for the default instance
 }
 
 func (sfnt *SFNT) parseCFF() error {
@@ -103,13 +110,12 @@ func (sfnt *SFNT) parseCFF() error {
 		globalSubrs: globalSubrsINDEX,
 		localSubrs:  localSubrsINDEX,
 		charStrings: charStringsINDEX,
+		maxNesting:  sfnt.opts.MaxCFFNesting,
 	}
 	return nil
 }
 
 func (sfnt *SFNT) parseCFF2() error {
-	return fmt.Errorf("CFF2: not supported")
-
 	b, ok := sfnt.Tables["CFF2"]
 	if !ok {
 		return fmt.Errorf("CFF2: missing table")
@@ -126,6 +132,9 @@ func (sfnt *SFNT) parseCFF2() error {
 		return fmt.Errorf("CFF2: bad headerSize")
 	}
 	topDictLength := r.ReadUint16()
+	if r.Len() < uint32(topDictLength) {
+		return fmt.Errorf("CFF2: bad Top DICT length")
+	}
 
 	topDICT, err := parseTopDICT2(r.ReadBytes(uint32(topDictLength)))
 	if err != nil {
@@ -137,7 +146,20 @@ func (sfnt *SFNT) parseCFF2() error {
 		return fmt.Errorf("CFF2: Global Subrs INDEX: %w", err)
 	}
 
-	if len(b) < topDICT.FDArray {
+	if topDICT.CharStrings <= 0 || len(b) <= topDICT.CharStrings {
+		return fmt.Errorf("CFF2: bad CharStrings INDEX offset")
+	}
+	r.Seek(uint32(topDICT.CharStrings))
+	charStringsINDEX, err := parseINDEX(r, true)
+	if err != nil {
+		return fmt.Errorf("CFF2: CharStrings INDEX: %w", err)
+	}
+	numGlyphs := len(charStringsINDEX.offset) - 1
+	if numGlyphs < 0 {
+		numGlyphs = 0
+	}
+
+	if topDICT.FDArray <= 0 || len(b) <= topDICT.FDArray {
 		return fmt.Errorf("CFF2: bad Font INDEX offset")
 	}
 	r.Seek(uint32(topDICT.FDArray))
@@ -145,52 +167,129 @@ func (sfnt *SFNT) parseCFF2() error {
 	if err != nil {
 		return fmt.Errorf("CFF2: Font INDEX: %w", err)
 	}
-	if len(fontINDEX.offset) != 1 {
-		return fmt.Errorf("CFF2: multiple Font DICTs unsupported")
+	numFonts := len(fontINDEX.offset) - 1
+	if numFonts < 1 || math.MaxUint16 < numFonts {
+		return fmt.Errorf("CFF2: Font INDEX: bad count")
 	}
 
-	var PrivateOffset, PrivateLength int
-	return parseDICT(b, true, func(b0 int, is []int, fs []float64) bool {
-		if b0 == 18 {
-			PrivateOffset = is[0]
-			PrivateLength = is[1]
+	fdLocalSubrs := make([]*cffINDEX, numFonts)
+	fdVsindex := make([]int, numFonts)
+	for i := 0; i < numFonts; i++ {
+		var privateOffset, privateLength int
+		err := parseDICT(fontINDEX.Get(uint16(i)), true, func(b0 int, is []int, fs []float64) bool {
+			if b0 == 18 {
+				privateOffset = is[1]
+				privateLength = is[0]
+			}
 			return true
+		})
+		if err != nil {
+			return fmt.Errorf("CFF2: Font DICT: %w", err)
+		} else if privateOffset < 0 || privateLength < 0 || len(b) < privateOffset || len(b)-privateOffset < privateLength {
+			return fmt.Errorf("CFF2: bad Private DICT offset")
 		}
-		return false
-	})
+		privateDICT, err := parsePrivateDICT(b[privateOffset:privateOffset+privateLength], true)
+		if err != nil {
+			return fmt.Errorf("CFF2: Private DICT: %w", err)
+		}
+		fdVsindex[i] = privateDICT.Vsindex
 
-	if len(b) < PrivateOffset || len(b)-PrivateOffset < PrivateLength {
-		return fmt.Errorf("CFF2: bad Private DICT offset")
-	}
-	privateDICT, err := parsePrivateDICT(b[PrivateOffset:PrivateOffset+PrivateLength], true)
-	if err != nil {
-		return fmt.Errorf("CFF2: Private DICT: %w", err)
+		fdLocalSubrs[i] = &cffINDEX{}
+		if privateDICT.Subrs != 0 {
+			if privateDICT.Subrs < 0 || len(b)-privateOffset < privateDICT.Subrs {
+				return fmt.Errorf("CFF2: bad Local Subrs INDEX offset")
+			}
+			r.Seek(uint32(privateOffset + privateDICT.Subrs))
+			if fdLocalSubrs[i], err = parseINDEX(r, true); err != nil {
+				return fmt.Errorf("CFF2: Local Subrs INDEX: %w", err)
+			}
+		}
 	}
 
-	if len(b)-PrivateOffset < privateDICT.Subrs {
-		return fmt.Errorf("CFF2: bad Local Subrs INDEX offset")
-	}
-	r.Seek(uint32(PrivateOffset + privateDICT.Subrs))
-	localSubrsINDEX, err := parseINDEX(r, true)
-	if err != nil {
-		return fmt.Errorf("CFF2: Local Subrs INDEX: %w", err)
+	var fdSelect []uint16
+	if 1 < numFonts {
+		if topDICT.FDSelect <= 0 || len(b) <= topDICT.FDSelect {
+			return fmt.Errorf("CFF2: bad FDSelect offset")
+		}
+		if fdSelect, err = parseFDSelect(b[topDICT.FDSelect:], numGlyphs, numFonts); err != nil {
+			return fmt.Errorf("CFF2: FDSelect: %w", err)
+		}
 	}
 
-	r.Seek(uint32(topDICT.CharStrings))
-	charStringsINDEX, err := parseINDEX(r, true)
-	if err != nil {
-		return fmt.Errorf("CFF2: CharStrings INDEX: %w", err)
+	var vstore *itemVariationStore
+	if topDICT.Vstore != 0 {
+		if topDICT.Vstore < 0 || len(b)-2 < topDICT.Vstore {
+			return fmt.Errorf("CFF2: bad VariationStore offset")
+		}
+		if vstore, err = parseItemVariationStore(b[topDICT.Vstore+2:]); err != nil {
+			return fmt.Errorf("CFF2: VariationStore: %w", err)
+		}
 	}
 
 	sfnt.CFF = &cffTable{
-		version:     2,
-		globalSubrs: globalSubrsINDEX,
-		localSubrs:  localSubrsINDEX,
-		charStrings: charStringsINDEX,
+		version:      2,
+		globalSubrs:  globalSubrsINDEX,
+		localSubrs:   fdLocalSubrs[0],
+		charStrings:  charStringsINDEX,
+		maxNesting:   sfnt.opts.MaxCFFNesting,
+		fdSelect:     fdSelect,
+		fdLocalSubrs: fdLocalSubrs,
+		fdVsindex:    fdVsindex,
+		vstore:       vstore,
 	}
 	return nil
 }
 
+// parseFDSelect returns the Font DICT index for each glyph.
+func parseFDSelect(b []byte, numGlyphs, numFonts int) ([]uint16, error) {
+	fdSelect := make([]uint16, numGlyphs)
+	r := NewBinaryReader(b)
+	format := r.ReadUint8()
+	if format == 0 {
+		for i := range fdSelect {
+			fdSelect[i] = uint16(r.ReadUint8())
+		}
+	} else if format == 3 || format == 4 {
+		var numRanges, first uint32
+		if format == 3 {
+			numRanges = uint32(r.ReadUint16())
+			first = uint32(r.ReadUint16())
+		} else {
+			numRanges = r.ReadUint32()
+			first = r.ReadUint32()
+		}
+		for i := uint32(0); i < numRanges && !r.EOF(); i++ {
+			var fd uint16
+			var next uint32
+			if format == 3 {
+				fd = uint16(r.ReadUint8())
+				next = uint32(r.ReadUint16())
+			} else {
+				fd = r.ReadUint16()
+				next = r.ReadUint32()
+			}
+			if next < first || uint32(numGlyphs) < next {
+				return nil, fmt.Errorf("bad range")
+			}
+			for glyphID := first; glyphID < next; glyphID++ {
+				fdSelect[glyphID] = fd
+			}
+			first = next
+		}
+	} else {
+		return nil, fmt.Errorf("bad format")
+	}
+	if r.EOF() {
+		return nil, fmt.Errorf("bad data")
+	}
+	for _, fd := range fdSelect {
+		if numFonts <= int(fd) {
+			return nil, fmt.Errorf("bad Font DICT index")
+		}
+	}
+	return fdSelect, nil
+}
+
 func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float64, hinting Hinting) error {
 	table := "CFF"
 	if cff.version == 2 {
@@ -205,6 +304,19 @@ func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float6
 		return fmt.Errorf("%v: charstring too long", table)
 	}
 
+	localSubrs := cff.localSubrs
+	vsindex := 0
+	if cff.version == 2 {
+		fd := 0
+		if cff.fdSelect != nil && int(glyphID) < len(cff.fdSelect) {
+			fd = int(cff.fdSelect[glyphID])
+		}
+		if fd < len(cff.fdLocalSubrs) {
+			localSubrs = cff.fdLocalSubrs[fd]
+			vsindex = cff.fdVsindex[fd]
+		}
+	}
+
 	// raise to most-significant 16 bits and treat less-significant bits as fraction
 	x <<= 16
 	y <<= 16
@@ -590,7 +702,7 @@ func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float6
 			// TODO: arithmetic, storage, and conditional operators for CFF version 1?
 			case 10, 29:
 				// callsubr and callgsubr
-				if 10 < len(callStack) {
+				if cff.maxNesting < len(callStack) {
 					return fmt.Errorf("%v: too many nested subroutines", table)
 				} else if len(stack) == 0 {
 					return errBadNumOperands
@@ -598,7 +710,7 @@ func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float6
 
 				n := 0
 				if b0 == 10 {
-					n = len(cff.localSubrs.offset) - 1
+					n = len(localSubrs.offset) - 1
 				} else {
 					n = len(cff.globalSubrs.offset) - 1
 				}
@@ -617,7 +729,7 @@ func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float6
 
 				var subr []byte
 				if b0 == 10 {
-					subr = cff.localSubrs.Get(uint16(i))
+					subr = localSubrs.Get(uint16(i))
 				} else {
 					subr = cff.globalSubrs.Get(uint16(i))
 				}
@@ -642,14 +754,42 @@ func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float6
 				// blend
 				if cff.version == 1 {
 					return fmt.Errorf("CFF: unsupported operator %d", b0)
+				} else if len(stack) == 0 {
+					return errBadNumOperands
+				}
+				n := int(stack[len(stack)-1] >> 16)
+				stack = stack[:len(stack)-1]
+				var scalars []float64
+				if cff.vstore != nil {
+					var err error
+					if scalars, err = cff.vstore.RegionScalars(uint16(vsindex), cff.coords); err != nil {
+						return fmt.Errorf("CFF2: %w", err)
+					}
 				}
-				// TODO: blend
+				k := len(scalars)
+				if n < 0 || len(stack) < n*(k+1) {
+					return errBadNumOperands
+				}
+				base := len(stack) - n*(k+1)
+				for i := 0; i < n; i++ {
+					v := float64(stack[base+i])
+					for j, scalar := range scalars {
+						if scalar != 0.0 {
+							v += scalar * float64(stack[base+n+i*k+j])
+						}
+					}
+					stack[base+i] = int32(math.Round(v))
+				}
+				stack = stack[:base+n]
 			case 15:
 				// vsindex
 				if cff.version == 1 {
 					return fmt.Errorf("CFF: unsupported operator %d", b0)
+				} else if len(stack) != 1 {
+					return errBadNumOperands
 				}
-				// TODO: vsindex
+				vsindex = int(stack[0] >> 16)
+				stack = stack[:0]
 			default:
 				if 256 <= b0 {
 					return fmt.Errorf("%v: unsupported operator 12 %d", table, b0-256)
@@ -661,6 +801,7 @@ func (cff *cffTable) ToPath(p Pather, glyphID, ppem uint16, x, y int32, f float6
 	if cff.version == 1 {
 		return fmt.Errorf("CFF: charstring must end with endchar operator")
 	}
+	p.Close()
 	return nil
 }
 
@@ -804,7 +945,6 @@ type cffPrivateDICT struct {
 
 	// CFF2
 	Vsindex int
-	Blend   []float64
 }
 
 type cff2TopDICT struct {
@@ -953,8 +1093,6 @@ func parsePrivateDICT(b []byte, isCFF2 bool) (*cffPrivateDICT, error) {
 			dict.NominalWidthX = fs[0]
 		case 22:
 			dict.Vsindex = is[0]
-		case 23:
-			dict.Blend = fs
 		default:
 			return false
 		}
@@ -1006,7 +1144,10 @@ func parseDICT(b []byte, isCFF2 bool, callback func(b0 int, is []int, fs []float
 	reals := []float64{}
 	for 0 < r.Len() {
 		b0 := int(r.ReadUint8())
-		if b0 < 22 {
+		if isCFF2 && b0 == 23 {
+			// blend, keep the default values and deltas on the stack as hint values are not used
+			continue
+		} else if b0 < 22 || isCFF2 && (b0 == 22 || b0 == 24) {
 			// operator
 			if b0 == 12 {
 				b0 = 256 + int(r.ReadUint8())
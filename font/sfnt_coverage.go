@@ -0,0 +1,200 @@
+package font
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Coverage is the character, script, and language coverage that a font declares.
+type Coverage struct {
+	Runes          []rune                      // sorted runes that map to a glyph other than .notdef
+	Scripts        map[ScriptTag][]LanguageTag // scripts and their languages of the GSUB and GPOS tables, excluding the default language
+	UnicodeRanges  [4]uint32                   // ulUnicodeRange1-4 bits of the OS/2 table
+	CodePageRanges [2]uint32                   // ulCodePageRange1-2 bits of the OS/2 table
+}
+
+// HasRune returns true if the rune maps to a glyph other than .notdef.
+func (c Coverage) HasRune(r rune) bool {
+	i := sort.Search(len(c.Runes), func(i int) bool { return r <= c.Runes[i] })
+	return i < len(c.Runes) && c.Runes[i] == r
+}
+
+// HasScript returns true if the GSUB or GPOS tables declare the script.
+func (c Coverage) HasScript(script ScriptTag) bool {
+	_, ok := c.Scripts[script]
+	return ok
+}
+
+// HasUnicodeRange returns true if the Unicode range bit (0-127) of the OS/2 table is set, see https://docs.microsoft.com/en-us/typography/opentype/spec/os2#ur
+func (c Coverage) HasUnicodeRange(bit int) bool {
+	if bit < 0 || 128 <= bit {
+		return false
+	}
+	return c.UnicodeRanges[bit/32]&(1<<uint(bit%32)) != 0
+}
+
+// HasCodePage returns true if the code page bit (0-63) of the OS/2 table is set, see https://docs.microsoft.com/en-us/typography/opentype/spec/os2#cpr
+func (c Coverage) HasCodePage(bit int) bool {
+	if bit < 0 || 64 <= bit {
+		return false
+	}
+	return c.CodePageRanges[bit/32]&(1<<uint(bit%32)) != 0
+}
+
+// Runes returns the sorted runes of all subtables that map to a glyph other than .notdef.
+func (cmap *cmapTable) Runes() []rune {
+	candidates := map[rune]bool{}
+	add := func(start, end rune) {
+		for r := start; r <= end; r++ {
+			candidates[r] = true
+		}
+	}
+	for _, subtable := range cmap.Subtables {
+		switch subtable := subtable.(type) {
+		case *cmapFormat0:
+			add(0, 255)
+		case *cmapFormat4:
+			for i := range subtable.StartCode {
+				add(rune(subtable.StartCode[i]), rune(subtable.EndCode[i]))
+			}
+		case *cmapFormat6:
+			if 0 < len(subtable.GlyphIdArray) {
+				add(rune(subtable.FirstCode), rune(subtable.FirstCode)+rune(len(subtable.GlyphIdArray))-1)
+			}
+		case *cmapFormat12:
+			for i := range subtable.StartCharCode {
+				if subtable.StartCharCode[i] <= subtable.EndCharCode[i] && subtable.EndCharCode[i] <= 0x10FFFF {
+					add(rune(subtable.StartCharCode[i]), rune(subtable.EndCharCode[i]))
+				}
+			}
+		}
+	}
+
+	runes := make([]rune, 0, len(candidates))
+	for r := range candidates {
+		if cmap.Get(r) != 0 {
+			runes = append(runes, r)
+		}
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+// Coverage returns the runes mapped by the cmap table, the scripts and languages declared by the GSUB and GPOS tables, and the Unicode and code page range bits of the OS/2 table.
+func (sfnt *SFNT) Coverage() Coverage {
+	coverage := Coverage{
+		Scripts: map[ScriptTag][]LanguageTag{},
+	}
+	if sfnt.Cmap != nil {
+		coverage.Runes = sfnt.Cmap.Runes()
+	}
+
+	for _, tag := range []string{"GSUB", "GPOS"} {
+		scripts, err := sfnt.layoutScriptList(tag)
+		if err != nil {
+			continue
+		}
+		for script, langSyss := range scripts {
+			languages := coverage.Scripts[script]
+			for language := range langSyss {
+				if language == DefaultLanguage {
+					continue
+				}
+				found := false
+				for _, prev := range languages {
+					if prev == language {
+						found = true
+						break
+					}
+				}
+				if !found {
+					languages = append(languages, language)
+				}
+			}
+			sort.Slice(languages, func(i, j int) bool { return languages[i] < languages[j] })
+			coverage.Scripts[script] = languages
+		}
+	}
+
+	if sfnt.OS2 != nil {
+		coverage.UnicodeRanges = [4]uint32{sfnt.OS2.UlUnicodeRange1, sfnt.OS2.UlUnicodeRange2, sfnt.OS2.UlUnicodeRange3, sfnt.OS2.UlUnicodeRange4}
+		coverage.CodePageRanges = [2]uint32{sfnt.OS2.UlCodePageRange1, sfnt.OS2.UlCodePageRange2}
+	}
+	return coverage
+}
+
+// layoutScriptList returns the script list of a GSUB or GPOS table.
+func (sfnt *SFNT) layoutScriptList(tag string) (scriptList, error) {
+	if tag == "GPOS" {
+		sfnt.mu.Lock()
+		gpos := sfnt.Gpos
+		sfnt.mu.Unlock()
+		if gpos != nil {
+			return gpos.scriptList, nil
+		}
+	}
+	b, err := sfnt.Table(tag)
+	if err != nil {
+		return nil, err
+	} else if len(b) < 10 {
+		return nil, ErrInvalidFontData
+	}
+
+	r := NewBinaryReader(b)
+	_ = r.ReadUint16() // majorVersion
+	_ = r.ReadUint16() // minorVersion
+	scriptListOffset := r.ReadUint16()
+	if len(b)-2 < int(scriptListOffset) {
+		return nil, ErrInvalidFontData
+	}
+	return sfnt.parseScriptList(b[scriptListOffset:])
+}
+
+// LayoutFeatures returns the sorted and unique feature tags of the GSUB or GPOS table. It returns nil if the font has no such table.
+func (sfnt *SFNT) LayoutFeatures(tag string) ([]FeatureTag, error) {
+	if tag != "GSUB" && tag != "GPOS" {
+		return nil, fmt.Errorf("%s: not a layout table", tag)
+	} else if !sfnt.HasTable(tag) {
+		return nil, nil
+	}
+	b, err := sfnt.Table(tag)
+	if err != nil {
+		return nil, err
+	} else if len(b) < 10 {
+		return nil, ErrInvalidFontData
+	}
+
+	r := NewBinaryReader(b)
+	_ = r.ReadUint16() // majorVersion
+	_ = r.ReadUint16() // minorVersion
+	_ = r.ReadUint16() // scriptListOffset
+	featureListOffset := r.ReadUint16()
+	if len(b)-2 < int(featureListOffset) {
+		return nil, ErrInvalidFontData
+	}
+	featureList := sfnt.parseFeatureList(b[featureListOffset:])
+
+	tags := []FeatureTag{}
+	seen := map[FeatureTag]bool{}
+	for _, featureTag := range featureList.tag {
+		if !seen[featureTag] {
+			tags = append(tags, featureTag)
+			seen[featureTag] = true
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	return tags, nil
+}
+
+// MissingRunes returns the runes of the string that map to the .notdef glyph, that is, which the font cannot render. Each rune is returned once in order of appearance. Note that control characters such as newlines usually do not map to a glyph either.
+func (sfnt *SFNT) MissingRunes(s string) []rune {
+	missing := []rune{}
+	seen := map[rune]bool{}
+	for _, r := range s {
+		if !seen[r] && sfnt.GlyphIndex(r) == 0 {
+			missing = append(missing, r)
+		}
+		seen[r] = true
+	}
+	return missing
+}
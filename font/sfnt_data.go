@@ -773,3 +773,940 @@ var cffStandardStrings = []string{
 	"Roman",
 	"Semibold",
 }
+
+// windowsLanguageTags maps Windows language IDs of the name table to BCP-47 language tags.
+var windowsLanguageTags = map[uint16]string{
+	0x0436: "af-ZA",
+	0x041C: "sq-AL",
+	0x0484: "gsw-FR",
+	0x045E: "am-ET",
+	0x1401: "ar-DZ",
+	0x3C01: "ar-BH",
+	0x0C01: "ar-EG",
+	0x0801: "ar-IQ",
+	0x2C01: "ar-JO",
+	0x3401: "ar-KW",
+	0x3001: "ar-LB",
+	0x1001: "ar-LY",
+	0x1801: "ar-MA",
+	0x2001: "ar-OM",
+	0x4001: "ar-QA",
+	0x0401: "ar-SA",
+	0x2801: "ar-SY",
+	0x1C01: "ar-TN",
+	0x3801: "ar-AE",
+	0x2401: "ar-YE",
+	0x042B: "hy-AM",
+	0x044D: "as-IN",
+	0x082C: "az-Cyrl-AZ",
+	0x042C: "az-Latn-AZ",
+	0x046D: "ba-RU",
+	0x042D: "eu-ES",
+	0x0423: "be-BY",
+	0x0845: "bn-BD",
+	0x0445: "bn-IN",
+	0x201A: "bs-Cyrl-BA",
+	0x141A: "bs-Latn-BA",
+	0x047E: "br-FR",
+	0x0402: "bg-BG",
+	0x0403: "ca-ES",
+	0x0C04: "zh-HK",
+	0x1404: "zh-MO",
+	0x0804: "zh-CN",
+	0x1004: "zh-SG",
+	0x0404: "zh-TW",
+	0x0483: "co-FR",
+	0x041A: "hr-HR",
+	0x101A: "hr-BA",
+	0x0405: "cs-CZ",
+	0x0406: "da-DK",
+	0x048C: "prs-AF",
+	0x0465: "dv-MV",
+	0x0813: "nl-BE",
+	0x0413: "nl-NL",
+	0x0C09: "en-AU",
+	0x2809: "en-BZ",
+	0x1009: "en-CA",
+	0x2409: "en-029",
+	0x4009: "en-IN",
+	0x1809: "en-IE",
+	0x2009: "en-JM",
+	0x4409: "en-MY",
+	0x1409: "en-NZ",
+	0x3409: "en-PH",
+	0x4809: "en-SG",
+	0x1C09: "en-ZA",
+	0x2C09: "en-TT",
+	0x0809: "en-GB",
+	0x0409: "en-US",
+	0x3009: "en-ZW",
+	0x0425: "et-EE",
+	0x0438: "fo-FO",
+	0x0464: "fil-PH",
+	0x040B: "fi-FI",
+	0x080C: "fr-BE",
+	0x0C0C: "fr-CA",
+	0x040C: "fr-FR",
+	0x140C: "fr-LU",
+	0x180C: "fr-MC",
+	0x100C: "fr-CH",
+	0x0462: "fy-NL",
+	0x0456: "gl-ES",
+	0x0437: "ka-GE",
+	0x0C07: "de-AT",
+	0x0407: "de-DE",
+	0x1407: "de-LI",
+	0x1007: "de-LU",
+	0x0807: "de-CH",
+	0x0408: "el-GR",
+	0x046F: "kl-GL",
+	0x0447: "gu-IN",
+	0x0468: "ha-Latn-NG",
+	0x040D: "he-IL",
+	0x0439: "hi-IN",
+	0x040E: "hu-HU",
+	0x040F: "is-IS",
+	0x0470: "ig-NG",
+	0x0421: "id-ID",
+	0x045D: "iu-Cans-CA",
+	0x085D: "iu-Latn-CA",
+	0x083C: "ga-IE",
+	0x0434: "xh-ZA",
+	0x0435: "zu-ZA",
+	0x0410: "it-IT",
+	0x0810: "it-CH",
+	0x0411: "ja-JP",
+	0x044B: "kn-IN",
+	0x043F: "kk-KZ",
+	0x0453: "km-KH",
+	0x0486: "quc-Latn-GT",
+	0x0487: "rw-RW",
+	0x0441: "sw-KE",
+	0x0457: "kok-IN",
+	0x0412: "ko-KR",
+	0x0440: "ky-KG",
+	0x0454: "lo-LA",
+	0x0426: "lv-LV",
+	0x0427: "lt-LT",
+	0x082E: "dsb-DE",
+	0x046E: "lb-LU",
+	0x042F: "mk-MK",
+	0x083E: "ms-BN",
+	0x043E: "ms-MY",
+	0x044C: "ml-IN",
+	0x043A: "mt-MT",
+	0x0481: "mi-NZ",
+	0x047A: "arn-CL",
+	0x044E: "mr-IN",
+	0x047C: "moh-CA",
+	0x0450: "mn-MN",
+	0x0850: "mn-Mong-CN",
+	0x0461: "ne-NP",
+	0x0414: "nb-NO",
+	0x0814: "nn-NO",
+	0x0482: "oc-FR",
+	0x0448: "or-IN",
+	0x0463: "ps-AF",
+	0x0415: "pl-PL",
+	0x0416: "pt-BR",
+	0x0816: "pt-PT",
+	0x0446: "pa-IN",
+	0x046B: "quz-BO",
+	0x086B: "quz-EC",
+	0x0C6B: "quz-PE",
+	0x0418: "ro-RO",
+	0x0417: "rm-CH",
+	0x0419: "ru-RU",
+	0x243B: "smn-FI",
+	0x103B: "smj-NO",
+	0x143B: "smj-SE",
+	0x0C3B: "se-FI",
+	0x043B: "se-NO",
+	0x083B: "se-SE",
+	0x203B: "sms-FI",
+	0x183B: "sma-NO",
+	0x1C3B: "sma-SE",
+	0x044F: "sa-IN",
+	0x1C1A: "sr-Cyrl-BA",
+	0x0C1A: "sr-Cyrl-RS",
+	0x181A: "sr-Latn-BA",
+	0x081A: "sr-Latn-RS",
+	0x046C: "nso-ZA",
+	0x0432: "tn-ZA",
+	0x045B: "si-LK",
+	0x041B: "sk-SK",
+	0x0424: "sl-SI",
+	0x2C0A: "es-AR",
+	0x400A: "es-BO",
+	0x340A: "es-CL",
+	0x240A: "es-CO",
+	0x140A: "es-CR",
+	0x1C0A: "es-DO",
+	0x300A: "es-EC",
+	0x440A: "es-SV",
+	0x100A: "es-GT",
+	0x480A: "es-HN",
+	0x080A: "es-MX",
+	0x4C0A: "es-NI",
+	0x180A: "es-PA",
+	0x3C0A: "es-PY",
+	0x280A: "es-PE",
+	0x500A: "es-PR",
+	0x0C0A: "es-ES",
+	0x040A: "es-ES",
+	0x540A: "es-US",
+	0x380A: "es-UY",
+	0x200A: "es-VE",
+	0x081D: "sv-FI",
+	0x041D: "sv-SE",
+	0x045A: "syr-SY",
+	0x0428: "tg-Cyrl-TJ",
+	0x085F: "tzm-Latn-DZ",
+	0x0449: "ta-IN",
+	0x0444: "tt-RU",
+	0x044A: "te-IN",
+	0x041E: "th-TH",
+	0x0451: "bo-CN",
+	0x041F: "tr-TR",
+	0x0442: "tk-TM",
+	0x0480: "ug-CN",
+	0x0422: "uk-UA",
+	0x042E: "hsb-DE",
+	0x0420: "ur-PK",
+	0x0843: "uz-Cyrl-UZ",
+	0x0443: "uz-Latn-UZ",
+	0x042A: "vi-VN",
+	0x0452: "cy-GB",
+	0x0488: "wo-SN",
+	0x0485: "sah-RU",
+	0x0478: "ii-CN",
+	0x046A: "yo-NG",
+}
+
+// macintoshLanguageTags maps Macintosh language IDs of the name table to BCP-47 language tags.
+var macintoshLanguageTags = map[uint16]string{
+	0:   "en",
+	1:   "fr",
+	2:   "de",
+	3:   "it",
+	4:   "nl",
+	5:   "sv",
+	6:   "es",
+	7:   "da",
+	8:   "pt",
+	9:   "nb",
+	10:  "he",
+	11:  "ja",
+	12:  "ar",
+	13:  "fi",
+	14:  "el",
+	15:  "is",
+	16:  "mt",
+	17:  "tr",
+	18:  "hr",
+	19:  "zh-Hant",
+	20:  "ur",
+	21:  "hi",
+	22:  "th",
+	23:  "ko",
+	24:  "lt",
+	25:  "pl",
+	26:  "hu",
+	27:  "et",
+	28:  "lv",
+	29:  "se",
+	30:  "fo",
+	31:  "fa",
+	32:  "ru",
+	33:  "zh-Hans",
+	34:  "nl-BE",
+	35:  "ga",
+	36:  "sq",
+	37:  "ro",
+	38:  "cs",
+	39:  "sk",
+	40:  "sl",
+	41:  "yi",
+	42:  "sr",
+	43:  "mk",
+	44:  "bg",
+	45:  "uk",
+	46:  "be",
+	47:  "uz",
+	48:  "kk",
+	49:  "az-Cyrl",
+	50:  "az-Arab",
+	51:  "hy",
+	52:  "ka",
+	53:  "ro-MD",
+	54:  "ky",
+	55:  "tg",
+	56:  "tk",
+	57:  "mn-Mong",
+	58:  "mn-Cyrl",
+	59:  "ps",
+	60:  "ku",
+	61:  "ks",
+	62:  "sd",
+	63:  "bo",
+	64:  "ne",
+	65:  "sa",
+	66:  "mr",
+	67:  "bn",
+	68:  "as",
+	69:  "gu",
+	70:  "pa",
+	71:  "or",
+	72:  "ml",
+	73:  "kn",
+	74:  "ta",
+	75:  "te",
+	76:  "si",
+	77:  "my",
+	78:  "km",
+	79:  "lo",
+	80:  "vi",
+	81:  "id",
+	82:  "tl",
+	83:  "ms",
+	84:  "ms-Arab",
+	85:  "am",
+	86:  "ti",
+	87:  "om",
+	88:  "so",
+	89:  "sw",
+	90:  "rw",
+	91:  "rn",
+	92:  "ny",
+	93:  "mg",
+	94:  "eo",
+	128: "cy",
+	129: "eu",
+	130: "ca",
+	131: "la",
+	132: "qu",
+	133: "gn",
+	134: "ay",
+	135: "tt",
+	136: "ug",
+	137: "dz",
+	138: "jv",
+	139: "su",
+	140: "gl",
+	141: "af",
+	142: "br",
+	143: "iu",
+	144: "gd",
+	145: "gv",
+	146: "ga",
+	147: "to",
+	148: "el-polyton",
+	149: "kl",
+	150: "az-Latn",
+}
+
+// standardEncoding is the Adobe StandardEncoding of Type 1 fonts, mapping character codes to glyph names.
+var standardEncoding = [256]string{
+	32:  "space",
+	33:  "exclam",
+	34:  "quotedbl",
+	35:  "numbersign",
+	36:  "dollar",
+	37:  "percent",
+	38:  "ampersand",
+	39:  "quoteright",
+	40:  "parenleft",
+	41:  "parenright",
+	42:  "asterisk",
+	43:  "plus",
+	44:  "comma",
+	45:  "hyphen",
+	46:  "period",
+	47:  "slash",
+	48:  "zero",
+	49:  "one",
+	50:  "two",
+	51:  "three",
+	52:  "four",
+	53:  "five",
+	54:  "six",
+	55:  "seven",
+	56:  "eight",
+	57:  "nine",
+	58:  "colon",
+	59:  "semicolon",
+	60:  "less",
+	61:  "equal",
+	62:  "greater",
+	63:  "question",
+	64:  "at",
+	65:  "A",
+	66:  "B",
+	67:  "C",
+	68:  "D",
+	69:  "E",
+	70:  "F",
+	71:  "G",
+	72:  "H",
+	73:  "I",
+	74:  "J",
+	75:  "K",
+	76:  "L",
+	77:  "M",
+	78:  "N",
+	79:  "O",
+	80:  "P",
+	81:  "Q",
+	82:  "R",
+	83:  "S",
+	84:  "T",
+	85:  "U",
+	86:  "V",
+	87:  "W",
+	88:  "X",
+	89:  "Y",
+	90:  "Z",
+	91:  "bracketleft",
+	92:  "backslash",
+	93:  "bracketright",
+	94:  "asciicircum",
+	95:  "underscore",
+	96:  "quoteleft",
+	97:  "a",
+	98:  "b",
+	99:  "c",
+	100: "d",
+	101: "e",
+	102: "f",
+	103: "g",
+	104: "h",
+	105: "i",
+	106: "j",
+	107: "k",
+	108: "l",
+	109: "m",
+	110: "n",
+	111: "o",
+	112: "p",
+	113: "q",
+	114: "r",
+	115: "s",
+	116: "t",
+	117: "u",
+	118: "v",
+	119: "w",
+	120: "x",
+	121: "y",
+	122: "z",
+	123: "braceleft",
+	124: "bar",
+	125: "braceright",
+	126: "asciitilde",
+	161: "exclamdown",
+	162: "cent",
+	163: "sterling",
+	164: "fraction",
+	165: "yen",
+	166: "florin",
+	167: "section",
+	168: "currency",
+	169: "quotesingle",
+	170: "quotedblleft",
+	171: "guillemotleft",
+	172: "guilsinglleft",
+	173: "guilsinglright",
+	174: "fi",
+	175: "fl",
+	177: "endash",
+	178: "dagger",
+	179: "daggerdbl",
+	180: "periodcentered",
+	182: "paragraph",
+	183: "bullet",
+	184: "quotesinglbase",
+	185: "quotedblbase",
+	186: "quotedblright",
+	187: "guillemotright",
+	188: "ellipsis",
+	189: "perthousand",
+	191: "questiondown",
+	193: "grave",
+	194: "acute",
+	195: "circumflex",
+	196: "tilde",
+	197: "macron",
+	198: "breve",
+	199: "dotaccent",
+	200: "dieresis",
+	202: "ring",
+	203: "cedilla",
+	205: "hungarumlaut",
+	206: "ogonek",
+	207: "caron",
+	208: "emdash",
+	225: "AE",
+	227: "ordfeminine",
+	232: "Lslash",
+	233: "Oslash",
+	234: "OE",
+	235: "ordmasculine",
+	241: "ae",
+	245: "dotlessi",
+	248: "lslash",
+	249: "oslash",
+	250: "oe",
+	251: "germandbls",
+}
+
+// glyphNameUnicode maps common glyph names to Unicode code points, see the Adobe Glyph List.
+var glyphNameUnicode = map[string]rune{
+	".null":            0x0000,
+	"A":                0x0041,
+	"AE":               0x00C6,
+	"Aacute":           0x00C1,
+	"Abreve":           0x0102,
+	"Acircumflex":      0x00C2,
+	"Adieresis":        0x00C4,
+	"Agrave":           0x00C0,
+	"Alpha":            0x0391,
+	"Amacron":          0x0100,
+	"Aogonek":          0x0104,
+	"Aring":            0x00C5,
+	"Atilde":           0x00C3,
+	"B":                0x0042,
+	"Beta":             0x0392,
+	"C":                0x0043,
+	"Cacute":           0x0106,
+	"Ccaron":           0x010C,
+	"Ccedilla":         0x00C7,
+	"Chi":              0x03A7,
+	"D":                0x0044,
+	"Dcaron":           0x010E,
+	"Dcroat":           0x0110,
+	"Delta":            0x0394,
+	"E":                0x0045,
+	"Eacute":           0x00C9,
+	"Ecaron":           0x011A,
+	"Ecircumflex":      0x00CA,
+	"Edieresis":        0x00CB,
+	"Edotaccent":       0x0116,
+	"Egrave":           0x00C8,
+	"Emacron":          0x0112,
+	"Eng":              0x014A,
+	"Eogonek":          0x0118,
+	"Epsilon":          0x0395,
+	"Eta":              0x0397,
+	"Eth":              0x00D0,
+	"Euro":             0x20AC,
+	"F":                0x0046,
+	"G":                0x0047,
+	"Gamma":            0x0393,
+	"Gbreve":           0x011E,
+	"Gcommaaccent":     0x0122,
+	"H":                0x0048,
+	"Hbar":             0x0126,
+	"I":                0x0049,
+	"IJ":               0x0132,
+	"Iacute":           0x00CD,
+	"Icircumflex":      0x00CE,
+	"Idieresis":        0x00CF,
+	"Idotaccent":       0x0130,
+	"Ifraktur":         0x2111,
+	"Igrave":           0x00CC,
+	"Imacron":          0x012A,
+	"Iogonek":          0x012E,
+	"Iota":             0x0399,
+	"J":                0x004A,
+	"K":                0x004B,
+	"Kappa":            0x039A,
+	"Kcommaaccent":     0x0136,
+	"L":                0x004C,
+	"Lacute":           0x0139,
+	"Lambda":           0x039B,
+	"Lcaron":           0x013D,
+	"Lcommaaccent":     0x013B,
+	"Ldot":             0x013F,
+	"Lslash":           0x0141,
+	"M":                0x004D,
+	"Mu":               0x039C,
+	"N":                0x004E,
+	"Nacute":           0x0143,
+	"Ncaron":           0x0147,
+	"Ncommaaccent":     0x0145,
+	"Ntilde":           0x00D1,
+	"Nu":               0x039D,
+	"O":                0x004F,
+	"OE":               0x0152,
+	"Oacute":           0x00D3,
+	"Ocircumflex":      0x00D4,
+	"Odieresis":        0x00D6,
+	"Ograve":           0x00D2,
+	"Ohungarumlaut":    0x0150,
+	"Omacron":          0x014C,
+	"Omega":            0x03A9,
+	"Omicron":          0x039F,
+	"Oslash":           0x00D8,
+	"Otilde":           0x00D5,
+	"P":                0x0050,
+	"Phi":              0x03A6,
+	"Pi":               0x03A0,
+	"Psi":              0x03A8,
+	"Q":                0x0051,
+	"R":                0x0052,
+	"Racute":           0x0154,
+	"Rcaron":           0x0158,
+	"Rcommaaccent":     0x0156,
+	"Rfraktur":         0x211C,
+	"Rho":              0x03A1,
+	"S":                0x0053,
+	"Sacute":           0x015A,
+	"Scaron":           0x0160,
+	"Scedilla":         0x015E,
+	"Scommaaccent":     0x0218,
+	"Sigma":            0x03A3,
+	"T":                0x0054,
+	"Tau":              0x03A4,
+	"Tbar":             0x0166,
+	"Tcaron":           0x0164,
+	"Tcommaaccent":     0x0162,
+	"Theta":            0x0398,
+	"Thorn":            0x00DE,
+	"U":                0x0055,
+	"Uacute":           0x00DA,
+	"Ucircumflex":      0x00DB,
+	"Udieresis":        0x00DC,
+	"Ugrave":           0x00D9,
+	"Uhungarumlaut":    0x0170,
+	"Umacron":          0x016A,
+	"Uogonek":          0x0172,
+	"Upsilon":          0x03A5,
+	"Upsilon1":         0x03D2,
+	"Uring":            0x016E,
+	"V":                0x0056,
+	"W":                0x0057,
+	"X":                0x0058,
+	"Xi":               0x039E,
+	"Y":                0x0059,
+	"Yacute":           0x00DD,
+	"Ydieresis":        0x0178,
+	"Z":                0x005A,
+	"Zacute":           0x0179,
+	"Zcaron":           0x017D,
+	"Zdotaccent":       0x017B,
+	"Zeta":             0x0396,
+	"a":                0x0061,
+	"aacute":           0x00E1,
+	"abreve":           0x0103,
+	"acircumflex":      0x00E2,
+	"acute":            0x00B4,
+	"adieresis":        0x00E4,
+	"ae":               0x00E6,
+	"afii61289":        0x2113,
+	"agrave":           0x00E0,
+	"aleph":            0x2135,
+	"alpha":            0x03B1,
+	"amacron":          0x0101,
+	"ampersand":        0x0026,
+	"angle":            0x2220,
+	"angleleft":        0x2329,
+	"angleright":       0x232A,
+	"aogonek":          0x0105,
+	"apple":            0xF8FF,
+	"approxequal":      0x2248,
+	"aring":            0x00E5,
+	"arrowboth":        0x2194,
+	"arrowdblboth":     0x21D4,
+	"arrowdblleft":     0x21D0,
+	"arrowdblright":    0x21D2,
+	"arrowdown":        0x2193,
+	"arrowleft":        0x2190,
+	"arrowright":       0x2192,
+	"arrowup":          0x2191,
+	"asciicircum":      0x005E,
+	"asciitilde":       0x007E,
+	"asterisk":         0x002A,
+	"asteriskmath":     0x2217,
+	"at":               0x0040,
+	"atilde":           0x00E3,
+	"b":                0x0062,
+	"backslash":        0x005C,
+	"bar":              0x007C,
+	"beta":             0x03B2,
+	"braceleft":        0x007B,
+	"braceright":       0x007D,
+	"bracketleft":      0x005B,
+	"bracketright":     0x005D,
+	"breve":            0x02D8,
+	"brokenbar":        0x00A6,
+	"bullet":           0x2022,
+	"c":                0x0063,
+	"cacute":           0x0107,
+	"caron":            0x02C7,
+	"ccaron":           0x010D,
+	"ccedilla":         0x00E7,
+	"cedilla":          0x00B8,
+	"cent":             0x00A2,
+	"chi":              0x03C7,
+	"circle":           0x25CB,
+	"circlemultiply":   0x2297,
+	"circleplus":       0x2295,
+	"circumflex":       0x02C6,
+	"club":             0x2663,
+	"colon":            0x003A,
+	"comma":            0x002C,
+	"commaaccent":      0xF6C3,
+	"congruent":        0x2245,
+	"copyright":        0x00A9,
+	"currency":         0x00A4,
+	"d":                0x0064,
+	"dagger":           0x2020,
+	"daggerdbl":        0x2021,
+	"dcaron":           0x010F,
+	"dcroat":           0x0111,
+	"degree":           0x00B0,
+	"delta":            0x03B4,
+	"diamond":          0x2666,
+	"dieresis":         0x00A8,
+	"divide":           0x00F7,
+	"dollar":           0x0024,
+	"dotaccent":        0x02D9,
+	"dotlessi":         0x0131,
+	"dotlessj":         0x0237,
+	"dotmath":          0x22C5,
+	"e":                0x0065,
+	"eacute":           0x00E9,
+	"ecaron":           0x011B,
+	"ecircumflex":      0x00EA,
+	"edieresis":        0x00EB,
+	"edotaccent":       0x0117,
+	"egrave":           0x00E8,
+	"eight":            0x0038,
+	"element":          0x2208,
+	"ellipsis":         0x2026,
+	"emacron":          0x0113,
+	"emdash":           0x2014,
+	"emptyset":         0x2205,
+	"endash":           0x2013,
+	"eng":              0x014B,
+	"eogonek":          0x0119,
+	"epsilon":          0x03B5,
+	"epsilon1":         0x03F5,
+	"equal":            0x003D,
+	"equivalence":      0x2261,
+	"estimated":        0x212E,
+	"eta":              0x03B7,
+	"eth":              0x00F0,
+	"exclam":           0x0021,
+	"exclamdbl":        0x203C,
+	"exclamdown":       0x00A1,
+	"existential":      0x2203,
+	"f":                0x0066,
+	"ff":               0xFB00,
+	"ffi":              0xFB03,
+	"ffl":              0xFB04,
+	"fi":               0xFB01,
+	"filledbox":        0x25A0,
+	"five":             0x0035,
+	"fl":               0xFB02,
+	"florin":           0x0192,
+	"four":             0x0034,
+	"fraction":         0x2044,
+	"franc":            0x20A3,
+	"g":                0x0067,
+	"gamma":            0x03B3,
+	"gbreve":           0x011F,
+	"gcommaaccent":     0x0123,
+	"germandbls":       0x00DF,
+	"gradient":         0x2207,
+	"grave":            0x0060,
+	"greater":          0x003E,
+	"greaterequal":     0x2265,
+	"guillemotleft":    0x00AB,
+	"guillemotright":   0x00BB,
+	"guilsinglleft":    0x2039,
+	"guilsinglright":   0x203A,
+	"h":                0x0068,
+	"hbar":             0x0127,
+	"heart":            0x2665,
+	"hungarumlaut":     0x02DD,
+	"hyphen":           0x002D,
+	"i":                0x0069,
+	"iacute":           0x00ED,
+	"icircumflex":      0x00EE,
+	"idieresis":        0x00EF,
+	"igrave":           0x00EC,
+	"ij":               0x0133,
+	"imacron":          0x012B,
+	"infinity":         0x221E,
+	"integral":         0x222B,
+	"intersection":     0x2229,
+	"iogonek":          0x012F,
+	"iota":             0x03B9,
+	"j":                0x006A,
+	"k":                0x006B,
+	"kappa":            0x03BA,
+	"kcommaaccent":     0x0137,
+	"kgreenlandic":     0x0138,
+	"l":                0x006C,
+	"lacute":           0x013A,
+	"lambda":           0x03BB,
+	"lcaron":           0x013E,
+	"lcommaaccent":     0x013C,
+	"ldot":             0x0140,
+	"less":             0x003C,
+	"lessequal":        0x2264,
+	"logicaland":       0x2227,
+	"logicalnot":       0x00AC,
+	"logicalor":        0x2228,
+	"longs":            0x017F,
+	"lozenge":          0x25CA,
+	"lslash":           0x0142,
+	"m":                0x006D,
+	"macron":           0x00AF,
+	"middot":           0x00B7,
+	"minus":            0x2212,
+	"minute":           0x2032,
+	"mu":               0x00B5,
+	"multiply":         0x00D7,
+	"n":                0x006E,
+	"nacute":           0x0144,
+	"napostrophe":      0x0149,
+	"ncaron":           0x0148,
+	"ncommaaccent":     0x0146,
+	"nine":             0x0039,
+	"nonbreakingspace": 0x00A0,
+	"nonmarkingreturn": 0x000D,
+	"notelement":       0x2209,
+	"notequal":         0x2260,
+	"ntilde":           0x00F1,
+	"nu":               0x03BD,
+	"numbersign":       0x0023,
+	"o":                0x006F,
+	"oacute":           0x00F3,
+	"ocircumflex":      0x00F4,
+	"odieresis":        0x00F6,
+	"oe":               0x0153,
+	"ogonek":           0x02DB,
+	"ograve":           0x00F2,
+	"ohungarumlaut":    0x0151,
+	"omacron":          0x014D,
+	"omega":            0x03C9,
+	"omega1":           0x03D6,
+	"omicron":          0x03BF,
+	"one":              0x0031,
+	"onehalf":          0x00BD,
+	"onequarter":       0x00BC,
+	"onesuperior":      0x00B9,
+	"openbullet":       0x25E6,
+	"ordfeminine":      0x00AA,
+	"ordmasculine":     0x00BA,
+	"oslash":           0x00F8,
+	"otilde":           0x00F5,
+	"overscore":        0x00AF,
+	"p":                0x0070,
+	"paragraph":        0x00B6,
+	"parenleft":        0x0028,
+	"parenright":       0x0029,
+	"partialdiff":      0x2202,
+	"percent":          0x0025,
+	"period":           0x002E,
+	"periodcentered":   0x00B7,
+	"perpendicular":    0x22A5,
+	"perthousand":      0x2030,
+	"phi":              0x03C6,
+	"phi1":             0x03D5,
+	"pi":               0x03C0,
+	"plus":             0x002B,
+	"plusminus":        0x00B1,
+	"prime":            0x2032,
+	"product":          0x220F,
+	"propersubset":     0x2282,
+	"propersuperset":   0x2283,
+	"proportional":     0x221D,
+	"psi":              0x03C8,
+	"q":                0x0071,
+	"question":         0x003F,
+	"questiondown":     0x00BF,
+	"quotedbl":         0x0022,
+	"quotedblbase":     0x201E,
+	"quotedblleft":     0x201C,
+	"quotedblright":    0x201D,
+	"quoteleft":        0x2018,
+	"quoteright":       0x2019,
+	"quotesinglbase":   0x201A,
+	"quotesingle":      0x0027,
+	"r":                0x0072,
+	"racute":           0x0155,
+	"radical":          0x221A,
+	"rcaron":           0x0159,
+	"rcommaaccent":     0x0157,
+	"reflexsubset":     0x2286,
+	"reflexsuperset":   0x2287,
+	"registered":       0x00AE,
+	"rho":              0x03C1,
+	"ring":             0x02DA,
+	"s":                0x0073,
+	"sacute":           0x015B,
+	"scaron":           0x0161,
+	"scedilla":         0x015F,
+	"scommaaccent":     0x0219,
+	"second":           0x2033,
+	"section":          0x00A7,
+	"semicolon":        0x003B,
+	"seven":            0x0037,
+	"sigma":            0x03C3,
+	"sigma1":           0x03C2,
+	"similar":          0x223C,
+	"six":              0x0036,
+	"slash":            0x002F,
+	"space":            0x0020,
+	"spade":            0x2660,
+	"sterling":         0x00A3,
+	"suchthat":         0x220B,
+	"summation":        0x2211,
+	"t":                0x0074,
+	"tau":              0x03C4,
+	"tbar":             0x0167,
+	"tcaron":           0x0165,
+	"tcommaaccent":     0x0163,
+	"therefore":        0x2234,
+	"theta":            0x03B8,
+	"theta1":           0x03D1,
+	"thorn":            0x00FE,
+	"three":            0x0033,
+	"threequarters":    0x00BE,
+	"threesuperior":    0x00B3,
+	"tilde":            0x02DC,
+	"trademark":        0x2122,
+	"two":              0x0032,
+	"twosuperior":      0x00B2,
+	"u":                0x0075,
+	"uacute":           0x00FA,
+	"ucircumflex":      0x00FB,
+	"udieresis":        0x00FC,
+	"ugrave":           0x00F9,
+	"uhungarumlaut":    0x0171,
+	"umacron":          0x016B,
+	"underscore":       0x005F,
+	"uni00A0":          0x00A0,
+	"union":            0x222A,
+	"universal":        0x2200,
+	"uogonek":          0x0173,
+	"upsilon":          0x03C5,
+	"uring":            0x016F,
+	"v":                0x0076,
+	"w":                0x0077,
+	"weierstrass":      0x2118,
+	"x":                0x0078,
+	"xi":               0x03BE,
+	"y":                0x0079,
+	"yacute":           0x00FD,
+	"ydieresis":        0x00FF,
+	"yen":              0x00A5,
+	"z":                0x007A,
+	"zacute":           0x017A,
+	"zcaron":           0x017E,
+	"zdotaccent":       0x017C,
+	"zero":             0x0030,
+	"zeta":             0x03B6,
+}
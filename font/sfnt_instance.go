@@ -0,0 +1,1001 @@
+package font
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// instanceDroppedTables are the variation tables that are removed from a static instance.
+var instanceDroppedTables = []string{"avar", "cvar", "fvar", "gvar", "HVAR", "MVAR", "STAT", "VVAR"}
+
+// Instance writes a static font of a variable font at the given user coordinates by axis tag, for example {"wght": 700, "wdth": 75}. Missing axes are set to their default and values are clamped to the axis range. The gvar deltas are applied to the glyf table or the blends of the CFF2 table are resolved, in which case the outlines are written as a CFF table. The HVAR and MVAR deltas are applied to the horizontal metrics and the hhea, OS/2, and post tables, and the variation tables are dropped. If the coordinates match a named instance, the name table and style bits are updated accordingly, otherwise only the PostScript name is updated. Variations of the GDEF and GPOS tables are not applied.
+func (sfnt *SFNT) Instance(coords map[string]float64) ([]byte, error) {
+	if sfnt.Fvar == nil {
+		return nil, fmt.Errorf("fvar: not a variable font")
+	}
+	norm, err := sfnt.normalizeCoords(coords)
+	if err != nil {
+		return nil, err
+	}
+
+	// user coordinates clamped to the axis ranges
+	user := make([]float64, len(sfnt.Fvar.Axes))
+	for i, axis := range sfnt.Fvar.Axes {
+		user[i] = axis.DefaultValue
+		if v, ok := coords[axis.Tag]; ok {
+			user[i] = math.Max(axis.MinValue, math.Min(axis.MaxValue, v))
+		}
+	}
+
+	// copy all tables so that the original font is not modified
+	sfnt.mu.Lock()
+	tables := map[string][]byte{}
+	for _, tag := range sfnt.tableTags() {
+		b, err := sfnt.table(tag)
+		if err != nil {
+			sfnt.mu.Unlock()
+			return nil, err
+		}
+		tables[tag] = append([]byte{}, b...)
+	}
+	sfnt.mu.Unlock()
+
+	numGlyphs := sfnt.Maxp.NumGlyphs
+	advances := make([]float64, numGlyphs)
+	lsbs := make([]int16, numGlyphs)
+	bounds := make([][4]int16, numGlyphs)
+	hasOutline := make([]bool, numGlyphs)
+	for glyphID := uint16(0); glyphID < numGlyphs; glyphID++ {
+		advances[glyphID] = float64(sfnt.Hmtx.Advance(glyphID))
+	}
+
+	hvar, err := sfnt.parseMetricsVariations("HVAR")
+	if err != nil {
+		return nil, err
+	}
+
+	if sfnt.IsTrueType {
+		if err := sfnt.instanceGlyf(tables, norm, hvar == nil, advances, lsbs, bounds, hasOutline); err != nil {
+			return nil, err
+		}
+		if err := sfnt.instanceCvt(tables, norm); err != nil {
+			return nil, err
+		}
+	} else if sfnt.IsCFF {
+		if err := sfnt.instanceCFF(tables, norm, hvar, advances, bounds, hasOutline); err != nil {
+			return nil, err
+		}
+		for glyphID := range lsbs {
+			lsbs[glyphID] = bounds[glyphID][0]
+		}
+	}
+	if hvar != nil {
+		for glyphID := range advances {
+			advances[glyphID] += hvar.AdvanceDelta(uint32(glyphID), norm)
+		}
+	}
+
+	// horizontal metrics
+	intAdvances := make([]uint16, numGlyphs)
+	for glyphID, advance := range advances {
+		intAdvances[glyphID] = uint16(math.Max(0.0, math.Round(advance)))
+	}
+	tables["hmtx"], tables["hhea"] = writeMetrics(tables["hhea"], intAdvances, lsbs)
+	advanceWidthMax, xAvgCharWidth, n := uint16(0), 0, 0
+	minLSB, minRSB, xMaxExtent := int16(math.MaxInt16), int16(math.MaxInt16), int16(math.MinInt16)
+	xMin, yMin, xMax, yMax := int16(math.MaxInt16), int16(math.MaxInt16), int16(math.MinInt16), int16(math.MinInt16)
+	for glyphID, advance := range intAdvances {
+		if advanceWidthMax < advance {
+			advanceWidthMax = advance
+		}
+		if advance != 0 {
+			xAvgCharWidth += int(advance)
+			n++
+		}
+		if hasOutline[glyphID] {
+			bound := bounds[glyphID]
+			minLSB = int16Min(minLSB, lsbs[glyphID])
+			minRSB = int16Min(minRSB, int16(int(advance)-int(lsbs[glyphID])-int(bound[2]-bound[0])))
+			xMaxExtent = int16Max(xMaxExtent, lsbs[glyphID]+bound[2]-bound[0])
+			xMin, yMin = int16Min(xMin, bound[0]), int16Min(yMin, bound[1])
+			xMax, yMax = int16Max(xMax, bound[2]), int16Max(yMax, bound[3])
+		}
+	}
+	if xMax < xMin {
+		minLSB, minRSB, xMaxExtent = 0, 0, 0
+		xMin, yMin, xMax, yMax = 0, 0, 0, 0
+	}
+	hhea := tables["hhea"]
+	binary.BigEndian.PutUint16(hhea[10:], advanceWidthMax)
+	binary.BigEndian.PutUint16(hhea[12:], uint16(minLSB))
+	binary.BigEndian.PutUint16(hhea[14:], uint16(minRSB))
+	binary.BigEndian.PutUint16(hhea[16:], uint16(xMaxExtent))
+	head := tables["head"]
+	binary.BigEndian.PutUint16(head[36:], uint16(xMin))
+	binary.BigEndian.PutUint16(head[38:], uint16(yMin))
+	binary.BigEndian.PutUint16(head[40:], uint16(xMax))
+	binary.BigEndian.PutUint16(head[42:], uint16(yMax))
+	if os2 := tables["OS/2"]; 4 <= len(os2) && 0 < n {
+		binary.BigEndian.PutUint16(os2[2:], uint16((xAvgCharWidth+n/2)/n))
+	}
+
+	// vertical advances
+	if vvar, err := sfnt.parseMetricsVariations("VVAR"); err != nil {
+		return nil, err
+	} else if vvar != nil && sfnt.Vmtx != nil && tables["vhea"] != nil {
+		vAdvances := make([]uint16, numGlyphs)
+		tsbs := make([]int16, numGlyphs)
+		for glyphID := uint16(0); glyphID < numGlyphs; glyphID++ {
+			advance := float64(sfnt.Vmtx.Advance(glyphID)) + vvar.AdvanceDelta(uint32(glyphID), norm)
+			vAdvances[glyphID] = uint16(math.Max(0.0, math.Round(advance)))
+			tsbs[glyphID] = sfnt.Vmtx.TopSideBearing(glyphID)
+		}
+		tables["vmtx"], tables["vhea"] = writeMetrics(tables["vhea"], vAdvances, tsbs)
+	}
+
+	if err := sfnt.instanceMVAR(tables, norm); err != nil {
+		return nil, err
+	}
+
+	// style values from the axes
+	for i, axis := range sfnt.Fvar.Axes {
+		switch axis.Tag {
+		case "wght":
+			if os2 := tables["OS/2"]; 6 <= len(os2) {
+				binary.BigEndian.PutUint16(os2[4:], uint16(math.Max(1.0, math.Min(1000.0, math.Round(user[i])))))
+			}
+		case "wdth":
+			if os2 := tables["OS/2"]; 8 <= len(os2) {
+				binary.BigEndian.PutUint16(os2[6:], os2WidthClass(user[i]))
+			}
+		case "slnt":
+			if post := tables["post"]; 8 <= len(post) {
+				binary.BigEndian.PutUint32(post[4:], uint32(int32(math.Round(user[i]*(1<<16)))))
+			}
+		}
+	}
+
+	if err := sfnt.instanceNames(tables, user); err != nil {
+		return nil, err
+	}
+
+	for _, tag := range instanceDroppedTables {
+		delete(tables, tag)
+	}
+	instance := &SFNT{
+		Version:    sfnt.Version,
+		IsTrueType: sfnt.IsTrueType,
+		IsCFF:      sfnt.IsCFF,
+		Tables:     tables,
+	}
+	return instance.Write(), nil
+}
+
+// instanceGlyf applies the gvar deltas to the glyphs and writes the glyf and loca tables. The advances are set from the phantom points if usePhantom is set, and the left side bearings and bounds are set for each glyph.
+func (sfnt *SFNT) instanceGlyf(tables map[string][]byte, coords []float64, usePhantom bool, advances []float64, lsbs []int16, bounds [][4]int16, hasOutline []bool) error {
+	var gvar *gvarTable
+	if sfnt.HasTable("gvar") {
+		var err error
+		if gvar, err = sfnt.parseGvar(); err != nil {
+			return err
+		}
+	}
+
+	numGlyphs := sfnt.Maxp.NumGlyphs
+	pp1 := make([]float64, numGlyphs) // horizontal origin of each glyph
+	glyphs := make([][]byte, numGlyphs)
+	for glyphID := uint16(0); glyphID < numGlyphs; glyphID++ {
+		b := sfnt.Glyf.Get(glyphID)
+		if b == nil {
+			return fmt.Errorf("glyf: bad glyphID %v", glyphID)
+		}
+
+		var xMin int16
+		var numberOfContours int16
+		var contour *glyfContour
+		var components []glyfComponent
+		var xs, ys []float64
+		if 10 <= len(b) {
+			numberOfContours = int16(binary.BigEndian.Uint16(b))
+			xMin = int16(binary.BigEndian.Uint16(b[2:]))
+			if 0 < numberOfContours {
+				var err error
+				if contour, err = sfnt.Glyf.Contour(glyphID, 0); err != nil {
+					return err
+				}
+				for i := range contour.XCoordinates {
+					xs = append(xs, float64(contour.XCoordinates[i]))
+					ys = append(ys, float64(contour.YCoordinates[i]))
+				}
+			} else if numberOfContours < 0 {
+				var err error
+				if components, err = parseGlyfComponents(b); err != nil {
+					return fmt.Errorf("glyf: glyphID %v: %w", glyphID, err)
+				}
+				for _, component := range components {
+					xs = append(xs, float64(component.dx))
+					ys = append(ys, float64(component.dy))
+				}
+			}
+		} else if len(b) != 0 {
+			return fmt.Errorf("glyf: bad table for glyphID %v", glyphID)
+		}
+
+		// phantom points, the vertical phantom points are not used
+		origin := float64(xMin - sfnt.Hmtx.LeftSideBearing(glyphID))
+		if len(b) == 0 {
+			origin = 0.0
+		}
+		xs = append(xs, origin, origin+advances[glyphID], 0.0, 0.0)
+		ys = append(ys, 0.0, 0.0, 0.0, 0.0)
+
+		if gvar != nil {
+			var endPoints []uint16
+			if contour != nil {
+				endPoints = contour.EndPoints
+			}
+			dx, dy, err := gvar.Deltas(glyphID, coords, xs, ys, endPoints)
+			if err != nil {
+				return err
+			}
+			for i := range xs {
+				xs[i] = math.Round(xs[i] + dx[i])
+				ys[i] = math.Round(ys[i] + dy[i])
+			}
+		}
+
+		n := len(xs) - 4
+		pp1[glyphID] = xs[n]
+		if usePhantom {
+			advances[glyphID] = xs[n+1] - xs[n]
+		}
+		if contour != nil {
+			for i := 0; i < n; i++ {
+				contour.XCoordinates[i] = int16(xs[i])
+				contour.YCoordinates[i] = int16(ys[i])
+			}
+			glyphs[glyphID] = writeGlyfSimple(contour)
+		} else if components != nil {
+			for i := range components {
+				if components[i].flags&0x0002 != 0 { // ARGS_ARE_XY_VALUES
+					components[i].dx = int16(xs[i])
+					components[i].dy = int16(ys[i])
+				}
+			}
+			glyphs[glyphID] = writeGlyfComposite(b, components)
+		}
+	}
+
+	// write glyf and loca, bounds of composite glyphs are recalculated from the new glyph data
+	glyf := NewBinaryWriter([]byte{})
+	offsets := make([]uint32, numGlyphs+1)
+	for glyphID, b := range glyphs {
+		offsets[glyphID] = glyf.Len()
+		glyf.WriteBytes(b)
+		if len(b)%2 == 1 {
+			glyf.WriteByte(0)
+		}
+	}
+	offsets[numGlyphs] = glyf.Len()
+	locaShortFormat := offsets[numGlyphs]/2 <= math.MaxUint16
+	loca := NewBinaryWriter([]byte{})
+	for _, offset := range offsets {
+		if locaShortFormat {
+			loca.WriteUint16(uint16(offset / 2))
+		} else {
+			loca.WriteUint32(offset)
+		}
+	}
+	tables["glyf"] = glyf.Bytes()
+	tables["loca"] = loca.Bytes()
+	if locaShortFormat {
+		binary.BigEndian.PutUint16(tables["head"][50:], 0)
+	} else {
+		binary.BigEndian.PutUint16(tables["head"][50:], 1)
+	}
+
+	instanceGlyf := &glyfTable{
+		data: tables["glyf"],
+		loca: &locaTable{
+			format: int16(binary.BigEndian.Uint16(tables["head"][50:])),
+			data:   tables["loca"],
+		},
+	}
+	for glyphID := uint16(0); glyphID < numGlyphs; glyphID++ {
+		b := glyphs[glyphID]
+		if len(b) == 0 {
+			continue
+		}
+		if int16(binary.BigEndian.Uint16(b)) < 0 {
+			if contour, err := instanceGlyf.Contour(glyphID, 0); err == nil && 0 < len(contour.XCoordinates) {
+				xMin, yMin, xMax, yMax := glyfPointsBounds(contour)
+				start := offsets[glyphID]
+				binary.BigEndian.PutUint16(tables["glyf"][start+2:], uint16(xMin))
+				binary.BigEndian.PutUint16(tables["glyf"][start+4:], uint16(yMin))
+				binary.BigEndian.PutUint16(tables["glyf"][start+6:], uint16(xMax))
+				binary.BigEndian.PutUint16(tables["glyf"][start+8:], uint16(yMax))
+			}
+		}
+		b = tables["glyf"][offsets[glyphID]:]
+		for i := 0; i < 4; i++ {
+			bounds[glyphID][i] = int16(binary.BigEndian.Uint16(b[2+2*i:]))
+		}
+		hasOutline[glyphID] = true
+		lsbs[glyphID] = int16(float64(bounds[glyphID][0]) - pp1[glyphID])
+	}
+	return nil
+}
+
+// instanceCvt applies the cvar deltas to the control values.
+func (sfnt *SFNT) instanceCvt(tables map[string][]byte, coords []float64) error {
+	b, err := sfnt.Table("cvar")
+	if err != nil {
+		return nil
+	}
+	cvt := tables["cvt "]
+	if len(cvt) == 0 {
+		return nil
+	} else if len(b) < 8 {
+		return fmt.Errorf("cvar: bad table")
+	}
+
+	r := NewBinaryReader(b)
+	majorVersion := r.ReadUint16()
+	_ = r.ReadUint16() // minorVersion
+	tupleVariationCount := r.ReadUint16()
+	dataOffset := r.ReadUint16()
+	if majorVersion != 1 {
+		return fmt.Errorf("cvar: bad version")
+	}
+	n := len(cvt) / 2
+	tuples, err := parseTupleVariations(b, r, tupleVariationCount, uint32(dataOffset), nil, coords, n, 1)
+	if err != nil {
+		return fmt.Errorf("cvar: %w", err)
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(int16(binary.BigEndian.Uint16(cvt[2*i:])))
+	}
+	for _, tuple := range tuples {
+		for i, delta := range tuple.deltas[0] {
+			index := i
+			if tuple.points != nil {
+				index = int(tuple.points[i])
+			}
+			values[index] += tuple.scalar * float64(delta)
+		}
+	}
+	for i, value := range values {
+		binary.BigEndian.PutUint16(cvt[2*i:], uint16(int16(math.Round(value))))
+	}
+	return nil
+}
+
+// instanceCFF resolves the blends of the CFF2 table and writes the outlines as a CFF table. The advances are needed for the charstrings and the bounds are set for each glyph.
+func (sfnt *SFNT) instanceCFF(tables map[string][]byte, coords []float64, hvar *metricsVariations, advances []float64, bounds [][4]int16, hasOutline []bool) error {
+	cff2, err := sfnt.loadCFF()
+	if err != nil {
+		return err
+	} else if cff2.version != 2 {
+		return nil
+	}
+	cff := *cff2
+	cff.coords = coords
+
+	b := &sfntBuilder{
+		PostScriptName: sfnt.NameString(NamePostScript),
+		FamilyName:     sfnt.NameString(NameFontFamily),
+		FullName:       sfnt.NameString(NameFull),
+		Version:        sfnt.NameString(NameVersion),
+		Copyright:      sfnt.NameString(NameCopyrightNotice),
+		UnitsPerEm:     sfnt.Head.UnitsPerEm,
+		Glyphs:         make([]sfntBuilderGlyph, sfnt.Maxp.NumGlyphs),
+	}
+	if sfnt.Post != nil {
+		b.ItalicAngle = float64(int32(sfnt.Post.ItalicAngle)) / (1 << 16)
+		b.UnderlinePosition = sfnt.Post.UnderlinePosition
+		b.UnderlineThickness = sfnt.Post.UnderlineThickness
+		b.IsFixedPitch = sfnt.Post.IsFixedPitch != 0
+	}
+
+	names := map[string]bool{}
+	for glyphID := range b.Glyphs {
+		advance := advances[glyphID]
+		if hvar != nil {
+			advance += hvar.AdvanceDelta(uint32(glyphID), coords)
+		}
+
+		bbox := &bboxPather{}
+		if err := cff.ToPath(bbox, uint16(glyphID), 0, 0, 0, 1.0, NoHinting); err != nil {
+			return err
+		}
+		charString := newType2CharString(math.Round(advance))
+		if err := cff.ToPath(charString, uint16(glyphID), 0, 0, 0, 1.0, NoHinting); err != nil {
+			return err
+		}
+
+		name := sfnt.GlyphName(uint16(glyphID))
+		if glyphID == 0 {
+			name = ".notdef"
+		} else if name == "" || names[name] {
+			name = "glyph" + strconv.Itoa(glyphID)
+		}
+		names[name] = true
+
+		glyph := &b.Glyphs[glyphID]
+		glyph.Name = name
+		glyph.Advance = uint16(math.Max(0.0, math.Round(advance)))
+		glyph.CharString = charString.Bytes()
+		if bbox.hasPoints {
+			glyph.XMin, glyph.YMin = int16(math.Floor(bbox.xMin)), int16(math.Floor(bbox.yMin))
+			glyph.XMax, glyph.YMax = int16(math.Ceil(bbox.xMax)), int16(math.Ceil(bbox.yMax))
+			bounds[glyphID] = [4]int16{glyph.XMin, glyph.YMin, glyph.XMax, glyph.YMax}
+			hasOutline[glyphID] = true
+		}
+	}
+	if b.PostScriptName == "" {
+		b.PostScriptName = "Untitled"
+	}
+	tables["CFF "] = b.cff()
+	delete(tables, "CFF2")
+
+	// glyph names are stored in the CFF table
+	if post := tables["post"]; 32 <= len(post) {
+		binary.BigEndian.PutUint32(post, 0x00030000)
+		tables["post"] = post[:32]
+	}
+	return nil
+}
+
+// instanceMVAR applies the MVAR deltas to the font-wide metrics.
+func (sfnt *SFNT) instanceMVAR(tables map[string][]byte, coords []float64) error {
+	b, err := sfnt.Table("MVAR")
+	if err != nil {
+		return nil
+	} else if len(b) < 12 {
+		return fmt.Errorf("MVAR: bad table")
+	}
+
+	r := NewBinaryReader(b)
+	majorVersion := r.ReadUint16()
+	_ = r.ReadUint16() // minorVersion
+	_ = r.ReadUint16() // reserved
+	valueRecordSize := r.ReadUint16()
+	valueRecordCount := r.ReadUint16()
+	itemVariationStoreOffset := r.ReadUint16()
+	if majorVersion != 1 || valueRecordSize < 8 || uint32(len(b)) < 12+uint32(valueRecordCount)*uint32(valueRecordSize) {
+		return fmt.Errorf("MVAR: bad table")
+	} else if valueRecordCount == 0 || itemVariationStoreOffset == 0 {
+		return nil
+	} else if len(b) <= int(itemVariationStoreOffset) {
+		return fmt.Errorf("MVAR: bad item variation store offset")
+	}
+	store, err := parseItemVariationStore(b[itemVariationStoreOffset:])
+	if err != nil {
+		return fmt.Errorf("MVAR: %w", err)
+	}
+
+	for i := 0; i < int(valueRecordCount); i++ {
+		r.Seek(12 + uint32(i)*uint32(valueRecordSize))
+		valueTag := r.ReadString(4)
+		outer := r.ReadUint16()
+		inner := r.ReadUint16()
+		target, ok := mvarTargets[valueTag]
+		if !ok {
+			continue
+		}
+		table := tables[target.table]
+		if len(table) < target.offset+2 {
+			continue
+		}
+		delta := store.Delta(outer, inner, coords)
+		value := binary.BigEndian.Uint16(table[target.offset:])
+		if target.unsigned {
+			binary.BigEndian.PutUint16(table[target.offset:], uint16(math.Max(0.0, math.Min(math.MaxUint16, math.Round(float64(value)+delta)))))
+		} else {
+			binary.BigEndian.PutUint16(table[target.offset:], uint16(int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(float64(int16(value))+delta))))))
+		}
+	}
+	return nil
+}
+
+// mvarTargets are the table fields of the MVAR value tags, see https://docs.microsoft.com/en-us/typography/opentype/spec/mvar#value-tags.
+var mvarTargets = map[string]struct {
+	table    string
+	offset   int
+	unsigned bool
+}{
+	"hasc": {"OS/2", 68, false}, // sTypoAscender
+	"hdsc": {"OS/2", 70, false}, // sTypoDescender
+	"hlgp": {"OS/2", 72, false}, // sTypoLineGap
+	"hcla": {"OS/2", 74, true},  // usWinAscent
+	"hcld": {"OS/2", 76, true},  // usWinDescent
+	"xhgt": {"OS/2", 86, false}, // sxHeight
+	"cpht": {"OS/2", 88, false}, // sCapHeight
+	"sbxs": {"OS/2", 10, false}, // ySubscriptXSize
+	"sbys": {"OS/2", 12, false}, // ySubscriptYSize
+	"sbxo": {"OS/2", 14, false}, // ySubscriptXOffset
+	"sbyo": {"OS/2", 16, false}, // ySubscriptYOffset
+	"spxs": {"OS/2", 18, false}, // ySuperscriptXSize
+	"spys": {"OS/2", 20, false}, // ySuperscriptYSize
+	"spxo": {"OS/2", 22, false}, // ySuperscriptXOffset
+	"spyo": {"OS/2", 24, false}, // ySuperscriptYOffset
+	"strs": {"OS/2", 26, false}, // yStrikeoutSize
+	"stro": {"OS/2", 28, false}, // yStrikeoutPosition
+	"hcrs": {"hhea", 18, false}, // caretSlopeRise
+	"hcrn": {"hhea", 20, false}, // caretSlopeRun
+	"hcof": {"hhea", 22, false}, // caretOffset
+	"vasc": {"vhea", 4, false},  // ascent
+	"vdsc": {"vhea", 6, false},  // descent
+	"vlgp": {"vhea", 8, false},  // lineGap
+	"vcrs": {"vhea", 18, false}, // caretSlopeRise
+	"vcrn": {"vhea", 20, false}, // caretSlopeRun
+	"vcof": {"vhea", 22, false}, // caretOffset
+	"undo": {"post", 8, false},  // underlinePosition
+	"unds": {"post", 10, false}, // underlineThickness
+}
+
+// instanceNames updates the names and style bits for the named instance at the user coordinates, or sets a generated PostScript name otherwise, see https://adobe-type-tools.github.io/font-tech-notes/pdfs/5902.AdobePSNameGeneration.pdf.
+func (sfnt *SFNT) instanceNames(tables map[string][]byte, user []float64) error {
+	if sfnt.Name == nil {
+		return nil
+	}
+
+	instance := -1
+	for i, fvarInstance := range sfnt.Fvar.Instances {
+		match := true
+		for j, v := range fvarInstance.Coordinates {
+			if math.Abs(v-user[j]) > 1e-3 {
+				match = false
+				break
+			}
+		}
+		if match {
+			instance = i
+			break
+		}
+	}
+
+	family := sfnt.NameString(NamePreferredFamily)
+	if family == "" {
+		family = sfnt.NameString(NameFontFamily)
+	}
+	prefix := sfnt.NameString(NameVariationsPostScriptPrefix)
+	if prefix == "" {
+		prefix = family
+	}
+	prefix = postScriptName(prefix)
+
+	names := map[NameID]string{}
+	var postScript string
+	if 0 <= instance {
+		subfamily := sfnt.NameString(sfnt.Fvar.Instances[instance].SubfamilyNameID)
+		if subfamily == "" {
+			subfamily = "Regular"
+		}
+		if id := sfnt.Fvar.Instances[instance].PostScriptNameID; id != 0xFFFF {
+			postScript = sfnt.NameString(id)
+		}
+		if postScript == "" {
+			postScript = prefix + "-" + postScriptName(subfamily)
+		}
+
+		// split the subfamily into a weight/width part and the italic style for the RIBBI names
+		italic := false
+		words := []string{}
+		for _, word := range strings.Fields(subfamily) {
+			if word == "Italic" || word == "Oblique" {
+				italic = true
+			} else {
+				words = append(words, word)
+			}
+		}
+		style := strings.Join(words, " ")
+		bold := style == "Bold"
+		if style == "" || style == "Regular" || style == "Bold" {
+			names[NameFontFamily] = family
+			names[NamePreferredFamily] = ""
+			names[NamePreferredSubfamily] = ""
+		} else {
+			names[NameFontFamily] = family + " " + style
+			names[NamePreferredFamily] = family
+			names[NamePreferredSubfamily] = subfamily
+		}
+		if bold && italic {
+			names[NameFontSubfamily] = "Bold Italic"
+		} else if bold {
+			names[NameFontSubfamily] = "Bold"
+		} else if italic {
+			names[NameFontSubfamily] = "Italic"
+		} else {
+			names[NameFontSubfamily] = "Regular"
+		}
+		names[NameFull] = family + " " + subfamily
+
+		// fsSelection and macStyle
+		if os2 := tables["OS/2"]; 64 <= len(os2) {
+			fsSelection := binary.BigEndian.Uint16(os2[62:]) &^ 0x0061
+			if italic {
+				fsSelection |= 0x0001 // ITALIC
+			}
+			if bold {
+				fsSelection |= 0x0020 // BOLD
+			}
+			if !italic && !bold {
+				fsSelection |= 0x0040 // REGULAR
+			}
+			binary.BigEndian.PutUint16(os2[62:], fsSelection)
+		}
+		macStyle := binary.BigEndian.Uint16(tables["head"][44:]) &^ 0x0003
+		if bold {
+			macStyle |= 0x0001
+		}
+		if italic {
+			macStyle |= 0x0002
+		}
+		binary.BigEndian.PutUint16(tables["head"][44:], macStyle)
+	} else {
+		postScript = prefix
+		for i, axis := range sfnt.Fvar.Axes {
+			if user[i] != axis.DefaultValue {
+				postScript += "_" + strconv.FormatFloat(math.Round(user[i]*100.0)/100.0, 'f', -1, 64) + strings.TrimRight(axis.Tag, " ")
+			}
+		}
+	}
+	if 63 < len(postScript) {
+		postScript = postScript[:63]
+	}
+	names[NamePostScript] = postScript
+	names[NameVariationsPostScriptPrefix] = ""
+
+	vendor := ""
+	if os2 := tables["OS/2"]; 62 <= len(os2) {
+		vendor = strings.TrimRight(string(os2[58:62]), " \x00")
+	}
+	revision := float64(sfnt.Head.FontRevision) / (1 << 16)
+	names[NameUniqueIdentifier] = strconv.FormatFloat(revision, 'f', 3, 64) + ";" + vendor + ";" + postScript
+
+	records := []nameRecord{}
+	for _, record := range sfnt.Name.NameRecord {
+		if _, ok := names[record.Name]; !ok {
+			records = append(records, record)
+		}
+	}
+	for nameID, value := range names {
+		if value == "" {
+			continue
+		}
+		b := []byte{}
+		for _, c := range utf16.Encode([]rune(value)) {
+			b = append(b, byte(c>>8), byte(c))
+		}
+		records = append(records, nameRecord{
+			Platform: PlatformWindows,
+			Encoding: EncodingWindowsUnicodeBMP,
+			Language: 0x0409,
+			Name:     nameID,
+			Value:    b,
+		})
+	}
+	tables["name"] = writeNameTable(records, sfnt.Name.LangTag)
+	return nil
+}
+
+// writeNameTable writes a name table with the given records, which are sorted as required. It writes version 1 if there are language tag records.
+func writeNameTable(records []nameRecord, langTags []nameLangTagRecord) []byte {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		} else if a.Encoding != b.Encoding {
+			return a.Encoding < b.Encoding
+		} else if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		return a.Name < b.Name
+	})
+
+	version := uint16(0)
+	headerLength := 6 + 12*len(records)
+	if 0 < len(langTags) {
+		version = 1
+		headerLength += 2 + 4*len(langTags)
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(version)
+	w.WriteUint16(uint16(len(records)))
+	w.WriteUint16(uint16(headerLength)) // storageOffset
+	storage := NewBinaryWriter([]byte{})
+	for _, record := range records {
+		w.WriteUint16(uint16(record.Platform))
+		w.WriteUint16(uint16(record.Encoding))
+		w.WriteUint16(record.Language)
+		w.WriteUint16(uint16(record.Name))
+		w.WriteUint16(uint16(len(record.Value)))
+		w.WriteUint16(uint16(storage.Len()))
+		storage.WriteBytes(record.Value)
+	}
+	if version == 1 {
+		w.WriteUint16(uint16(len(langTags)))
+		for _, langTag := range langTags {
+			w.WriteUint16(uint16(len(langTag.Value)))
+			w.WriteUint16(uint16(storage.Len()))
+			storage.WriteBytes(langTag.Value)
+		}
+	}
+	w.WriteBytes(storage.Bytes())
+	return w.Bytes()
+}
+
+// postScriptName removes the characters that are not allowed in PostScript names.
+func postScriptName(s string) string {
+	sb := strings.Builder{}
+	for _, r := range s {
+		if 33 <= r && r <= 126 && !strings.ContainsRune("[](){}<>/%", r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// os2WidthClass returns the usWidthClass closest to the width percentage of the wdth axis.
+func os2WidthClass(wdth float64) uint16 {
+	widths := []float64{50.0, 62.5, 75.0, 87.5, 100.0, 112.5, 125.0, 150.0, 200.0}
+	class := 0
+	for i, width := range widths {
+		if math.Abs(width-wdth) < math.Abs(widths[class]-wdth) {
+			class = i
+		}
+	}
+	return uint16(class + 1)
+}
+
+// writeMetrics writes the hmtx or vmtx table for the advances and side bearings, and returns it together with the hhea or vhea table with an updated number of metrics.
+func writeMetrics(header []byte, advances []uint16, bearings []int16) ([]byte, []byte) {
+	numberOfMetrics := len(advances)
+	for 1 < numberOfMetrics && advances[numberOfMetrics-1] == advances[numberOfMetrics-2] {
+		numberOfMetrics--
+	}
+
+	w := NewBinaryWriter([]byte{})
+	for i := range advances {
+		if i < numberOfMetrics {
+			w.WriteUint16(advances[i])
+		}
+		w.WriteInt16(bearings[i])
+	}
+	binary.BigEndian.PutUint16(header[34:], uint16(numberOfMetrics))
+	return w.Bytes(), header
+}
+
+////////////////////////////////////////////////////////////////
+
+// metricsVariations holds the advance variations of the HVAR or VVAR tables.
+type metricsVariations struct {
+	store      *itemVariationStore
+	advanceMap *deltaSetIndexMap
+}
+
+// parseMetricsVariations parses the HVAR or VVAR table, it returns nil if the table does not exist.
+func (sfnt *SFNT) parseMetricsVariations(tag string) (*metricsVariations, error) {
+	b, err := sfnt.Table(tag)
+	if err != nil {
+		return nil, nil
+	} else if len(b) < 20 {
+		return nil, fmt.Errorf("%s: bad table", tag)
+	}
+
+	r := NewBinaryReader(b)
+	majorVersion := r.ReadUint16()
+	_ = r.ReadUint16() // minorVersion
+	itemVariationStoreOffset := r.ReadUint32()
+	advanceMappingOffset := r.ReadUint32()
+	if majorVersion != 1 || uint32(len(b)) <= itemVariationStoreOffset || uint32(len(b)) <= advanceMappingOffset {
+		return nil, fmt.Errorf("%s: bad table", tag)
+	}
+
+	vars := &metricsVariations{}
+	if vars.store, err = parseItemVariationStore(b[itemVariationStoreOffset:]); err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	if advanceMappingOffset != 0 {
+		if vars.advanceMap, err = parseDeltaSetIndexMap(b[advanceMappingOffset:]); err != nil {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+	}
+	return vars, nil
+}
+
+// AdvanceDelta returns the advance delta of a glyph for the normalized coordinates.
+func (vars *metricsVariations) AdvanceDelta(glyphID uint32, coords []float64) float64 {
+	outer, inner := vars.advanceMap.Get(glyphID)
+	return vars.store.Delta(outer, inner, coords)
+}
+
+////////////////////////////////////////////////////////////////
+
+// glyfComponent is a component of a composite glyph with its offset, which is a point number when ARGS_ARE_XY_VALUES is not set.
+type glyfComponent struct {
+	flags   uint16
+	glyphID uint16
+	dx, dy  int16
+	scale   []byte // raw transformation
+}
+
+func parseGlyfComponents(b []byte) ([]glyfComponent, error) {
+	r := NewBinaryReader(b)
+	_ = r.ReadBytes(10) // header
+	components := []glyfComponent{}
+	for {
+		var component glyfComponent
+		component.flags = r.ReadUint16()
+		component.glyphID = r.ReadUint16()
+		if component.flags&0x0001 != 0 { // ARG_1_AND_2_ARE_WORDS
+			component.dx = r.ReadInt16()
+			component.dy = r.ReadInt16()
+		} else if component.flags&0x0002 != 0 { // ARGS_ARE_XY_VALUES
+			component.dx = int16(r.ReadInt8())
+			component.dy = int16(r.ReadInt8())
+		} else {
+			component.dx = int16(r.ReadUint8())
+			component.dy = int16(r.ReadUint8())
+		}
+		length, more := glyfCompositeLength(component.flags)
+		component.scale = r.ReadBytes(length - 6 - 2*uint32(component.flags&0x0001))
+		if r.EOF() {
+			return nil, fmt.Errorf("bad composite glyph")
+		}
+		components = append(components, component)
+		if !more {
+			break
+		}
+	}
+	return components, nil
+}
+
+// writeGlyfComposite writes a composite glyph with the given components, the header and instructions are copied from the original glyph data b. The bounds are recalculated later.
+func writeGlyfComposite(b []byte, components []glyfComponent) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteBytes(b[:10])
+	hasInstructions := false
+	for _, component := range components {
+		flags := component.flags &^ 0x0001
+		if component.flags&0x0002 != 0 {
+			if component.dx < math.MinInt8 || math.MaxInt8 < component.dx || component.dy < math.MinInt8 || math.MaxInt8 < component.dy {
+				flags |= 0x0001
+			}
+		} else if math.MaxUint8 < uint16(component.dx) || math.MaxUint8 < uint16(component.dy) {
+			flags |= 0x0001
+		}
+		hasInstructions = hasInstructions || flags&0x0100 != 0
+		w.WriteUint16(flags)
+		w.WriteUint16(component.glyphID)
+		if flags&0x0001 != 0 {
+			w.WriteInt16(component.dx)
+			w.WriteInt16(component.dy)
+		} else {
+			w.WriteUint8(uint8(component.dx))
+			w.WriteUint8(uint8(component.dy))
+		}
+		w.WriteBytes(component.scale)
+	}
+	if hasInstructions {
+		// instructions follow the last component
+		offset := uint32(10)
+		for {
+			flags := binary.BigEndian.Uint16(b[offset:])
+			length, more := glyfCompositeLength(flags)
+			offset += length
+			if !more {
+				break
+			}
+		}
+		w.WriteBytes(b[offset:])
+	}
+	return w.Bytes()
+}
+
+// writeGlyfSimple writes a simple glyph with compressed flags and coordinates, and bounds calculated from its points.
+func writeGlyfSimple(contour *glyfContour) []byte {
+	xMin, yMin, xMax, yMax := glyfPointsBounds(contour)
+	w := NewBinaryWriter([]byte{})
+	w.WriteInt16(int16(len(contour.EndPoints)))
+	w.WriteInt16(xMin)
+	w.WriteInt16(yMin)
+	w.WriteInt16(xMax)
+	w.WriteInt16(yMax)
+	for _, endPoint := range contour.EndPoints {
+		w.WriteUint16(endPoint)
+	}
+	w.WriteUint16(uint16(len(contour.Instructions)))
+	w.WriteBytes(contour.Instructions)
+
+	n := len(contour.XCoordinates)
+	flags := make([]byte, n)
+	xs, ys := NewBinaryWriter([]byte{}), NewBinaryWriter([]byte{})
+	var x, y int16
+	for i := 0; i < n; i++ {
+		if contour.OnCurve[i] {
+			flags[i] |= 0x01 // ON_CURVE_POINT
+		}
+		dx := int(contour.XCoordinates[i]) - int(x)
+		if dx == 0 {
+			flags[i] |= 0x10 // X_IS_SAME_OR_POSITIVE_X_SHORT_VECTOR
+		} else if -255 <= dx && dx <= 255 {
+			flags[i] |= 0x02 // X_SHORT_VECTOR
+			if 0 < dx {
+				flags[i] |= 0x10
+			} else {
+				dx = -dx
+			}
+			xs.WriteUint8(uint8(dx))
+		} else {
+			xs.WriteInt16(int16(dx))
+		}
+		dy := int(contour.YCoordinates[i]) - int(y)
+		if dy == 0 {
+			flags[i] |= 0x20 // Y_IS_SAME_OR_POSITIVE_Y_SHORT_VECTOR
+		} else if -255 <= dy && dy <= 255 {
+			flags[i] |= 0x04 // Y_SHORT_VECTOR
+			if 0 < dy {
+				flags[i] |= 0x20
+			} else {
+				dy = -dy
+			}
+			ys.WriteUint8(uint8(dy))
+		} else {
+			ys.WriteInt16(int16(dy))
+		}
+		x, y = contour.XCoordinates[i], contour.YCoordinates[i]
+	}
+	for i := 0; i < n; {
+		repeat := 0
+		for i+repeat+1 < n && flags[i+repeat+1] == flags[i] && repeat < 255 {
+			repeat++
+		}
+		if 0 < repeat {
+			w.WriteUint8(flags[i] | 0x08) // REPEAT_FLAG
+			w.WriteUint8(uint8(repeat))
+		} else {
+			w.WriteUint8(flags[i])
+		}
+		i += repeat + 1
+	}
+	w.WriteBytes(xs.Bytes())
+	w.WriteBytes(ys.Bytes())
+	return w.Bytes()
+}
+
+func glyfPointsBounds(contour *glyfContour) (xMin, yMin, xMax, yMax int16) {
+	if len(contour.XCoordinates) == 0 {
+		return 0, 0, 0, 0
+	}
+	xMin, yMin = contour.XCoordinates[0], contour.YCoordinates[0]
+	xMax, yMax = xMin, yMin
+	for i := range contour.XCoordinates {
+		xMin, xMax = int16Min(xMin, contour.XCoordinates[i]), int16Max(xMax, contour.XCoordinates[i])
+		yMin, yMax = int16Min(yMin, contour.YCoordinates[i]), int16Max(yMax, contour.YCoordinates[i])
+	}
+	return
+}
+
+func int16Min(a, b int16) int16 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func int16Max(a, b int16) int16 {
+	if a < b {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,180 @@
+package font
+
+import (
+	"testing"
+)
+
+// addTables adds the tables to the font file and returns the parsed font.
+func addTables(t *testing.T, b []byte, tables map[string][]byte) *SFNT {
+	t.Helper()
+	sfnt, err := ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tag, table := range tables {
+		sfnt.Tables[tag] = table
+	}
+	if sfnt, err = ParseSFNT(sfnt.Write(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return sfnt
+}
+
+// testVariableFont returns a TrueType font with a rectangle glyph for 'a' and a wght axis from 100 to 900 with a default of 400. At the maximum weight the gvar table moves all points by (50,40) and adds 100 to the advance. The y-offset is given for point zero only so that it must be inferred for the other points.
+func testVariableFont(t *testing.T) *SFNT {
+	t.Helper()
+	builder := NewBuilder("Test", 1000)
+	glyph := builder.AddGlyph("a", 600, 'a')
+	glyph.MoveTo(100, 0)
+	glyph.LineTo(500, 0)
+	glyph.LineTo(500, 700)
+	glyph.LineTo(100, 700)
+	glyph.Close()
+	b, err := builder.WriteTrueType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfnt, err := ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contour, err := sfnt.Glyf.Contour(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numPoints := len(contour.XCoordinates) + 4 // including phantom points
+
+	fvar := NewBinaryWriter([]byte{})
+	fvar.WriteUint16(1)  // majorVersion
+	fvar.WriteUint16(0)  // minorVersion
+	fvar.WriteUint16(16) // axesArrayOffset
+	fvar.WriteUint16(2)  // reserved
+	fvar.WriteUint16(1)  // axisCount
+	fvar.WriteUint16(20) // axisSize
+	fvar.WriteUint16(0)  // instanceCount
+	fvar.WriteUint16(8)  // instanceSize
+	fvar.WriteString("wght")
+	fvar.WriteInt32(100 << 16) // minValue
+	fvar.WriteInt32(400 << 16) // defaultValue
+	fvar.WriteInt32(900 << 16) // maxValue
+	fvar.WriteUint16(0)        // flags
+	fvar.WriteUint16(256)      // axisNameID
+
+	// first tuple moves all points horizontally and the advance, second tuple moves point zero vertically
+	xDeltas, yDeltas := make([]int16, numPoints), make([]int16, numPoints)
+	for i := 0; i < numPoints-4; i++ {
+		xDeltas[i] = 50
+	}
+	xDeltas[numPoints-3] = 100 // advance phantom point
+	data := NewBinaryWriter([]byte{})
+	data.WriteByte(0) // all points
+	for _, deltas := range [][]int16{xDeltas, yDeltas} {
+		data.WriteByte(0x40 | byte(len(deltas)-1)) // run of words
+		for _, delta := range deltas {
+			data.WriteInt16(delta)
+		}
+	}
+	size1 := data.Len()
+	data.WriteByte(1) // one point
+	data.WriteByte(0) // one point number as byte
+	data.WriteByte(0) // point zero
+	data.WriteByte(0x40)
+	data.WriteInt16(0) // x delta
+	data.WriteByte(0x40)
+	data.WriteInt16(40) // y delta
+	size2 := data.Len() - size1
+
+	glyphData := NewBinaryWriter([]byte{})
+	glyphData.WriteUint16(2)       // tupleVariationCount
+	glyphData.WriteUint16(4 + 2*6) // dataOffset
+	glyphData.WriteUint16(uint16(size1))
+	glyphData.WriteUint16(0xA000) // EMBEDDED_PEAK_TUPLE, PRIVATE_POINT_NUMBERS
+	glyphData.WriteInt16(1 << 14) // peak
+	glyphData.WriteUint16(uint16(size2))
+	glyphData.WriteUint16(0xA000) // EMBEDDED_PEAK_TUPLE, PRIVATE_POINT_NUMBERS
+	glyphData.WriteInt16(1 << 14) // peak
+	glyphData.WriteBytes(data.Bytes())
+	if glyphData.Len()%2 != 0 {
+		glyphData.WriteByte(0)
+	}
+
+	gvar := NewBinaryWriter([]byte{})
+	gvar.WriteUint16(1)  // majorVersion
+	gvar.WriteUint16(0)  // minorVersion
+	gvar.WriteUint16(1)  // axisCount
+	gvar.WriteUint16(0)  // sharedTupleCount
+	gvar.WriteUint32(26) // sharedTuplesOffset
+	gvar.WriteUint16(2)  // glyphCount
+	gvar.WriteUint16(0)  // flags
+	gvar.WriteUint32(26) // glyphVariationDataArrayOffset
+	gvar.WriteUint16(0)
+	gvar.WriteUint16(0)
+	gvar.WriteUint16(uint16(glyphData.Len() / 2))
+	gvar.WriteBytes(glyphData.Bytes())
+
+	return addTables(t, b, map[string][]byte{
+		"fvar": fvar.Bytes(),
+		"gvar": gvar.Bytes(),
+	})
+}
+
+func TestInstance(t *testing.T) {
+	sfnt := testVariableFont(t)
+	orig, err := sfnt.Glyf.Contour(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		wght    float64
+		dx, dy  int16
+		advance uint16
+	}{
+		{400, 0, 0, 600},
+		{900, 50, 40, 700},
+		{650, 25, 20, 650},
+		{100, 0, 0, 600}, // no deltas below the default
+	}
+	for _, tt := range tests {
+		b, err := sfnt.Instance(map[string]float64{"wght": tt.wght})
+		if err != nil {
+			t.Fatal(tt.wght, err)
+		}
+		if report := Validate(b); !report.Valid() {
+			t.Fatal(tt.wght, report)
+		}
+		instance, err := ParseSFNT(b, 0)
+		if err != nil {
+			t.Fatal(tt.wght, err)
+		} else if instance.Fvar != nil || instance.HasTable("gvar") {
+			t.Fatal(tt.wght, "variation tables not dropped")
+		}
+
+		contour, err := instance.Glyf.Contour(1, 0)
+		if err != nil {
+			t.Fatal(tt.wght, err)
+		} else if len(contour.XCoordinates) != len(orig.XCoordinates) {
+			t.Fatal(tt.wght, "number of points changed")
+		}
+		for i := range orig.XCoordinates {
+			if contour.XCoordinates[i] != orig.XCoordinates[i]+tt.dx || contour.YCoordinates[i] != orig.YCoordinates[i]+tt.dy {
+				t.Fatalf("%v: point %d is (%d,%d), expected (%d,%d)", tt.wght, i, contour.XCoordinates[i], contour.YCoordinates[i], orig.XCoordinates[i]+tt.dx, orig.YCoordinates[i]+tt.dy)
+			}
+		}
+
+		if advance := instance.GlyphAdvance(1); advance != tt.advance {
+			t.Fatalf("%v: advance is %d, expected %d", tt.wght, advance, tt.advance)
+		}
+		xMin, yMin, xMax, yMax, err := instance.GlyphBounds(1)
+		if err != nil {
+			t.Fatal(tt.wght, err)
+		} else if xMin != orig.XMin+tt.dx || yMin != orig.YMin+tt.dy || xMax != orig.XMax+tt.dx || yMax != orig.YMax+tt.dy {
+			t.Fatalf("%v: bounds are (%d,%d,%d,%d)", tt.wght, xMin, yMin, xMax, yMax)
+		} else if lsb := instance.Hmtx.LeftSideBearing(1); lsb != xMin {
+			t.Fatalf("%v: left side bearing is %d, expected %d", tt.wght, lsb, xMin)
+		}
+		if tt.wght == 400 && instance.Hhea.AdvanceWidthMax != sfnt.Hhea.AdvanceWidthMax {
+			t.Fatal(tt.wght, "advanceWidthMax changed at the default location")
+		}
+	}
+}
@@ -0,0 +1,1210 @@
+package font
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// MergeOptions are the options for merging fonts.
+type MergeOptions struct {
+	UnitsPerEm uint16 // units per em of the merged font, zero uses that of the first font
+	FamilyName string // family name of the merged font, empty uses that of the first font
+}
+
+// Merge merges the fonts into a single font that contains the glyphs of all fonts in order, and returns the font file. Characters are mapped to the glyph of the first font that maps them, and the names and font-wide metrics are taken from the first font. The embedding permissions are the most restrictive of all fonts. Outlines, advances, kerning, and GPOS values are scaled to a common units per em. The GSUB and GPOS lookups of all fonts are kept, and the GDEF glyph classes, mark attachment classes, and mark glyph sets are merged. The merged font has TrueType outlines if all fonts have TrueType outlines and CFF outlines otherwise. Hinting, vertical metrics, and other tables are dropped.
+func Merge(fonts []*SFNT, opts MergeOptions) ([]byte, error) {
+	if len(fonts) == 0 {
+		return nil, fmt.Errorf("merge: no fonts")
+	}
+	first := fonts[0]
+	unitsPerEm := opts.UnitsPerEm
+	if unitsPerEm == 0 {
+		unitsPerEm = first.Head.UnitsPerEm
+	}
+
+	m := &fontMerger{
+		fonts:      fonts,
+		glyphBases: make([]uint16, len(fonts)),
+		scales:     make([]float64, len(fonts)),
+	}
+	isTrueType := true
+	numGlyphs := 0
+	for i, sfnt := range fonts {
+		if err := sfnt.CheckEmbedding(true); err != nil {
+			return nil, err
+		} else if !sfnt.IsTrueType && !sfnt.IsCFF {
+			return nil, fmt.Errorf("merge: only TrueType and CFF are supported")
+		}
+		isTrueType = isTrueType && sfnt.IsTrueType
+		m.glyphBases[i] = uint16(numGlyphs)
+		m.scales[i] = float64(unitsPerEm) / float64(sfnt.Head.UnitsPerEm)
+		numGlyphs += int(sfnt.NumGlyphs())
+		if math.MaxUint16 < numGlyphs {
+			return nil, fmt.Errorf("merge: too many glyphs")
+		}
+	}
+
+	familyName := first.NameString(NameFontFamily)
+	subfamilyName := first.NameString(NameFontSubfamily)
+	fullName := first.NameString(NameFull)
+	psName := first.NameString(NamePostScript)
+	if opts.FamilyName != "" {
+		familyName = opts.FamilyName
+		fullName = familyName + " " + subfamilyName
+		psName = postScriptName(familyName + "-" + subfamilyName)
+	}
+	b := &sfntBuilder{
+		PostScriptName: psName,
+		FamilyName:     familyName,
+		SubfamilyName:  subfamilyName,
+		FullName:       fullName,
+		Version:        first.NameString(NameVersion),
+		Copyright:      first.NameString(NameCopyrightNotice),
+		UnitsPerEm:     unitsPerEm,
+		Ascender:       scaleInt16(first.Hhea.Ascender, m.scales[0]),
+		Descender:      scaleInt16(first.Hhea.Descender, m.scales[0]),
+		LineGap:        scaleInt16(first.Hhea.LineGap, m.scales[0]),
+		Glyphs:         make([]sfntBuilderGlyph, 0, numGlyphs),
+	}
+	if first.OS2 != nil {
+		b.CapHeight = scaleInt16(first.OS2.SCapHeight, m.scales[0])
+		b.XHeight = scaleInt16(first.OS2.SxHeight, m.scales[0])
+	}
+	if first.Post != nil {
+		b.ItalicAngle = float64(int32(first.Post.ItalicAngle)) / (1 << 16)
+		b.UnderlinePosition = scaleInt16(first.Post.UnderlinePosition, m.scales[0])
+		b.UnderlineThickness = scaleInt16(first.Post.UnderlineThickness, m.scales[0])
+		b.IsFixedPitch = first.Post.IsFixedPitch != 0
+	}
+
+	// glyphs
+	names := map[string]bool{}
+	for i, sfnt := range fonts {
+		for glyphID := uint16(0); glyphID < sfnt.NumGlyphs(); glyphID++ {
+			glyph, err := m.glyph(i, glyphID, isTrueType)
+			if err != nil {
+				return nil, fmt.Errorf("merge: font %v: %w", i, err)
+			}
+			glyph.Name = sfnt.GlyphName(glyphID)
+			if len(b.Glyphs) == 0 {
+				glyph.Name = ".notdef"
+			} else if glyph.Name == "" || glyph.Name == ".notdef" || names[glyph.Name] {
+				glyph.Name = "glyph" + strconv.Itoa(len(b.Glyphs))
+				for k := 1; names[glyph.Name]; k++ {
+					glyph.Name = "glyph" + strconv.Itoa(len(b.Glyphs)) + "." + strconv.Itoa(k)
+				}
+			}
+			names[glyph.Name] = true
+			b.Glyphs = append(b.Glyphs, glyph)
+		}
+	}
+
+	// character map, the first font that maps a rune takes precedence
+	mapped := map[rune]bool{}
+	for i, sfnt := range fonts {
+		if sfnt.Cmap == nil {
+			continue
+		}
+		for _, r := range sfnt.Cmap.Runes() {
+			if !mapped[r] {
+				glyphID := m.glyphBases[i] + sfnt.Cmap.Get(r)
+				b.Glyphs[glyphID].Runes = append(b.Glyphs[glyphID].Runes, r)
+				mapped[r] = true
+			}
+		}
+	}
+
+	// kerning
+	kerning := map[uint32]float64{}
+	for i, sfnt := range fonts {
+		if sfnt.Kern == nil {
+			continue
+		}
+		for _, subtable := range sfnt.Kern.Subtables {
+			if subtable.Coverage[1] { // minimum values
+				continue
+			}
+			for _, pair := range subtable.Pairs {
+				left, right := m.glyphBases[i]+uint16(pair.Key>>16), m.glyphBases[i]+uint16(pair.Key)
+				kerning[uint32(left)<<16|uint32(right)] += float64(pair.Value) * m.scales[i]
+			}
+		}
+	}
+	for key, value := range kerning {
+		if value := math.Round(value); value != 0.0 {
+			b.Kerning = append(b.Kerning, kernPair{
+				Key:   key,
+				Value: int16(math.Max(math.MinInt16, math.Min(value, math.MaxInt16))),
+			})
+		}
+	}
+
+	// outlines and metrics
+	var tables map[string][]byte
+	if isTrueType {
+		tables = b.trueTypeTables()
+	} else {
+		tables = b.tables()
+		tables["CFF "] = b.cff()
+	}
+	if head, err := first.Table("head"); err == nil && 46 <= len(head) {
+		copy(tables["head"][44:46], head[44:46]) // macStyle
+	}
+	if os2, err := first.Table("OS/2"); err == nil && 64 <= len(os2) {
+		copy(tables["OS/2"][4:8], os2[4:8])     // usWeightClass, usWidthClass
+		copy(tables["OS/2"][30:42], os2[30:42]) // sFamilyClass, panose
+		copy(tables["OS/2"][58:64], os2[58:64]) // achVendID, fsSelection
+	}
+	binary.BigEndian.PutUint16(tables["OS/2"][8:], mergeFsType(fonts))
+
+	// layout
+	gdef, err := m.gdef()
+	if err != nil {
+		return nil, err
+	} else if gdef != nil {
+		tables["GDEF"] = gdef
+	}
+	for _, tag := range []string{"GSUB", "GPOS"} {
+		layout, err := m.layout(tag)
+		if err != nil {
+			return nil, err
+		} else if layout != nil {
+			tables[tag] = layout
+		}
+	}
+
+	sfnt := &SFNT{
+		IsTrueType: isTrueType,
+		IsCFF:      !isTrueType,
+		Tables:     tables,
+	}
+	return sfnt.Write(), nil
+}
+
+// fontMerger holds the glyph ID offsets and scale factors of the fonts being merged.
+type fontMerger struct {
+	fonts          []*SFNT
+	glyphBases     []uint16  // offset of the glyph IDs of each font
+	scales         []float64 // scale factor from the units per em of each font
+	markClassBases []uint16  // offset of the mark attachment classes of each font
+	markSetBases   []uint16  // offset of the mark glyph set indices of each font
+}
+
+// glyph returns the scaled outline and metrics of a glyph of the i-th font, either as TrueType glyph data or a Type 2 charstring.
+// mergeFsType returns the fsType with the most restrictive embedding permissions of all fonts.
+func mergeFsType(fonts []*SFNT) uint16 {
+	// usage permissions from least to most restrictive: installable, editable, preview & print, restricted
+	usages := []uint16{0x0000, 0x0008, 0x0004, 0x0002}
+	usage, flags := 0, uint16(0)
+	for _, sfnt := range fonts {
+		if sfnt.OS2 == nil {
+			continue
+		}
+		fsType := sfnt.OS2.FsType
+		i := 0 // the least restrictive usage permission that is set takes precedence
+		if fsType&0x0008 != 0 {
+			i = 1
+		} else if fsType&0x0004 != 0 {
+			i = 2
+		} else if fsType&0x0002 != 0 {
+			i = 3
+		}
+		if usage < i {
+			usage = i
+		}
+		flags |= fsType & 0x0300 // no subsetting, bitmap embedding only
+	}
+	return usages[usage] | flags
+}
+
+func (m *fontMerger) glyph(i int, glyphID uint16, isTrueType bool) (sfntBuilderGlyph, error) {
+	sfnt, scale := m.fonts[i], m.scales[i]
+	advance := math.Round(float64(sfnt.GlyphAdvance(glyphID)) * scale)
+	glyph := sfntBuilderGlyph{
+		Advance: uint16(math.Max(0.0, math.Min(advance, math.MaxUint16))),
+	}
+	if isTrueType {
+		b := sfnt.Glyf.Get(glyphID)
+		if b == nil {
+			return glyph, fmt.Errorf("glyf: bad glyphID %v", glyphID)
+		} else if len(b) == 0 {
+			return glyph, nil
+		} else if len(b) < 10 {
+			return glyph, fmt.Errorf("glyf: bad table for glyphID %v", glyphID)
+		}
+
+		if 0 <= int16(binary.BigEndian.Uint16(b)) {
+			contour, err := sfnt.Glyf.Contour(glyphID, 0)
+			if err != nil {
+				return glyph, err
+			}
+			for j := range contour.XCoordinates {
+				contour.XCoordinates[j] = scaleInt16(contour.XCoordinates[j], scale)
+				contour.YCoordinates[j] = scaleInt16(contour.YCoordinates[j], scale)
+			}
+			contour.Instructions = nil
+			glyph.Glyf = writeGlyfSimple(contour)
+			glyph.XMin, glyph.YMin, glyph.XMax, glyph.YMax = glyfPointsBounds(contour)
+		} else {
+			components, err := parseGlyfComponents(b)
+			if err != nil {
+				return glyph, fmt.Errorf("glyf: glyphID %v: %w", glyphID, err)
+			}
+			for j := range components {
+				components[j].flags &^= 0x0100 // WE_HAVE_INSTRUCTIONS
+				components[j].glyphID += m.glyphBases[i]
+				if components[j].flags&0x0002 != 0 { // ARGS_ARE_XY_VALUES
+					components[j].dx = scaleInt16(components[j].dx, scale)
+					components[j].dy = scaleInt16(components[j].dy, scale)
+				}
+			}
+			glyph.Glyf = writeGlyfComposite(b, components) // bounds are recalculated by the builder
+		}
+		return glyph, nil
+	}
+
+	bbox := &bboxPather{}
+	if err := sfnt.GlyphPath(bbox, glyphID, 0, 0, 0, scale, NoHinting); err != nil {
+		return glyph, err
+	}
+	cs := newType2CharString(float64(glyph.Advance))
+	if err := sfnt.GlyphPath(cs, glyphID, 0, 0, 0, scale, NoHinting); err != nil {
+		return glyph, err
+	}
+	glyph.CharString = cs.Bytes()
+	if bbox.hasPoints {
+		glyph.XMin = int16(math.Floor(bbox.xMin))
+		glyph.YMin = int16(math.Floor(bbox.yMin))
+		glyph.XMax = int16(math.Ceil(bbox.xMax))
+		glyph.YMax = int16(math.Ceil(bbox.yMax))
+	}
+	return glyph, nil
+}
+
+// gdef merges the GDEF tables, it returns nil if no font has a GDEF table. Mark attachment classes and mark glyph sets of each font are offset so that they don't collide, the attachment point and ligature caret lists are dropped.
+func (m *fontMerger) gdef() ([]byte, error) {
+	m.markClassBases = make([]uint16, len(m.fonts))
+	m.markSetBases = make([]uint16, len(m.fonts))
+
+	hasGDEF := false
+	glyphClasses := map[uint16]uint16{}
+	markClasses := map[uint16]uint16{}
+	markSets := [][]uint16{}
+	markClassBase := uint16(0)
+	for i, sfnt := range m.fonts {
+		m.markClassBases[i] = markClassBase
+		m.markSetBases[i] = uint16(len(markSets))
+		if !sfnt.HasTable("GDEF") {
+			continue
+		}
+		b, err := sfnt.Table("GDEF")
+		if err != nil {
+			return nil, err
+		} else if len(b) < 12 {
+			return nil, fmt.Errorf("GDEF: bad table")
+		}
+		hasGDEF = true
+
+		minorVersion := binary.BigEndian.Uint16(b[2:])
+		if offset := binary.BigEndian.Uint16(b[4:]); offset != 0 {
+			classes, err := classDefGlyphs(b, uint32(offset))
+			if err != nil {
+				return nil, fmt.Errorf("GDEF: %w", err)
+			}
+			for glyphID, class := range classes {
+				glyphClasses[m.glyphBases[i]+glyphID] = class
+			}
+		}
+		if offset := binary.BigEndian.Uint16(b[10:]); offset != 0 {
+			classes, err := classDefGlyphs(b, uint32(offset))
+			if err != nil {
+				return nil, fmt.Errorf("GDEF: %w", err)
+			}
+			maxClass := uint16(0)
+			for glyphID, class := range classes {
+				if math.MaxUint8 < int(markClassBase)+int(class) {
+					return nil, fmt.Errorf("merge: too many mark attachment classes")
+				}
+				markClasses[m.glyphBases[i]+glyphID] = markClassBase + class
+				if maxClass < class {
+					maxClass = class
+				}
+			}
+			markClassBase += maxClass
+		}
+		if 2 <= minorVersion && 14 <= len(b) {
+			if offset := uint32(binary.BigEndian.Uint16(b[12:])); offset != 0 {
+				if uint32(len(b)) < offset+4 {
+					return nil, fmt.Errorf("GDEF: bad table")
+				}
+				markGlyphSetCount := uint32(binary.BigEndian.Uint16(b[offset+2:]))
+				if uint32(len(b)) < offset+4+4*markGlyphSetCount {
+					return nil, fmt.Errorf("GDEF: bad table")
+				}
+				for j := uint32(0); j < markGlyphSetCount; j++ {
+					glyphIDs, err := coverageGlyphs(b, offset+binary.BigEndian.Uint32(b[offset+4+4*j:]))
+					if err != nil {
+						return nil, fmt.Errorf("GDEF: %w", err)
+					}
+					for k := range glyphIDs {
+						glyphIDs[k] += m.glyphBases[i]
+					}
+					markSets = append(markSets, glyphIDs)
+				}
+			}
+		}
+	}
+	if !hasGDEF {
+		return nil, nil
+	}
+
+	headerLength := uint16(12)
+	if 0 < len(markSets) {
+		headerLength = 14
+	}
+	glyphClassDef := writeClassDef(glyphClasses)
+	markClassDef := writeClassDef(markClasses)
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(1) // majorVersion
+	if 0 < len(markSets) {
+		w.WriteUint16(2) // minorVersion
+	} else {
+		w.WriteUint16(0) // minorVersion
+	}
+	w.WriteUint16(headerLength)                              // glyphClassDefOffset
+	w.WriteUint16(0)                                         // attachListOffset
+	w.WriteUint16(0)                                         // ligCaretListOffset
+	w.WriteUint16(headerLength + uint16(len(glyphClassDef))) // markAttachClassDefOffset
+	if 0 < len(markSets) {
+		offset := uint32(headerLength) + uint32(len(glyphClassDef)) + uint32(len(markClassDef))
+		if math.MaxUint16 < offset {
+			return nil, fmt.Errorf("merge: GDEF table too large")
+		}
+		w.WriteUint16(uint16(offset)) // markGlyphSetsDefOffset
+	}
+	w.WriteBytes(glyphClassDef)
+	w.WriteBytes(markClassDef)
+	if 0 < len(markSets) {
+		w.WriteUint16(1) // format
+		w.WriteUint16(uint16(len(markSets)))
+		offset := 4 + 4*uint32(len(markSets))
+		coverages := make([][]byte, len(markSets))
+		for j, glyphIDs := range markSets {
+			coverages[j] = writeCoverage(glyphIDs)
+			w.WriteUint32(offset) // coverageOffset
+			offset += uint32(len(coverages[j]))
+		}
+		for _, coverage := range coverages {
+			w.WriteBytes(coverage)
+		}
+	}
+	return w.Bytes(), nil
+}
+
+// layout merges the GSUB or GPOS tables, it returns nil if no font has the table. The script lists are merged, and the features and lookups of all fonts are concatenated. Each lookup is written as an extension lookup that points into a copy of the font's original table, in which glyph IDs, nested lookup indices, and GPOS design units are patched in place. Feature variations are dropped.
+func (m *fontMerger) layout(tag string) ([]byte, error) {
+	extensionType := uint16(7)
+	if tag == "GPOS" {
+		extensionType = 9
+	}
+
+	type feature struct {
+		tag     FeatureTag
+		lookups []uint16
+		font    int
+		index   uint16
+	}
+	type mergedLookup struct {
+		lookupType       uint16
+		lookupFlag       uint16
+		markFilteringSet uint16
+		subtables        []uint32 // offsets into the copy of the original table
+		font             int
+	}
+
+	hasTable := false
+	scripts := make([]scriptList, len(m.fonts))
+	features := []feature{}
+	lookups := []mergedLookup{}
+	tables := make([][]byte, len(m.fonts))
+	for i, sfnt := range m.fonts {
+		lookupBase := len(lookups)
+		if !sfnt.HasTable(tag) {
+			continue
+		}
+		b, err := sfnt.Table(tag)
+		if err != nil {
+			return nil, err
+		} else if len(b) < 10 {
+			return nil, fmt.Errorf("%v: bad table", tag)
+		}
+		hasTable = true
+		b = append([]byte{}, b...)
+		tables[i] = b
+
+		scriptListOffset := binary.BigEndian.Uint16(b[4:])
+		featureListOffset := binary.BigEndian.Uint16(b[6:])
+		lookupListOffset := uint32(binary.BigEndian.Uint16(b[8:]))
+		if len(b)-2 < int(scriptListOffset) || len(b)-2 < int(featureListOffset) || len(b)-2 < int(lookupListOffset) {
+			return nil, fmt.Errorf("%v: bad table", tag)
+		}
+		if scripts[i], err = sfnt.parseScriptList(b[scriptListOffset:]); err != nil {
+			return nil, fmt.Errorf("%v: %w", tag, err)
+		}
+		featureList := sfnt.parseFeatureList(b[featureListOffset:])
+
+		// lookups, extension lookups are resolved to point to their subtables directly
+		r := NewBinaryReader(b)
+		r.Seek(lookupListOffset)
+		lookupCount := r.ReadUint16()
+		if math.MaxUint16 < lookupBase+int(lookupCount) {
+			return nil, fmt.Errorf("merge: too many %v lookups", tag)
+		}
+		for j := uint16(0); j < lookupCount; j++ {
+			r.Seek(lookupListOffset + 2 + 2*uint32(j))
+			lookupOffset := lookupListOffset + uint32(r.ReadUint16())
+			r.Seek(lookupOffset)
+			lookup := mergedLookup{
+				lookupType: r.ReadUint16(),
+				lookupFlag: r.ReadUint16(),
+				font:       i,
+			}
+			subtableCount := r.ReadUint16()
+			for k := uint16(0); k < subtableCount; k++ {
+				lookup.subtables = append(lookup.subtables, lookupOffset+uint32(r.ReadUint16()))
+			}
+			if lookup.lookupFlag&0x0010 != 0 { // USE_MARK_FILTERING_SET
+				lookup.markFilteringSet = r.ReadUint16() + m.markSetBases[i]
+			}
+			if r.EOF() {
+				return nil, fmt.Errorf("%v: bad lookup", tag)
+			}
+			if lookup.lookupType == extensionType {
+				for k, offset := range lookup.subtables {
+					if uint32(len(b)) < offset+8 {
+						return nil, fmt.Errorf("%v: bad extension subtable", tag)
+					}
+					lookup.lookupType = binary.BigEndian.Uint16(b[offset+2:])
+					lookup.subtables[k] = offset + binary.BigEndian.Uint32(b[offset+4:])
+				}
+			}
+			if markAttachmentType := lookup.lookupFlag >> 8; markAttachmentType != 0 {
+				lookup.lookupFlag = lookup.lookupFlag&0x00FF | (markAttachmentType+m.markClassBases[i])<<8
+			}
+			lookups = append(lookups, lookup)
+		}
+
+		for j := range featureList.tag {
+			indices := make([]uint16, 0, len(featureList.feature[j]))
+			for _, index := range featureList.feature[j] {
+				if index < lookupCount {
+					indices = append(indices, uint16(lookupBase)+index)
+				}
+			}
+			features = append(features, feature{featureList.tag[j], indices, i, uint16(j)})
+		}
+
+		// patch the glyph IDs, lookup indices, and design units of the subtables
+		if m.glyphBases[i] != 0 || lookupBase != 0 || m.scales[i] != 1.0 {
+			p := &layoutPatcher{
+				b:          b,
+				gpos:       tag == "GPOS",
+				glyphBase:  m.glyphBases[i],
+				lookupBase: uint16(lookupBase),
+				scale:      m.scales[i],
+				patched:    map[uint32]bool{},
+			}
+			for _, lookup := range lookups[lookupBase:] {
+				for _, offset := range lookup.subtables {
+					p.subtable(lookup.lookupType, offset)
+				}
+			}
+			if p.err != nil {
+				return nil, fmt.Errorf("%v: %w", tag, p.err)
+			}
+		}
+	}
+	if !hasTable {
+		return nil, nil
+	} else if math.MaxUint16 < len(features) {
+		return nil, fmt.Errorf("merge: too many %v features", tag)
+	}
+
+	// features sorted by tag
+	sort.SliceStable(features, func(i, j int) bool { return features[i].tag < features[j].tag })
+	featureIndices := make([]map[uint16]uint16, len(m.fonts))
+	for i := range featureIndices {
+		featureIndices[i] = map[uint16]uint16{}
+	}
+	for index, feature := range features {
+		featureIndices[feature.font][feature.index] = uint16(index)
+	}
+
+	// script list, fonts without a script use their default script
+	scriptTags := []ScriptTag{}
+	for _, fontScriptList := range scripts {
+		for scriptTag := range fontScriptList {
+			scriptTags = append(scriptTags, scriptTag)
+		}
+	}
+	sort.Slice(scriptTags, func(i, j int) bool { return scriptTags[i] < scriptTags[j] })
+	scriptList := NewBinaryWriter([]byte{})
+	scriptRecords := NewBinaryWriter([]byte{})
+	scriptTables := NewBinaryWriter([]byte{})
+	numScripts := 0
+	for k, scriptTag := range scriptTags {
+		if 0 < k && scriptTags[k-1] == scriptTag {
+			continue
+		}
+		fontScripts := make([]map[LanguageTag]langSys, len(m.fonts))
+		languageTags := []LanguageTag{}
+		for i := range m.fonts {
+			script, ok := scripts[i][scriptTag]
+			if !ok {
+				script = scripts[i][DefaultScript]
+			}
+			fontScripts[i] = script
+			for languageTag := range script {
+				if languageTag != DefaultLanguage {
+					languageTags = append(languageTags, languageTag)
+				}
+			}
+		}
+		sort.Slice(languageTags, func(i, j int) bool { return languageTags[i] < languageTags[j] })
+		for i := 1; i < len(languageTags); i++ {
+			if languageTags[i-1] == languageTags[i] {
+				languageTags = append(languageTags[:i], languageTags[i+1:]...)
+				i--
+			}
+		}
+
+		// language systems, the default language system is written first
+		langSysTables := make([][]byte, len(languageTags)+1)
+		for j := range langSysTables {
+			languageTag := DefaultLanguage
+			if 0 < j {
+				languageTag = languageTags[j-1]
+			}
+			requiredFeatureIndex := uint16(0xFFFF)
+			indices := []uint16{}
+			for i, script := range fontScripts {
+				langSys, ok := script[languageTag]
+				if !ok {
+					if langSys, ok = script[DefaultLanguage]; !ok {
+						continue
+					}
+				}
+				if index, ok := featureIndices[i][langSys.requiredFeatureIndex]; ok && langSys.requiredFeatureIndex != 0xFFFF {
+					if requiredFeatureIndex == 0xFFFF {
+						requiredFeatureIndex = index
+					} else {
+						indices = append(indices, index)
+					}
+				}
+				for _, featureIndex := range langSys.featureIndices {
+					if index, ok := featureIndices[i][featureIndex]; ok {
+						indices = append(indices, index)
+					}
+				}
+			}
+			sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+			w := NewBinaryWriter([]byte{})
+			w.WriteUint16(0)                    // lookupOrderOffset
+			w.WriteUint16(requiredFeatureIndex) // requiredFeatureIndex
+			w.WriteUint16(uint16(len(indices))) // featureIndexCount
+			for _, index := range indices {
+				w.WriteUint16(index)
+			}
+			langSysTables[j] = w.Bytes()
+		}
+
+		script := NewBinaryWriter([]byte{})
+		offset := 4 + 6*uint32(len(languageTags))
+		script.WriteUint16(uint16(offset)) // defaultLangSysOffset
+		script.WriteUint16(uint16(len(languageTags)))
+		offset += uint32(len(langSysTables[0]))
+		for j, languageTag := range languageTags {
+			script.WriteString(string(languageTag))
+			script.WriteUint16(uint16(offset)) // langSysOffset
+			offset += uint32(len(langSysTables[j+1]))
+		}
+		for _, langSys := range langSysTables {
+			script.WriteBytes(langSys)
+		}
+		if math.MaxUint16 < offset {
+			return nil, fmt.Errorf("merge: %v script list too large", tag)
+		}
+
+		scriptRecords.WriteString(string(scriptTag))
+		scriptRecords.WriteUint16(uint16(scriptTables.Len())) // patched below
+		scriptTables.WriteBytes(script.Bytes())
+		numScripts++
+	}
+	scriptList.WriteUint16(uint16(numScripts))
+	records := scriptRecords.Bytes()
+	for k := 0; k < numScripts; k++ {
+		offset := 2 + 6*uint32(numScripts) + uint32(binary.BigEndian.Uint16(records[6*k+4:]))
+		if math.MaxUint16 < offset {
+			return nil, fmt.Errorf("merge: %v script list too large", tag)
+		}
+		binary.BigEndian.PutUint16(records[6*k+4:], uint16(offset))
+	}
+	scriptList.WriteBytes(records)
+	scriptList.WriteBytes(scriptTables.Bytes())
+
+	// feature list
+	featureList := NewBinaryWriter([]byte{})
+	featureList.WriteUint16(uint16(len(features)))
+	offset := 2 + 6*uint32(len(features))
+	for _, feature := range features {
+		featureList.WriteString(string(feature.tag))
+		featureList.WriteUint16(uint16(offset)) // featureOffset
+		offset += 4 + 2*uint32(len(feature.lookups))
+		if math.MaxUint16 < offset {
+			return nil, fmt.Errorf("merge: %v feature list too large", tag)
+		}
+	}
+	for _, feature := range features {
+		featureList.WriteUint16(0) // featureParamsOffset
+		featureList.WriteUint16(uint16(len(feature.lookups)))
+		for _, index := range feature.lookups {
+			featureList.WriteUint16(index)
+		}
+	}
+
+	// lookup list, each lookup is followed by its extension subtables
+	lookupListOffset := 10 + uint32(scriptList.Len()) + uint32(featureList.Len())
+	if math.MaxUint16 < lookupListOffset {
+		return nil, fmt.Errorf("merge: %v table too large", tag)
+	}
+	lookupOffsets := make([]uint32, len(lookups))
+	offset = 2 + 2*uint32(len(lookups))
+	for j, lookup := range lookups {
+		lookupOffsets[j] = offset
+		offset += 6 + 2*uint32(len(lookup.subtables)) + 8*uint32(len(lookup.subtables))
+		if lookup.lookupFlag&0x0010 != 0 {
+			offset += 2
+		}
+		if math.MaxUint16 < lookupOffsets[j] {
+			return nil, fmt.Errorf("merge: too many %v lookups", tag)
+		}
+	}
+	tableOffsets := make([]uint32, len(m.fonts))
+	offset += lookupListOffset
+	for i, b := range tables {
+		tableOffsets[i] = offset
+		offset += uint32(len(b))
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(1)                             // majorVersion
+	w.WriteUint16(0)                             // minorVersion
+	w.WriteUint16(10)                            // scriptListOffset
+	w.WriteUint16(10 + uint16(scriptList.Len())) // featureListOffset
+	w.WriteUint16(uint16(lookupListOffset))      // lookupListOffset
+	w.WriteBytes(scriptList.Bytes())
+	w.WriteBytes(featureList.Bytes())
+	w.WriteUint16(uint16(len(lookups))) // lookupCount
+	for _, lookupOffset := range lookupOffsets {
+		w.WriteUint16(uint16(lookupOffset))
+	}
+	for j, lookup := range lookups {
+		n := uint32(len(lookup.subtables))
+		headerLength := 6 + 2*n
+		if lookup.lookupFlag&0x0010 != 0 {
+			headerLength += 2
+		}
+		w.WriteUint16(extensionType)
+		w.WriteUint16(lookup.lookupFlag)
+		w.WriteUint16(uint16(n))
+		for k := uint32(0); k < n; k++ {
+			w.WriteUint16(uint16(headerLength + 8*k)) // subtableOffset
+		}
+		if lookup.lookupFlag&0x0010 != 0 {
+			w.WriteUint16(lookup.markFilteringSet)
+		}
+		for k, subtable := range lookup.subtables {
+			extensionOffset := lookupListOffset + lookupOffsets[j] + headerLength + 8*uint32(k)
+			w.WriteUint16(1) // format
+			w.WriteUint16(lookup.lookupType)
+			w.WriteUint32(tableOffsets[lookup.font] + subtable - extensionOffset)
+		}
+	}
+	for _, b := range tables {
+		w.WriteBytes(b)
+	}
+	return w.Bytes(), nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// layoutPatcher patches a copy of a GSUB or GPOS table in place for use in a merged font. Glyph IDs are offset, the lookup indices of nested lookups are offset, and for GPOS the placements, advances, and anchor coordinates are scaled. Since subtables may share data, each position is patched at most once.
+type layoutPatcher struct {
+	b          []byte
+	gpos       bool
+	glyphBase  uint16
+	lookupBase uint16
+	scale      float64
+	patched    map[uint32]bool
+	err        error
+}
+
+func (p *layoutPatcher) uint16(pos uint32) uint16 {
+	if uint32(len(p.b)) < pos+2 || pos+2 < pos {
+		if p.err == nil {
+			p.err = fmt.Errorf("bad subtable")
+		}
+		return 0
+	}
+	return binary.BigEndian.Uint16(p.b[pos:])
+}
+
+func (p *layoutPatcher) offset(base, pos uint32) (uint32, bool) {
+	offset := p.uint16(pos)
+	return base + uint32(offset), offset != 0 && p.err == nil
+}
+
+func (p *layoutPatcher) patch(pos uint32, f func(uint16) uint16) {
+	v := p.uint16(pos)
+	if p.err != nil || p.patched[pos] {
+		return
+	}
+	p.patched[pos] = true
+	binary.BigEndian.PutUint16(p.b[pos:], f(v))
+}
+
+func (p *layoutPatcher) glyphID(pos uint32) {
+	p.patch(pos, func(glyphID uint16) uint16 { return glyphID + p.glyphBase })
+}
+
+func (p *layoutPatcher) glyphIDs(pos uint32, n uint32) {
+	for i := uint32(0); i < n && p.err == nil; i++ {
+		p.glyphID(pos + 2*i)
+	}
+}
+
+func (p *layoutPatcher) coord(pos uint32) {
+	if p.scale != 1.0 {
+		p.patch(pos, func(v uint16) uint16 { return uint16(scaleInt16(int16(v), p.scale)) })
+	}
+}
+
+func (p *layoutPatcher) coverage(pos uint32) {
+	if p.err != nil {
+		return
+	}
+	switch p.uint16(pos) {
+	case 1:
+		p.glyphIDs(pos+4, uint32(p.uint16(pos+2)))
+	case 2:
+		for i := uint32(0); i < uint32(p.uint16(pos+2)) && p.err == nil; i++ {
+			p.glyphIDs(pos+4+6*i, 2) // startGlyphID, endGlyphID
+		}
+	default:
+		p.err = fmt.Errorf("bad coverage table")
+	}
+}
+
+func (p *layoutPatcher) classDef(pos uint32) {
+	if p.err != nil {
+		return
+	}
+	switch p.uint16(pos) {
+	case 1:
+		p.glyphID(pos + 2)
+	case 2:
+		for i := uint32(0); i < uint32(p.uint16(pos+2)) && p.err == nil; i++ {
+			p.glyphIDs(pos+4+6*i, 2) // startGlyphID, endGlyphID
+		}
+	default:
+		p.err = fmt.Errorf("bad class definition table")
+	}
+}
+
+// valueRecord patches a value record and returns its length.
+func (p *layoutPatcher) valueRecord(pos uint32, valueFormat uint16) uint32 {
+	n := uint32(0)
+	for bit := uint16(0x0001); bit <= 0x0080; bit <<= 1 {
+		if valueFormat&bit != 0 {
+			if bit <= 0x0008 { // XPlacement, YPlacement, XAdvance, YAdvance
+				p.coord(pos + 2*n)
+			}
+			n++
+		}
+	}
+	return 2 * n
+}
+
+func (p *layoutPatcher) anchor(pos uint32) {
+	if p.uint16(pos) < 1 || 3 < p.uint16(pos) {
+		if p.err == nil {
+			p.err = fmt.Errorf("bad anchor table")
+		}
+		return
+	}
+	p.coord(pos + 2) // xCoordinate
+	p.coord(pos + 4) // yCoordinate
+}
+
+// offsets calls f for each non-null offset in an array of n offsets at pos, the offsets are relative to base.
+func (p *layoutPatcher) offsets(base, pos, n uint32, f func(uint32)) {
+	for i := uint32(0); i < n && p.err == nil; i++ {
+		if offset, ok := p.offset(base, pos+2*i); ok {
+			f(offset)
+		}
+	}
+}
+
+// lookupRecords patches the lookup indices of n sequence lookup records at pos.
+func (p *layoutPatcher) lookupRecords(pos, n uint32) {
+	for i := uint32(0); i < n && p.err == nil; i++ {
+		p.patch(pos+4*i+2, func(index uint16) uint16 { return index + p.lookupBase })
+	}
+}
+
+func (p *layoutPatcher) subtable(lookupType uint16, pos uint32) {
+	if p.err != nil {
+		return
+	}
+	if p.gpos {
+		p.gposSubtable(lookupType, pos)
+	} else {
+		p.gsubSubtable(lookupType, pos)
+	}
+}
+
+func (p *layoutPatcher) gsubSubtable(lookupType uint16, pos uint32) {
+	format := p.uint16(pos)
+	switch lookupType {
+	case 1: // single
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		if format == 2 {
+			p.glyphIDs(pos+6, uint32(p.uint16(pos+4)))
+		}
+	case 2, 3: // multiple, alternate
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		p.offsets(pos, pos+6, uint32(p.uint16(pos+4)), func(seq uint32) {
+			p.glyphIDs(seq+2, uint32(p.uint16(seq)))
+		})
+	case 4: // ligature
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		p.offsets(pos, pos+6, uint32(p.uint16(pos+4)), func(set uint32) {
+			p.offsets(set, set+2, uint32(p.uint16(set)), func(lig uint32) {
+				p.glyphID(lig)
+				if n := uint32(p.uint16(lig + 2)); 1 < n {
+					p.glyphIDs(lig+4, n-1)
+				}
+			})
+		})
+	case 5: // context
+		p.context(pos)
+	case 6: // chained context
+		p.chainedContext(pos)
+	case 8: // reverse chained context
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		offset := pos + 4
+		for i := 0; i < 2; i++ { // backtrack and lookahead coverages
+			n := uint32(p.uint16(offset))
+			p.offsets(pos, offset+2, n, p.coverage)
+			offset += 2 + 2*n
+		}
+		p.glyphIDs(offset+2, uint32(p.uint16(offset)))
+	default:
+		p.err = fmt.Errorf("bad lookup type %v", lookupType)
+	}
+}
+
+func (p *layoutPatcher) gposSubtable(lookupType uint16, pos uint32) {
+	format := p.uint16(pos)
+	switch lookupType {
+	case 1: // single
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		valueFormat := p.uint16(pos + 4)
+		if format == 1 {
+			p.valueRecord(pos+6, valueFormat)
+		} else {
+			offset := pos + 8
+			for i := uint32(0); i < uint32(p.uint16(pos+6)) && p.err == nil; i++ {
+				offset += p.valueRecord(offset, valueFormat)
+			}
+		}
+	case 2: // pair
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		valueFormat1, valueFormat2 := p.uint16(pos+4), p.uint16(pos+6)
+		if format == 1 {
+			p.offsets(pos, pos+10, uint32(p.uint16(pos+8)), func(set uint32) {
+				offset := set + 2
+				for i := uint32(0); i < uint32(p.uint16(set)) && p.err == nil; i++ {
+					p.glyphID(offset)
+					offset += 2
+					offset += p.valueRecord(offset, valueFormat1)
+					offset += p.valueRecord(offset, valueFormat2)
+				}
+			})
+		} else {
+			if classDef, ok := p.offset(pos, pos+8); ok {
+				p.classDef(classDef)
+			}
+			if classDef, ok := p.offset(pos, pos+10); ok {
+				p.classDef(classDef)
+			}
+			n := uint32(p.uint16(pos+12)) * uint32(p.uint16(pos+14))
+			offset := pos + 16
+			for i := uint32(0); i < n && p.err == nil; i++ {
+				offset += p.valueRecord(offset, valueFormat1)
+				offset += p.valueRecord(offset, valueFormat2)
+			}
+		}
+	case 3: // cursive
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		p.offsets(pos, pos+6, 2*uint32(p.uint16(pos+4)), p.anchor)
+	case 4, 5, 6: // mark-to-base, mark-to-ligature, mark-to-mark
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		if cov, ok := p.offset(pos, pos+4); ok {
+			p.coverage(cov)
+		}
+		markClassCount := uint32(p.uint16(pos + 6))
+		if markArray, ok := p.offset(pos, pos+8); ok {
+			n := uint32(p.uint16(markArray))
+			for i := uint32(0); i < n && p.err == nil; i++ {
+				if anchor, ok := p.offset(markArray, markArray+2+4*i+2); ok {
+					p.anchor(anchor)
+				}
+			}
+		}
+		if baseArray, ok := p.offset(pos, pos+10); ok {
+			if lookupType == 5 {
+				p.offsets(baseArray, baseArray+2, uint32(p.uint16(baseArray)), func(attach uint32) {
+					p.offsets(attach, attach+2, uint32(p.uint16(attach))*markClassCount, p.anchor)
+				})
+			} else {
+				p.offsets(baseArray, baseArray+2, uint32(p.uint16(baseArray))*markClassCount, p.anchor)
+			}
+		}
+	case 7: // context
+		p.context(pos)
+	case 8: // chained context
+		p.chainedContext(pos)
+	default:
+		p.err = fmt.Errorf("bad lookup type %v", lookupType)
+	}
+}
+
+func (p *layoutPatcher) context(pos uint32) {
+	switch format := p.uint16(pos); format {
+	case 1, 2:
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		offset := pos + 4
+		if format == 2 {
+			if classDef, ok := p.offset(pos, pos+4); ok {
+				p.classDef(classDef)
+			}
+			offset += 2
+		}
+		p.offsets(pos, offset+2, uint32(p.uint16(offset)), func(set uint32) {
+			p.offsets(set, set+2, uint32(p.uint16(set)), func(rule uint32) {
+				glyphCount, seqLookupCount := uint32(p.uint16(rule)), uint32(p.uint16(rule+2))
+				if glyphCount == 0 {
+					return
+				} else if format == 1 {
+					p.glyphIDs(rule+4, glyphCount-1)
+				}
+				p.lookupRecords(rule+4+2*(glyphCount-1), seqLookupCount)
+			})
+		})
+	case 3:
+		glyphCount, seqLookupCount := uint32(p.uint16(pos+2)), uint32(p.uint16(pos+4))
+		p.offsets(pos, pos+6, glyphCount, p.coverage)
+		p.lookupRecords(pos+6+2*glyphCount, seqLookupCount)
+	default:
+		p.err = fmt.Errorf("bad context subtable")
+	}
+}
+
+func (p *layoutPatcher) chainedContext(pos uint32) {
+	switch format := p.uint16(pos); format {
+	case 1, 2:
+		if cov, ok := p.offset(pos, pos+2); ok {
+			p.coverage(cov)
+		}
+		offset := pos + 4
+		if format == 2 {
+			for i := uint32(0); i < 3; i++ { // backtrack, input, and lookahead class definitions
+				if classDef, ok := p.offset(pos, pos+4+2*i); ok {
+					p.classDef(classDef)
+				}
+			}
+			offset += 6
+		}
+		p.offsets(pos, offset+2, uint32(p.uint16(offset)), func(set uint32) {
+			p.offsets(set, set+2, uint32(p.uint16(set)), func(rule uint32) {
+				offset := rule
+				for i := 0; i < 3; i++ { // backtrack, input, and lookahead sequences
+					n := uint32(p.uint16(offset))
+					if i == 1 && 0 < n {
+						n-- // the first input glyph is given by the coverage
+					}
+					if format == 1 {
+						p.glyphIDs(offset+2, n)
+					}
+					offset += 2 + 2*n
+				}
+				p.lookupRecords(offset+2, uint32(p.uint16(offset)))
+			})
+		})
+	case 3:
+		offset := pos + 2
+		for i := 0; i < 3; i++ { // backtrack, input, and lookahead coverages
+			n := uint32(p.uint16(offset))
+			p.offsets(pos, offset+2, n, p.coverage)
+			offset += 2 + 2*n
+		}
+		p.lookupRecords(offset+2, uint32(p.uint16(offset)))
+	default:
+		p.err = fmt.Errorf("bad chained context subtable")
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// classDefGlyphs returns the non-zero classes of the glyphs in the class definition table at pos.
+func classDefGlyphs(b []byte, pos uint32) (map[uint16]uint16, error) {
+	r := NewBinaryReader(b)
+	r.Seek(pos)
+	classes := map[uint16]uint16{}
+	switch r.ReadUint16() {
+	case 1:
+		startGlyphID := r.ReadUint16()
+		glyphCount := r.ReadUint16()
+		for i := uint16(0); i < glyphCount; i++ {
+			if class := r.ReadUint16(); class != 0 {
+				classes[startGlyphID+i] = class
+			}
+		}
+	case 2:
+		classRangeCount := r.ReadUint16()
+		for i := uint16(0); i < classRangeCount; i++ {
+			startGlyphID := r.ReadUint16()
+			endGlyphID := r.ReadUint16()
+			class := r.ReadUint16()
+			for glyphID := uint32(startGlyphID); glyphID <= uint32(endGlyphID) && class != 0; glyphID++ {
+				classes[uint16(glyphID)] = class
+			}
+		}
+	default:
+		return nil, fmt.Errorf("bad class definition table")
+	}
+	if r.EOF() {
+		return nil, fmt.Errorf("bad class definition table")
+	}
+	return classes, nil
+}
+
+// coverageGlyphs returns the glyph IDs of the coverage table at pos in coverage order.
+func coverageGlyphs(b []byte, pos uint32) ([]uint16, error) {
+	r := NewBinaryReader(b)
+	r.Seek(pos)
+	glyphIDs := []uint16{}
+	switch r.ReadUint16() {
+	case 1:
+		glyphCount := r.ReadUint16()
+		for i := uint16(0); i < glyphCount; i++ {
+			glyphIDs = append(glyphIDs, r.ReadUint16())
+		}
+	case 2:
+		rangeCount := r.ReadUint16()
+		for i := uint16(0); i < rangeCount; i++ {
+			startGlyphID := r.ReadUint16()
+			endGlyphID := r.ReadUint16()
+			_ = r.ReadUint16() // startCoverageIndex
+			for glyphID := uint32(startGlyphID); glyphID <= uint32(endGlyphID); glyphID++ {
+				glyphIDs = append(glyphIDs, uint16(glyphID))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("bad coverage table")
+	}
+	if r.EOF() {
+		return nil, fmt.Errorf("bad coverage table")
+	}
+	return glyphIDs, nil
+}
+
+// writeClassDef writes a class definition table of format 2.
+func writeClassDef(classes map[uint16]uint16) []byte {
+	glyphIDs := make([]uint16, 0, len(classes))
+	for glyphID := range classes {
+		glyphIDs = append(glyphIDs, glyphID)
+	}
+	sort.Slice(glyphIDs, func(i, j int) bool { return glyphIDs[i] < glyphIDs[j] })
+
+	type classRange struct {
+		start, end, class uint16
+	}
+	ranges := []classRange{}
+	for _, glyphID := range glyphIDs {
+		class := classes[glyphID]
+		if 0 < len(ranges) {
+			last := &ranges[len(ranges)-1]
+			if last.end+1 == glyphID && last.class == class {
+				last.end = glyphID
+				continue
+			}
+		}
+		ranges = append(ranges, classRange{glyphID, glyphID, class})
+	}
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(2) // format
+	w.WriteUint16(uint16(len(ranges)))
+	for _, r := range ranges {
+		w.WriteUint16(r.start)
+		w.WriteUint16(r.end)
+		w.WriteUint16(r.class)
+	}
+	return w.Bytes()
+}
+
+// writeCoverage writes a coverage table of format 1.
+func writeCoverage(glyphIDs []uint16) []byte {
+	glyphIDs = append([]uint16{}, glyphIDs...)
+	sort.Slice(glyphIDs, func(i, j int) bool { return glyphIDs[i] < glyphIDs[j] })
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(1) // format
+	w.WriteUint16(uint16(len(glyphIDs)))
+	for _, glyphID := range glyphIDs {
+		w.WriteUint16(glyphID)
+	}
+	return w.Bytes()
+}
+
+func scaleInt16(v int16, scale float64) int16 {
+	if scale == 1.0 {
+		return v
+	}
+	return int16(math.Max(math.MinInt16, math.Min(math.Round(float64(v)*scale), math.MaxInt16)))
+}
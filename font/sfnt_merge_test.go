@@ -0,0 +1,218 @@
+package font
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// testLookup is a lookup with a single subtable.
+type testLookup struct {
+	lookupType uint16
+	subtable   []byte
+}
+
+// testLayoutTable returns a GSUB or GPOS table with a feature of the default script that uses the first lookup.
+func testLayoutTable(feature string, lookups ...testLookup) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint32(0x00010000) // version
+	w.WriteUint16(10)         // scriptListOffset
+	w.WriteUint16(30)         // featureListOffset
+	w.WriteUint16(44)         // lookupListOffset
+
+	// script list
+	w.WriteUint16(1) // scriptCount
+	w.WriteString("DFLT")
+	w.WriteUint16(8)      // scriptOffset
+	w.WriteUint16(4)      // defaultLangSysOffset
+	w.WriteUint16(0)      // langSysCount
+	w.WriteUint16(0)      // lookupOrderOffset
+	w.WriteUint16(0xFFFF) // requiredFeatureIndex
+	w.WriteUint16(1)      // featureIndexCount
+	w.WriteUint16(0)      // featureIndices
+
+	// feature list
+	w.WriteUint16(1) // featureCount
+	w.WriteString(feature)
+	w.WriteUint16(8) // featureOffset
+	w.WriteUint16(0) // featureParamsOffset
+	w.WriteUint16(1) // lookupIndexCount
+	w.WriteUint16(0) // lookupListIndices
+
+	// lookup list
+	w.WriteUint16(uint16(len(lookups))) // lookupCount
+	offset := 2 + 2*len(lookups)
+	for _, lookup := range lookups {
+		w.WriteUint16(uint16(offset)) // lookupOffset
+		offset += 8 + len(lookup.subtable)
+	}
+	for _, lookup := range lookups {
+		w.WriteUint16(lookup.lookupType)
+		w.WriteUint16(0) // lookupFlag
+		w.WriteUint16(1) // subTableCount
+		w.WriteUint16(8) // subtableOffset
+		w.WriteBytes(lookup.subtable)
+	}
+	return w.Bytes()
+}
+
+// testSingleSubst returns a single substitution subtable of format 2 that replaces one glyph.
+func testSingleSubst(glyphID, substitute uint16) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(2) // substFormat
+	w.WriteUint16(8) // coverageOffset
+	w.WriteUint16(1) // glyphCount
+	w.WriteUint16(substitute)
+	w.WriteUint16(1) // coverageFormat
+	w.WriteUint16(1) // glyphCount
+	w.WriteUint16(glyphID)
+	return w.Bytes()
+}
+
+// testContext returns a sequence context subtable of format 3 that applies a lookup to one glyph.
+func testContext(glyphID, lookupIndex uint16) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(3)  // format
+	w.WriteUint16(1)  // glyphCount
+	w.WriteUint16(1)  // seqLookupCount
+	w.WriteUint16(12) // coverageOffsets
+	w.WriteUint16(0)  // sequenceIndex
+	w.WriteUint16(lookupIndex)
+	w.WriteUint16(1) // coverageFormat
+	w.WriteUint16(1) // glyphCount
+	w.WriteUint16(glyphID)
+	return w.Bytes()
+}
+
+// testPairPos returns a pair adjustment subtable of format 1 that adjusts the advance of the first glyph of one pair.
+func testPairPos(first, second uint16, xAdvance int16) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(1)      // posFormat
+	w.WriteUint16(18)     // coverageOffset
+	w.WriteUint16(0x0004) // valueFormat1, X_ADVANCE
+	w.WriteUint16(0)      // valueFormat2
+	w.WriteUint16(1)      // pairSetCount
+	w.WriteUint16(12)     // pairSetOffsets
+	w.WriteUint16(1)      // pairValueCount
+	w.WriteUint16(second)
+	w.WriteInt16(xAdvance)
+	w.WriteUint16(1) // coverageFormat
+	w.WriteUint16(1) // glyphCount
+	w.WriteUint16(first)
+	return w.Bytes()
+}
+
+// testLayoutFont returns a TrueType font with a rectangle glyph of the given width for each rune, a GSUB table that replaces the first glyph by the second directly and through a contextual lookup, and a GPOS table that kerns the first two glyphs.
+func testLayoutFont(t *testing.T, unitsPerEm uint16, runes []rune, widths []float64, kern int16) *SFNT {
+	t.Helper()
+	builder := NewBuilder("Test", unitsPerEm)
+	for i, r := range runes {
+		glyph := builder.AddGlyph(string(r), uint16(widths[i]+100.0), r)
+		glyph.MoveTo(50, 0)
+		glyph.LineTo(50+widths[i], 0)
+		glyph.LineTo(50+widths[i], float64(unitsPerEm)/2.0)
+		glyph.LineTo(50, float64(unitsPerEm)/2.0)
+		glyph.Close()
+	}
+	b, err := builder.WriteTrueType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addTables(t, b, map[string][]byte{
+		"GSUB": testLayoutTable("salt", testLookup{1, testSingleSubst(1, 2)}, testLookup{5, testContext(1, 0)}),
+		"GPOS": testLayoutTable("kern", testLookup{2, testPairPos(1, 2, kern)}),
+	})
+}
+
+func TestMerge(t *testing.T) {
+	fonts := []*SFNT{
+		testLayoutFont(t, 1000, []rune{'a', 'b'}, []float64{100, 200}, -50),
+		testLayoutFont(t, 2000, []rune{'c', 'd', 'a'}, []float64{300, 400, 500}, -100),
+	}
+	b, err := Merge(fonts, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := Validate(b); !report.Valid() {
+		t.Fatal(report)
+	}
+	merged, err := ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if merged.NumGlyphs() != 7 {
+		t.Fatalf("has %d glyphs, expected 7", merged.NumGlyphs())
+	}
+
+	// glyphs of the second font follow those of the first and are scaled to 1000 units per em
+	var tests = []struct {
+		r       rune
+		glyphID uint16
+		advance uint16
+		xMax    int16
+	}{
+		{'a', 1, 200, 150}, // the first font takes precedence
+		{'b', 2, 300, 250},
+		{'c', 4, 200, 175},
+		{'d', 5, 250, 225},
+	}
+	for _, tt := range tests {
+		if glyphID := merged.GlyphIndex(tt.r); glyphID != tt.glyphID {
+			t.Fatalf("%c: glyph ID is %d, expected %d", tt.r, glyphID, tt.glyphID)
+		} else if advance := merged.GlyphAdvance(glyphID); advance != tt.advance {
+			t.Fatalf("%c: advance is %d, expected %d", tt.r, advance, tt.advance)
+		} else if _, _, xMax, _, err := merged.GlyphBounds(glyphID); err != nil || xMax != tt.xMax {
+			t.Fatalf("%c: xMax is %d, expected %d", tt.r, xMax, tt.xMax)
+		}
+	}
+
+	// the layout tables must parse and point to the glyphs and lookups of their font
+	font, err := truetype.Parse(bytes.NewReader(b), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gsub, err := font.GSUBTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpos, err := font.GPOSTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gsub.Lookups) != 4 || len(gpos.Lookups) != 2 {
+		t.Fatalf("has %d GSUB and %d GPOS lookups, expected 4 and 2", len(gsub.Lookups), len(gpos.Lookups))
+	}
+	for i, feature := range gsub.Features {
+		if len(feature.LookupIndices) != 1 || int(feature.LookupIndices[0]) != 2*i {
+			t.Fatalf("GSUB feature %d has lookups %v", i, feature.LookupIndices)
+		}
+	}
+	for i, glyphIDs := range [][2]truetype.GID{{1, 2}, {4, 5}} {
+		context, ok := gsub.Lookups[2*i+1].Subtables[0].Data.(truetype.GSUBContext3)
+		if !ok || len(context.SequenceLookups) != 1 || int(context.SequenceLookups[0].LookupIndex) != 2*i {
+			t.Fatalf("GSUB lookup %d does not apply lookup %d", 2*i+1, 2*i)
+		} else if _, ok := context.Coverages[0].Index(glyphIDs[0]); !ok {
+			t.Fatalf("GSUB lookup %d does not cover glyph %d", 2*i+1, glyphIDs[0])
+		}
+
+		subtable := gsub.Lookups[2*i].Subtables[0]
+		index, ok := subtable.Coverage.Index(glyphIDs[0])
+		if !ok {
+			t.Fatalf("GSUB lookup %d does not cover glyph %d", 2*i, glyphIDs[0])
+		} else if substitutes, ok := subtable.Data.(truetype.GSUBSingle2); !ok || substitutes[index] != glyphIDs[1] {
+			t.Fatalf("GSUB lookup %d does not substitute glyph %d by %d", 2*i, glyphIDs[0], glyphIDs[1])
+		}
+
+		pairSubtable := gpos.Lookups[i].Subtables[0]
+		index, ok = pairSubtable.Coverage.Index(glyphIDs[0])
+		if !ok {
+			t.Fatalf("GPOS lookup %d does not cover glyph %d", i, glyphIDs[0])
+		}
+		pairs, ok := pairSubtable.Data.(truetype.GPOSPair1)
+		if !ok {
+			t.Fatalf("GPOS lookup %d is not a pair adjustment", i)
+		} else if record := pairs.Values[index].FindGlyph(glyphIDs[1]); record == nil || record.Pos[0].XAdvance != -50 {
+			t.Fatalf("GPOS lookup %d does not kern glyphs %d and %d by -50", i, glyphIDs[0], glyphIDs[1])
+		}
+	}
+}
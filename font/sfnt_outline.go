@@ -0,0 +1,298 @@
+package font
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// SegmentType is the type of an outline segment.
+type SegmentType uint8
+
+// see SegmentType
+const (
+	MoveToSegment SegmentType = iota
+	LineToSegment
+	QuadToSegment
+	CubeToSegment
+	CloseSegment
+)
+
+// Segment is a segment of an outline. Args holds the coordinates in the order of the arguments of the corresponding Pather method, unused values are zero.
+type Segment struct {
+	Type SegmentType
+	Args [6]float64
+}
+
+// Outline is a glyph outline as a list of segments in font units with the y-axis pointing upwards. It implements the Pather interface to record a path.
+type Outline []Segment
+
+// MoveTo adds a move to (x,y).
+func (o *Outline) MoveTo(x, y float64) {
+	*o = append(*o, Segment{MoveToSegment, [6]float64{x, y}})
+}
+
+// LineTo adds a line to (x,y).
+func (o *Outline) LineTo(x, y float64) {
+	*o = append(*o, Segment{LineToSegment, [6]float64{x, y}})
+}
+
+// QuadTo adds a quadratic Bézier with control point (cpx,cpy) to (x,y).
+func (o *Outline) QuadTo(cpx, cpy, x, y float64) {
+	*o = append(*o, Segment{QuadToSegment, [6]float64{cpx, cpy, x, y}})
+}
+
+// CubeTo adds a cubic Bézier with control points (cpx1,cpy1) and (cpx2,cpy2) to (x,y).
+func (o *Outline) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	*o = append(*o, Segment{CubeToSegment, [6]float64{cpx1, cpy1, cpx2, cpy2, x, y}})
+}
+
+// Close closes the current contour.
+func (o *Outline) Close() {
+	*o = append(*o, Segment{Type: CloseSegment})
+}
+
+// Draw replays the outline onto the pather, where each point (x,y) is drawn at (xOffset+scale*x, yOffset+scale*y).
+func (o Outline) Draw(p Pather, xOffset, yOffset, scale float64) {
+	for _, seg := range o {
+		a := seg.Args
+		switch seg.Type {
+		case MoveToSegment:
+			p.MoveTo(xOffset+scale*a[0], yOffset+scale*a[1])
+		case LineToSegment:
+			p.LineTo(xOffset+scale*a[0], yOffset+scale*a[1])
+		case QuadToSegment:
+			p.QuadTo(xOffset+scale*a[0], yOffset+scale*a[1], xOffset+scale*a[2], yOffset+scale*a[3])
+		case CubeToSegment:
+			p.CubeTo(xOffset+scale*a[0], yOffset+scale*a[1], xOffset+scale*a[2], yOffset+scale*a[3], xOffset+scale*a[4], yOffset+scale*a[5])
+		case CloseSegment:
+			p.Close()
+		}
+	}
+}
+
+// Oblique returns a copy of the outline sheared horizontally so that each point (x,y) moves to (x+shear*y, y), a positive shear slants the outline forward.
+func (o Outline) Oblique(shear float64) Outline {
+	r := make(Outline, len(o))
+	for i, seg := range o {
+		r[i] = seg
+		for j := 0; j+1 < len(seg.Args); j += 2 {
+			r[i].Args[j] += shear * seg.Args[j+1]
+		}
+	}
+	return r
+}
+
+// Embolden returns a copy of the outline where the contours are offset outwards so that the outline becomes xStrength wider and yStrength higher, half of which on each side. Each point moves along the bisector of its adjacent edges, the offset is limited by the length of the adjacent edges so that counters stay open. This follows FT_Outline_EmboldenXY of FreeType and is applied to the control points of Béziers as well.
+func (o Outline) Embolden(xStrength, yStrength float64) Outline {
+	r := make(Outline, len(o))
+	copy(r, o)
+	if xStrength == 0.0 && yStrength == 0.0 {
+		return r
+	}
+	xStrength /= 2.0
+	yStrength /= 2.0
+
+	// references to the points of each contour
+	type point struct {
+		seg, arg int
+	}
+	contours := [][]point{}
+	for i, seg := range o {
+		n := 0
+		switch seg.Type {
+		case MoveToSegment:
+			contours = append(contours, []point{})
+			n = 1
+		case LineToSegment:
+			n = 1
+		case QuadToSegment:
+			n = 2
+		case CubeToSegment:
+			n = 3
+		}
+		if 0 < n && len(contours) == 0 {
+			contours = append(contours, []point{})
+		}
+		for j := 0; j < n; j++ {
+			contours[len(contours)-1] = append(contours[len(contours)-1], point{i, 2 * j})
+		}
+	}
+	get := func(p point) (float64, float64) {
+		return o[p.seg].Args[p.arg], o[p.seg].Args[p.arg+1]
+	}
+
+	// the orientation of the outer contours, the sign of the area is positive for counter-clockwise contours
+	area := 0.0
+	for _, contour := range contours {
+		for i := range contour {
+			x0, y0 := get(contour[i])
+			x1, y1 := get(contour[(i+1)%len(contour)])
+			area += x0*y1 - x1*y0
+		}
+	}
+	clockwise := area < 0.0
+
+	for _, contour := range contours {
+		// the closing point coincides with the starting point
+		n := len(contour)
+		if 1 < n {
+			x0, y0 := get(contour[0])
+			x1, y1 := get(contour[n-1])
+			if x0 == x1 && y0 == y1 {
+				n--
+			}
+		}
+
+		shifts := make([][2]float64, n)
+		for i := 0; i < n; i++ {
+			x, y := get(contour[i])
+
+			// find the previous and next distinct points
+			var inX, inY, outX, outY float64
+			for k := 1; k < n; k++ {
+				px, py := get(contour[(i-k+n)%n])
+				if px != x || py != y {
+					inX, inY = x-px, y-py
+					break
+				}
+			}
+			for k := 1; k < n; k++ {
+				nx, ny := get(contour[(i+k)%n])
+				if nx != x || ny != y {
+					outX, outY = nx-x, ny-y
+					break
+				}
+			}
+			lIn, lOut := math.Hypot(inX, inY), math.Hypot(outX, outY)
+			if lIn == 0.0 || lOut == 0.0 {
+				continue
+			}
+			inX, inY = inX/lIn, inY/lIn
+			outX, outY = outX/lOut, outY/lOut
+
+			d := inX*outX + inY*outY // cosine of the turning angle
+			if d <= -0.9375 {
+				continue // sharp spikes are not shifted
+			}
+			d += 1.0
+
+			shiftX, shiftY := inY+outY, inX+outX
+			q := outX*inY - outY*inX
+			if clockwise {
+				shiftX = -shiftX
+				q = -q
+			} else {
+				shiftY = -shiftY
+			}
+
+			// shifts are limited by the shorter adjacent edge
+			l := math.Min(lIn, lOut)
+			if xStrength*q <= l*d {
+				shiftX = shiftX * xStrength / d
+			} else {
+				shiftX = shiftX * l / q
+			}
+			if yStrength*q <= l*d {
+				shiftY = shiftY * yStrength / d
+			} else {
+				shiftY = shiftY * l / q
+			}
+			shifts[i] = [2]float64{shiftX, shiftY}
+		}
+		for i, p := range contour {
+			shift := shifts[i%n]
+			r[p.seg].Args[p.arg] += shift[0]
+			r[p.seg].Args[p.arg+1] += shift[1]
+		}
+	}
+	return r
+}
+
+// GlyphOutline returns the unhinted outline of the glyph in font units. Outlines are kept in a least-recently-used cache whose memory is bounded by ParseOptions.MaxOutlineCache, so that repeatedly drawn glyphs are decoded only once. The returned outline is shared and must not be modified. It is safe for concurrent use.
+func (sfnt *SFNT) GlyphOutline(glyphID uint16) (Outline, error) {
+	if outline, ok := sfnt.outlines.Get(glyphID); ok {
+		return outline, nil
+	} else if sfnt.NumGlyphs() <= glyphID {
+		return nil, fmt.Errorf("bad glyphID %v", glyphID)
+	}
+
+	outline := Outline{}
+	if err := sfnt.GlyphPath(&outline, glyphID, 0, 0, 0, 1.0, NoHinting); err != nil {
+		return nil, err
+	}
+	outline = outline[:len(outline):len(outline)] // appending copies
+
+	maxSize := sfnt.opts.MaxOutlineCache
+	if maxSize == 0 {
+		maxSize = MaxOutlineCache
+	}
+	sfnt.outlines.Add(glyphID, outline, maxSize)
+	return outline, nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// outlineCacheOverhead is the approximate memory used per cached outline besides its segments.
+const outlineCacheOverhead = 128
+
+// outlineCache is a least-recently-used cache of glyph outlines, bounded by the memory used by the segments.
+type outlineCache struct {
+	mu      sync.Mutex
+	size    uint32
+	entries map[uint16]*list.Element
+	lru     *list.List // most recently used at the front
+}
+
+type outlineCacheEntry struct {
+	glyphID uint16
+	outline Outline
+	size    uint32
+}
+
+// Get returns the cached outline and marks it as most recently used.
+func (c *outlineCache) Get(glyphID uint16) (Outline, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[glyphID]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*outlineCacheEntry).outline, true
+	}
+	return nil, false
+}
+
+// Add adds an outline and evicts the least recently used outlines until the cache fits within maxSize.
+func (c *outlineCache) Add(glyphID uint16, outline Outline, maxSize uint32) {
+	size := uint32(len(outline))*uint32(7*8) + outlineCacheOverhead
+	if maxSize < size {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[uint16]*list.Element{}
+		c.lru = list.New()
+	} else if _, ok := c.entries[glyphID]; ok {
+		return // added concurrently
+	}
+	for maxSize-size < c.size {
+		elem := c.lru.Back()
+		entry := elem.Value.(*outlineCacheEntry)
+		c.lru.Remove(elem)
+		delete(c.entries, entry.glyphID)
+		c.size -= entry.size
+	}
+	c.entries[glyphID] = c.lru.PushFront(&outlineCacheEntry{glyphID, outline, size})
+	c.size += size
+}
+
+// Clear removes all outlines.
+func (c *outlineCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.lru = nil
+	c.size = 0
+}
@@ -0,0 +1,285 @@
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// lazyEagerTables are the tables that are always loaded and parsed by ParseSFNTReaderAt, other tables are loaded on demand.
+var lazyEagerTables = []string{"cmap", "fvar", "head", "hhea", "hmtx", "kern", "loca", "maxp", "name", "OS/2", "post", "vhea", "vmtx"}
+
+// ParseSFNTReaderAt parses an OpenType file format (TTF, OTF, TTC) of the given size from a reader, such as an os.File or a memory-mapped file. The index is used for font collections to select a single font, and optionally parse options can be passed to set limits and required tables. Only the small tables required for metrics and character mapping are read and parsed, glyph outlines are read on demand for each glyph (glyf) or when first needed (CFF, CFF2), and other tables are read when requested through Table or LoadTable. The reader must remain valid for the lifetime of the SFNT. Use Release to free data that was loaded on demand.
+func ParseSFNTReaderAt(r io.ReaderAt, size int64, index int, opts ...ParseOptions) (*SFNT, error) {
+	o := parseOptions(opts)
+	if size < 12 || math.MaxUint32 < size {
+		return nil, ErrInvalidFontData
+	}
+
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	offset := uint32(0)
+	sfntVersion := string(header[:4])
+	if sfntVersion == "ttcf" {
+		majorVersion := binary.BigEndian.Uint16(header[4:])
+		minorVersion := binary.BigEndian.Uint16(header[6:])
+		if majorVersion != 1 && majorVersion != 2 || minorVersion != 0 {
+			return nil, fmt.Errorf("bad TTC version")
+		}
+		numFonts := binary.BigEndian.Uint32(header[8:])
+		if index < 0 || numFonts <= uint32(index) {
+			return nil, fmt.Errorf("bad font index %d", index)
+		} else if uint32(size)-12 < 4*uint32(index)+4 {
+			return nil, ErrInvalidFontData
+		}
+
+		b := make([]byte, 4)
+		if _, err := r.ReadAt(b, 12+4*int64(index)); err != nil {
+			return nil, err
+		}
+		offset = binary.BigEndian.Uint32(b)
+		if uint32(size)-12 < offset {
+			return nil, ErrInvalidFontData
+		}
+		if _, err := r.ReadAt(header, int64(offset)); err != nil {
+			return nil, err
+		}
+		sfntVersion = string(header[:4])
+	} else if index != 0 {
+		return nil, fmt.Errorf("bad font index %d", index)
+	}
+	if sfntVersion != "OTTO" && sfntVersion != "true" && binary.BigEndian.Uint32([]byte(sfntVersion)) != 0x00010000 {
+		return nil, fmt.Errorf("bad SFNT version")
+	}
+	numTables := binary.BigEndian.Uint16(header[4:])
+	if uint32(size)-offset-12 < 16*uint32(numTables) {
+		return nil, ErrInvalidFontData
+	}
+
+	directory := make([]byte, 16*uint32(numTables))
+	if _, err := r.ReadAt(directory, int64(offset)+12); err != nil {
+		return nil, err
+	}
+	records := make(map[string]tableRecord, numTables)
+	rd := NewBinaryReader(directory)
+	for i := 0; i < int(numTables); i++ {
+		record := tableRecord{
+			tag:      rd.ReadString(4),
+			checksum: rd.ReadUint32(),
+			offset:   rd.ReadUint32(),
+			length:   rd.ReadUint32(),
+		}
+		if uint32(size) <= record.offset || uint32(size)-record.offset < record.length {
+			return nil, ErrInvalidFontData
+		} else if record.tag == "head" && record.length < 12 {
+			return nil, ErrInvalidFontData
+		}
+		records[record.tag] = record
+	}
+
+	sfnt := &SFNT{
+		Version:    sfntVersion,
+		IsCFF:      sfntVersion == "OTTO",
+		IsTrueType: sfntVersion == "true" || binary.BigEndian.Uint32([]byte(sfntVersion)) == 0x00010000,
+		Tables:     map[string][]byte{},
+		src:        r,
+		size:       size,
+		index:      index,
+		records:    records,
+		opts:       o,
+	}
+
+	for _, requiredTable := range o.requiredTables(sfnt.IsTrueType) {
+		if _, ok := records[requiredTable]; !ok {
+			return nil, fmt.Errorf("%s: missing table", requiredTable)
+		}
+	}
+	if sfnt.IsCFF {
+		_, hasCFF := records["CFF "]
+		_, hasCFF2 := records["CFF2"]
+		if !hasCFF && !hasCFF2 {
+			return nil, fmt.Errorf("CFF: missing table")
+		} else if hasCFF && hasCFF2 {
+			return nil, fmt.Errorf("CFF2: CFF table already exists")
+		}
+	}
+
+	for _, tag := range lazyEagerTables {
+		if _, ok := records[tag]; ok {
+			if _, err := sfnt.table(tag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// maxp and hhea tables are required for other tables to be parse first
+	if err := sfnt.parseHead(); err != nil {
+		return nil, err
+	} else if err := sfnt.parseMaxp(); err != nil {
+		return nil, err
+	} else if err := sfnt.parseHhea(); err != nil {
+		return nil, err
+	}
+	if sfnt.IsTrueType {
+		if err := sfnt.parseLoca(); err != nil {
+			return nil, err
+		} else if err := sfnt.parseGlyf(); err != nil {
+			return nil, err
+		}
+	}
+
+	tableNames := make([]string, 0, len(sfnt.Tables))
+	for tableName := range sfnt.Tables {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		if err := sfnt.parseTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+	if sfnt.OS2 == nil {
+		sfnt.OS2 = &os2Table{}
+	}
+	if sfnt.OS2.Version <= 1 {
+		sfnt.estimateOS2()
+	}
+	return sfnt, nil
+}
+
+// HasTable returns true if the font contains the table.
+func (sfnt *SFNT) HasTable(tag string) bool {
+	sfnt.mu.Lock()
+	defer sfnt.mu.Unlock()
+	return sfnt.hasTable(tag)
+}
+
+func (sfnt *SFNT) hasTable(tag string) bool {
+	if _, ok := sfnt.Tables[tag]; ok {
+		return true
+	}
+	_, ok := sfnt.records[tag]
+	return ok
+}
+
+// Table returns the data of a table. For fonts parsed with ParseSFNTReaderAt, the table is read on first access.
+func (sfnt *SFNT) Table(tag string) ([]byte, error) {
+	sfnt.mu.Lock()
+	defer sfnt.mu.Unlock()
+	return sfnt.table(tag)
+}
+
+func (sfnt *SFNT) table(tag string) ([]byte, error) {
+	if b, ok := sfnt.Tables[tag]; ok {
+		return b, nil
+	}
+	record, ok := sfnt.records[tag]
+	if !ok || sfnt.src == nil {
+		return nil, fmt.Errorf("%s: missing table", tag)
+	}
+	if sfnt.opts.MaxMemory < record.length {
+		return nil, fmt.Errorf("%s: %w", tag, ErrExceedsMemory)
+	}
+	b := make([]byte, record.length)
+	if _, err := sfnt.src.ReadAt(b, int64(record.offset)); err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	sfnt.Tables[tag] = b
+	return b, nil
+}
+
+// tableTags returns the sorted tags of all tables, including those not yet loaded.
+func (sfnt *SFNT) tableTags() []string {
+	tags := make([]string, 0, len(sfnt.Tables)+len(sfnt.records))
+	for tag := range sfnt.Tables {
+		tags = append(tags, tag)
+	}
+	for tag := range sfnt.records {
+		if _, ok := sfnt.Tables[tag]; !ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// LoadTable reads and parses a table that is loaded on demand for fonts parsed with ParseSFNTReaderAt, such as CFF, CFF2, or GPOS. It does nothing if the table was already parsed.
+func (sfnt *SFNT) LoadTable(tag string) error {
+	sfnt.mu.Lock()
+	defer sfnt.mu.Unlock()
+	return sfnt.loadTable(tag)
+}
+
+func (sfnt *SFNT) loadTable(tag string) error {
+	switch tag {
+	case "CFF ", "CFF2":
+		if sfnt.CFF != nil {
+			return nil
+		}
+	case "GPOS":
+		if sfnt.Gpos != nil {
+			return nil
+		}
+	default:
+		if _, ok := sfnt.Tables[tag]; ok {
+			return nil
+		}
+	}
+	if _, err := sfnt.table(tag); err != nil {
+		return err
+	}
+	return sfnt.parseTable(tag)
+}
+
+// loadCFF loads the CFF or CFF2 table if needed and returns it. The returned table remains valid when Release is called concurrently.
+func (sfnt *SFNT) loadCFF() (*cffTable, error) {
+	sfnt.mu.Lock()
+	defer sfnt.mu.Unlock()
+	if sfnt.CFF != nil {
+		return sfnt.CFF, nil
+	}
+	tag := "CFF "
+	if sfnt.hasTable("CFF2") {
+		tag = "CFF2"
+	}
+	if err := sfnt.loadTable(tag); err != nil {
+		return nil, err
+	}
+	return sfnt.CFF, nil
+}
+
+// Release frees decoded glyph data such as cached glyph outlines. The reverse character maps of the cmap table are kept so that Release does not race with ToUnicode. For fonts parsed with ParseSFNTReaderAt, it also frees the tables that were loaded on demand, they will be loaded again when needed.
+func (sfnt *SFNT) Release() {
+	sfnt.mu.Lock()
+	defer sfnt.mu.Unlock()
+
+	sfnt.outlines.Clear()
+	if sfnt.src == nil {
+		return
+	}
+
+	eager := make(map[string]bool, len(lazyEagerTables))
+	for _, tag := range lazyEagerTables {
+		eager[tag] = true
+	}
+	for tag := range sfnt.Tables {
+		if !eager[tag] {
+			delete(sfnt.Tables, tag)
+		}
+	}
+	sfnt.CFF = nil
+	sfnt.Gpos = nil
+}
+
+// Reader returns a reader of the font file and the index of the font within the file in case of a font collection. For fonts parsed with ParseSFNT it reads from Data.
+func (sfnt *SFNT) Reader() (*io.SectionReader, int) {
+	if sfnt.src != nil {
+		return io.NewSectionReader(sfnt.src, 0, sfnt.size), sfnt.index
+	}
+	return io.NewSectionReader(bytes.NewReader(sfnt.Data), 0, int64(len(sfnt.Data))), 0
+}
@@ -0,0 +1,51 @@
+package font
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// TestReleaseConcurrent must be run with -race to detect data races between Release and the readers of the tables.
+func TestReleaseConcurrent(t *testing.T) {
+	b := testLayoutFont(t, 1000, []rune{'a', 'b'}, []float64{100, 200}, -50).Write()
+	sfnt, err := ParseSFNTReaderAt(bytes.NewReader(b), int64(len(b)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				f()
+			}
+		}()
+	}
+	run(sfnt.Release)
+	run(func() {
+		if err := sfnt.LoadTable("GPOS"); err != nil {
+			t.Error(err)
+		}
+	})
+	run(func() {
+		if !sfnt.HasTable("GSUB") || !sfnt.HasTable("GPOS") {
+			t.Error("missing layout table")
+		}
+	})
+	run(func() {
+		if coverage := sfnt.Coverage(); len(coverage.Scripts) == 0 {
+			t.Error("no scripts")
+		}
+	})
+	run(func() {
+		if _, _, err := sfnt.SubsetChecked([]uint16{0, 1}); err != nil {
+			t.Error(err)
+		} else if r := sfnt.Cmap.ToUnicode(1); r != 'a' {
+			t.Errorf("glyph 1 maps to %q, expected 'a'", r)
+		}
+	})
+	wg.Wait()
+}
@@ -3,6 +3,7 @@ package font
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -61,12 +62,29 @@ func (contour *glyfContour) String() string {
 type glyfTable struct {
 	data []byte
 	loca *locaTable
+
+	// glyphs are read on demand when src is set
+	src    io.ReaderAt
+	offset uint32
+	length uint32
 }
 
 func (glyf *glyfTable) Get(glyphID uint16) []byte {
 	start, ok1 := glyf.loca.Get(glyphID)
 	end, ok2 := glyf.loca.Get(glyphID + 1)
-	if !ok1 || !ok2 {
+	if !ok1 || !ok2 || end < start {
+		return nil
+	}
+	if glyf.src != nil {
+		if glyf.length < end {
+			return nil
+		}
+		b := make([]byte, end-start)
+		if _, err := glyf.src.ReadAt(b, int64(glyf.offset)+int64(start)); err != nil {
+			return nil
+		}
+		return b
+	} else if uint32(len(glyf.data)) < end {
 		return nil
 	}
 	return glyf.data[start:end]
@@ -400,6 +418,20 @@ func (glyf *glyfTable) ToPath(p Pather, glyphID, ppem uint16, xOffset, yOffset i
 
 func (sfnt *SFNT) parseGlyf() error {
 	// requires data from loca
+	if record, ok := sfnt.records["glyf"]; ok && sfnt.src != nil {
+		// glyphs are read on demand
+		if length, _ := sfnt.Loca.Get(sfnt.Maxp.NumGlyphs); record.length < length {
+			return fmt.Errorf("glyf: bad table")
+		}
+		sfnt.Glyf = &glyfTable{
+			loca:   sfnt.Loca,
+			src:    sfnt.src,
+			offset: record.offset,
+			length: record.length,
+		}
+		return nil
+	}
+
 	b, ok := sfnt.Tables["glyf"]
 	if !ok {
 		return fmt.Errorf("glyf: missing table")
@@ -0,0 +1,708 @@
+package font
+
+import (
+	"fmt"
+	"math"
+)
+
+////////////////////////////////////////////////////////////////
+
+type fvarAxis struct {
+	Tag          string
+	MinValue     float64
+	DefaultValue float64
+	MaxValue     float64
+	Flags        uint16 // 0x0001 is HIDDEN_AXIS
+	AxisNameID   NameID
+}
+
+type fvarInstance struct {
+	SubfamilyNameID  NameID
+	Flags            uint16
+	Coordinates      []float64 // user coordinates for each axis
+	PostScriptNameID NameID    // 0xFFFF if not set
+}
+
+type fvarTable struct {
+	Axes      []fvarAxis
+	Instances []fvarInstance
+}
+
+// InstanceCoords returns the user coordinates of the named instance by axis tag, which can be passed to SFNT.Instance.
+func (fvar *fvarTable) InstanceCoords(instance int) map[string]float64 {
+	if instance < 0 || len(fvar.Instances) <= instance {
+		return nil
+	}
+	coords := make(map[string]float64, len(fvar.Axes))
+	for i, axis := range fvar.Axes {
+		coords[axis.Tag] = fvar.Instances[instance].Coordinates[i]
+	}
+	return coords
+}
+
+func (sfnt *SFNT) parseFvar() error {
+	b, ok := sfnt.Tables["fvar"]
+	if !ok {
+		return fmt.Errorf("fvar: missing table")
+	} else if len(b) < 16 {
+		return fmt.Errorf("fvar: bad table")
+	}
+
+	r := NewBinaryReader(b)
+	majorVersion := r.ReadUint16()
+	minorVersion := r.ReadUint16()
+	if majorVersion != 1 || minorVersion != 0 {
+		return fmt.Errorf("fvar: bad version")
+	}
+	axesArrayOffset := r.ReadUint16()
+	_ = r.ReadUint16() // reserved
+	axisCount := r.ReadUint16()
+	axisSize := r.ReadUint16()
+	instanceCount := r.ReadUint16()
+	instanceSize := r.ReadUint16()
+	if axisSize < 20 || instanceSize < 4+4*axisCount {
+		return fmt.Errorf("fvar: bad table")
+	} else if uint32(len(b)) < uint32(axesArrayOffset)+uint32(axisCount)*uint32(axisSize)+uint32(instanceCount)*uint32(instanceSize) {
+		return fmt.Errorf("fvar: bad table")
+	}
+
+	sfnt.Fvar = &fvarTable{}
+	sfnt.Fvar.Axes = make([]fvarAxis, axisCount)
+	for i := range sfnt.Fvar.Axes {
+		r.Seek(uint32(axesArrayOffset) + uint32(i)*uint32(axisSize))
+		axis := &sfnt.Fvar.Axes[i]
+		axis.Tag = r.ReadString(4)
+		axis.MinValue = float64(r.ReadInt32()) / (1 << 16)
+		axis.DefaultValue = float64(r.ReadInt32()) / (1 << 16)
+		axis.MaxValue = float64(r.ReadInt32()) / (1 << 16)
+		axis.Flags = r.ReadUint16()
+		axis.AxisNameID = NameID(r.ReadUint16())
+		if axis.DefaultValue < axis.MinValue || axis.MaxValue < axis.DefaultValue {
+			return fmt.Errorf("fvar: bad axis %s", axis.Tag)
+		}
+	}
+
+	instancesOffset := uint32(axesArrayOffset) + uint32(axisCount)*uint32(axisSize)
+	sfnt.Fvar.Instances = make([]fvarInstance, instanceCount)
+	for i := range sfnt.Fvar.Instances {
+		r.Seek(instancesOffset + uint32(i)*uint32(instanceSize))
+		instance := &sfnt.Fvar.Instances[i]
+		instance.SubfamilyNameID = NameID(r.ReadUint16())
+		instance.Flags = r.ReadUint16()
+		instance.Coordinates = make([]float64, axisCount)
+		for j := range instance.Coordinates {
+			instance.Coordinates[j] = float64(r.ReadInt32()) / (1 << 16)
+		}
+		instance.PostScriptNameID = 0xFFFF
+		if 6+4*axisCount <= instanceSize {
+			instance.PostScriptNameID = NameID(r.ReadUint16())
+		}
+	}
+	return nil
+}
+
+// normalizeCoords returns the normalized coordinates in [-1,1] for the user coordinates by axis tag, applying the avar table if present. Missing axes are set to their default.
+func (sfnt *SFNT) normalizeCoords(coords map[string]float64) ([]float64, error) {
+	if sfnt.Fvar == nil {
+		return nil, fmt.Errorf("fvar: missing table")
+	}
+	for tag := range coords {
+		found := false
+		for _, axis := range sfnt.Fvar.Axes {
+			if axis.Tag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("fvar: unknown axis %s", tag)
+		}
+	}
+
+	norm := make([]float64, len(sfnt.Fvar.Axes))
+	for i, axis := range sfnt.Fvar.Axes {
+		v, ok := coords[axis.Tag]
+		if !ok {
+			continue
+		}
+		v = math.Max(axis.MinValue, math.Min(axis.MaxValue, v))
+		if v < axis.DefaultValue {
+			norm[i] = -(axis.DefaultValue - v) / (axis.DefaultValue - axis.MinValue)
+		} else if axis.DefaultValue < v {
+			norm[i] = (v - axis.DefaultValue) / (axis.MaxValue - axis.DefaultValue)
+		}
+		norm[i] = toF2Dot14(norm[i])
+	}
+
+	if b, err := sfnt.Table("avar"); err == nil {
+		// segment maps of avar version 1
+		r := NewBinaryReader(b)
+		majorVersion := r.ReadUint16()
+		_ = r.ReadUint16() // minorVersion
+		_ = r.ReadUint16() // reserved
+		axisCount := r.ReadUint16()
+		if majorVersion != 1 && majorVersion != 2 || int(axisCount) != len(norm) {
+			return nil, fmt.Errorf("avar: bad table")
+		}
+		for i := range norm {
+			positionMapCount := r.ReadUint16()
+			from := make([]float64, positionMapCount)
+			to := make([]float64, positionMapCount)
+			for j := range from {
+				from[j] = float64(r.ReadInt16()) / (1 << 14)
+				to[j] = float64(r.ReadInt16()) / (1 << 14)
+			}
+			if r.EOF() {
+				return nil, fmt.Errorf("avar: bad table")
+			}
+			for j := 1; j < len(from); j++ {
+				if from[j-1] <= norm[i] && norm[i] <= from[j] {
+					if from[j-1] == from[j] {
+						norm[i] = to[j]
+					} else {
+						t := (norm[i] - from[j-1]) / (from[j] - from[j-1])
+						norm[i] = toF2Dot14(to[j-1] + t*(to[j]-to[j-1]))
+					}
+					break
+				}
+			}
+		}
+	}
+	return norm, nil
+}
+
+func toF2Dot14(v float64) float64 {
+	return math.Round(v*(1<<14)) / (1 << 14)
+}
+
+// regionScalar returns the scalar of a variation region for the normalized coordinates, where each region axis has a start, peak, and end coordinate. For gvar tuples without an intermediate region, start and end are implied from the peak.
+func regionScalar(region [][3]float64, coords []float64) float64 {
+	scalar := 1.0
+	for i, axis := range region {
+		start, peak, end := axis[0], axis[1], axis[2]
+		if peak == 0.0 || end < start || peak < start || end < peak || start < 0.0 && 0.0 < end {
+			continue
+		}
+		coord := 0.0
+		if i < len(coords) {
+			coord = coords[i]
+		}
+		if coord == peak {
+			continue
+		} else if coord <= start || end <= coord {
+			return 0.0
+		} else if coord < peak {
+			scalar *= (coord - start) / (peak - start)
+		} else {
+			scalar *= (end - coord) / (end - peak)
+		}
+	}
+	return scalar
+}
+
+////////////////////////////////////////////////////////////////
+
+type itemVariationData struct {
+	regionIndices []uint16
+	deltas        [][]int32 // per item the deltas for each region
+}
+
+type itemVariationStore struct {
+	regions [][][3]float64 // per region the start, peak, and end coordinates for each axis
+	data    []itemVariationData
+}
+
+func parseItemVariationStore(b []byte) (*itemVariationStore, error) {
+	r := NewBinaryReader(b)
+	format := r.ReadUint16()
+	if format != 1 {
+		return nil, fmt.Errorf("bad format")
+	}
+	regionListOffset := r.ReadUint32()
+	dataCount := r.ReadUint16()
+	dataOffsets := make([]uint32, dataCount)
+	for i := range dataOffsets {
+		dataOffsets[i] = r.ReadUint32()
+	}
+	if r.EOF() || uint32(len(b)) < regionListOffset {
+		return nil, fmt.Errorf("bad data")
+	}
+
+	store := &itemVariationStore{}
+	if regionListOffset != 0 {
+		r.Seek(regionListOffset)
+		axisCount := r.ReadUint16()
+		regionCount := r.ReadUint16()
+		if r.Len() < 6*uint32(axisCount)*uint32(regionCount) {
+			return nil, fmt.Errorf("bad region list")
+		}
+		store.regions = make([][][3]float64, regionCount)
+		for i := range store.regions {
+			store.regions[i] = make([][3]float64, axisCount)
+			for j := range store.regions[i] {
+				for k := 0; k < 3; k++ {
+					store.regions[i][j][k] = float64(r.ReadInt16()) / (1 << 14)
+				}
+			}
+		}
+	}
+
+	store.data = make([]itemVariationData, dataCount)
+	for i, offset := range dataOffsets {
+		if offset == 0 {
+			continue
+		} else if uint32(len(b)) < offset {
+			return nil, fmt.Errorf("bad data offset")
+		}
+		r.Seek(offset)
+		itemCount := r.ReadUint16()
+		wordDeltaCount := r.ReadUint16()
+		regionIndexCount := r.ReadUint16()
+		longWords := wordDeltaCount&0x8000 != 0
+		wordCount := int(wordDeltaCount & 0x7FFF)
+		if int(regionIndexCount) < wordCount {
+			return nil, fmt.Errorf("bad word delta count")
+		}
+		data := &store.data[i]
+		data.regionIndices = make([]uint16, regionIndexCount)
+		for j := range data.regionIndices {
+			data.regionIndices[j] = r.ReadUint16()
+			if len(store.regions) <= int(data.regionIndices[j]) {
+				return nil, fmt.Errorf("bad region index")
+			}
+		}
+
+		rowSize := uint32(wordCount)*2 + uint32(int(regionIndexCount)-wordCount)
+		if longWords {
+			rowSize *= 2
+		}
+		if r.EOF() || r.Len() < uint32(itemCount)*rowSize {
+			return nil, fmt.Errorf("bad delta sets")
+		}
+		data.deltas = make([][]int32, itemCount)
+		for j := range data.deltas {
+			data.deltas[j] = make([]int32, regionIndexCount)
+			for k := range data.deltas[j] {
+				if k < wordCount {
+					if longWords {
+						data.deltas[j][k] = r.ReadInt32()
+					} else {
+						data.deltas[j][k] = int32(r.ReadInt16())
+					}
+				} else if longWords {
+					data.deltas[j][k] = int32(r.ReadInt16())
+				} else {
+					data.deltas[j][k] = int32(r.ReadInt8())
+				}
+			}
+		}
+	}
+	return store, nil
+}
+
+// RegionScalars returns the scalars of the regions referenced by the item variation data for the normalized coordinates.
+func (store *itemVariationStore) RegionScalars(outer uint16, coords []float64) ([]float64, error) {
+	if len(store.data) <= int(outer) {
+		return nil, fmt.Errorf("bad item variation data index %d", outer)
+	}
+	data := store.data[outer]
+	scalars := make([]float64, len(data.regionIndices))
+	for i, index := range data.regionIndices {
+		scalars[i] = regionScalar(store.regions[index], coords)
+	}
+	return scalars, nil
+}
+
+// Delta returns the interpolated delta for the item at the outer and inner indices for the normalized coordinates.
+func (store *itemVariationStore) Delta(outer, inner uint16, coords []float64) float64 {
+	if outer == 0xFFFF && inner == 0xFFFF {
+		// no variation data
+		return 0.0
+	} else if len(store.data) <= int(outer) || len(store.data[outer].deltas) <= int(inner) {
+		return 0.0
+	}
+	data := store.data[outer]
+	delta := 0.0
+	for i, index := range data.regionIndices {
+		if d := data.deltas[inner][i]; d != 0 {
+			delta += float64(d) * regionScalar(store.regions[index], coords)
+		}
+	}
+	return delta
+}
+
+// deltaSetIndexMap maps glyph IDs or other indices to outer and inner indices of an item variation store.
+type deltaSetIndexMap struct {
+	outer, inner []uint16
+}
+
+func parseDeltaSetIndexMap(b []byte) (*deltaSetIndexMap, error) {
+	r := NewBinaryReader(b)
+	format := r.ReadUint8()
+	entryFormat := r.ReadUint8()
+	var mapCount uint32
+	if format == 0 {
+		mapCount = uint32(r.ReadUint16())
+	} else if format == 1 {
+		mapCount = r.ReadUint32()
+	} else {
+		return nil, fmt.Errorf("bad format")
+	}
+	innerBitCount := uint32(entryFormat&0x0F) + 1
+	entrySize := uint32(entryFormat&0x30)>>4 + 1
+	if r.EOF() || r.Len()/entrySize < mapCount {
+		return nil, fmt.Errorf("bad data")
+	}
+
+	m := &deltaSetIndexMap{
+		outer: make([]uint16, mapCount),
+		inner: make([]uint16, mapCount),
+	}
+	for i := uint32(0); i < mapCount; i++ {
+		var entry uint32
+		for j := uint32(0); j < entrySize; j++ {
+			entry = entry<<8 | uint32(r.ReadUint8())
+		}
+		m.outer[i] = uint16(entry >> innerBitCount)
+		m.inner[i] = uint16(entry & (1<<innerBitCount - 1))
+	}
+	return m, nil
+}
+
+// Get returns the outer and inner indices for the given index, indices past the end of the map use the last entry. A nil map is the identity mapping with an outer index of zero.
+func (m *deltaSetIndexMap) Get(i uint32) (uint16, uint16) {
+	if m == nil {
+		return 0, uint16(i)
+	} else if len(m.outer) == 0 {
+		return 0xFFFF, 0xFFFF
+	} else if uint32(len(m.outer)) <= i {
+		i = uint32(len(m.outer)) - 1
+	}
+	return m.outer[i], m.inner[i]
+}
+
+////////////////////////////////////////////////////////////////
+
+// tupleVariation is a single tuple of a tuple variation store with a non-zero scalar for the current coordinates.
+type tupleVariation struct {
+	scalar float64
+	points []uint16   // referenced points, nil for all points
+	deltas [2][]int32 // x and y deltas for the referenced points, y is nil for cvar
+}
+
+// parseTupleVariations parses the tuple variation headers and serialized data of gvar or cvar and returns the tuples that apply to the normalized coordinates. The headers start at the reader's position and the serialized data at dataOffset of b.
+func parseTupleVariations(b []byte, r *BinaryReader, tupleVariationCount uint16, dataOffset uint32, sharedTuples [][]float64, coords []float64, numPoints int, dimensions int) ([]tupleVariation, error) {
+	axisCount := len(coords)
+	count := int(tupleVariationCount & 0x0FFF)
+	if uint32(len(b)) < dataOffset {
+		return nil, fmt.Errorf("bad data offset")
+	}
+	data := NewBinaryReader(b[dataOffset:])
+
+	var sharedPoints []uint16
+	if tupleVariationCount&0x8000 != 0 { // SHARED_POINT_NUMBERS
+		var err error
+		if sharedPoints, err = readPackedPointNumbers(data, numPoints); err != nil {
+			return nil, err
+		}
+	}
+
+	tuples := []tupleVariation{}
+	for i := 0; i < count; i++ {
+		variationDataSize := r.ReadUint16()
+		tupleIndex := r.ReadUint16()
+		region := make([][3]float64, axisCount)
+		if tupleIndex&0x8000 != 0 { // EMBEDDED_PEAK_TUPLE
+			for j := range region {
+				region[j][1] = float64(r.ReadInt16()) / (1 << 14)
+			}
+		} else if int(tupleIndex&0x0FFF) < len(sharedTuples) {
+			for j := range region {
+				region[j][1] = sharedTuples[tupleIndex&0x0FFF][j]
+			}
+		} else {
+			return nil, fmt.Errorf("bad shared tuple index")
+		}
+		if tupleIndex&0x4000 != 0 { // INTERMEDIATE_REGION
+			for j := range region {
+				region[j][0] = float64(r.ReadInt16()) / (1 << 14)
+			}
+			for j := range region {
+				region[j][2] = float64(r.ReadInt16()) / (1 << 14)
+			}
+		} else {
+			for j := range region {
+				region[j][0] = math.Min(0.0, region[j][1])
+				region[j][2] = math.Max(0.0, region[j][1])
+			}
+		}
+		if r.EOF() {
+			return nil, fmt.Errorf("bad tuple variation header")
+		}
+
+		start := data.Pos()
+		if data.Len() < uint32(variationDataSize) {
+			return nil, fmt.Errorf("bad variation data size")
+		}
+		scalar := regionScalar(region, coords)
+		if scalar == 0.0 {
+			data.Seek(start + uint32(variationDataSize))
+			continue
+		}
+
+		tuple := tupleVariation{
+			scalar: scalar,
+			points: sharedPoints,
+		}
+		tupleData := NewBinaryReader(data.ReadBytes(uint32(variationDataSize)))
+		if tupleIndex&0x2000 != 0 { // PRIVATE_POINT_NUMBERS
+			var err error
+			if tuple.points, err = readPackedPointNumbers(tupleData, numPoints); err != nil {
+				return nil, err
+			}
+		}
+		n := numPoints
+		if tuple.points != nil {
+			n = len(tuple.points)
+		}
+		for j := 0; j < dimensions; j++ {
+			var err error
+			if tuple.deltas[j], err = readPackedDeltas(tupleData, n); err != nil {
+				return nil, err
+			}
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+// readPackedPointNumbers reads packed point numbers, it returns nil if all points are referenced.
+func readPackedPointNumbers(r *BinaryReader, numPoints int) ([]uint16, error) {
+	count := uint16(r.ReadUint8())
+	if count == 0 {
+		return nil, nil
+	} else if count&0x80 != 0 {
+		count = (count&0x7F)<<8 | uint16(r.ReadUint8())
+	}
+
+	points := make([]uint16, 0, count)
+	point := uint16(0)
+	for len(points) < int(count) {
+		control := r.ReadUint8()
+		runCount := int(control&0x7F) + 1
+		for i := 0; i < runCount && len(points) < int(count); i++ {
+			if control&0x80 != 0 { // POINTS_ARE_WORDS
+				point += r.ReadUint16()
+			} else {
+				point += uint16(r.ReadUint8())
+			}
+			points = append(points, point)
+		}
+		if r.EOF() {
+			return nil, fmt.Errorf("bad packed point numbers")
+		}
+	}
+	for _, point := range points {
+		if numPoints <= int(point) {
+			return nil, fmt.Errorf("bad point number")
+		}
+	}
+	return points, nil
+}
+
+func readPackedDeltas(r *BinaryReader, n int) ([]int32, error) {
+	deltas := make([]int32, 0, n)
+	for len(deltas) < n {
+		control := r.ReadUint8()
+		runCount := int(control&0x3F) + 1
+		for i := 0; i < runCount && len(deltas) < n; i++ {
+			switch control & 0xC0 {
+			case 0x80: // DELTAS_ARE_ZERO
+				deltas = append(deltas, 0)
+			case 0x40: // DELTAS_ARE_WORDS
+				deltas = append(deltas, int32(r.ReadInt16()))
+			case 0xC0: // DELTAS_ARE_LONGS
+				deltas = append(deltas, r.ReadInt32())
+			default:
+				deltas = append(deltas, int32(r.ReadInt8()))
+			}
+		}
+		if r.EOF() {
+			return nil, fmt.Errorf("bad packed deltas")
+		}
+	}
+	return deltas, nil
+}
+
+////////////////////////////////////////////////////////////////
+
+type gvarTable struct {
+	axisCount    int
+	sharedTuples [][]float64
+	offsets      []uint32
+	data         []byte
+}
+
+func (sfnt *SFNT) parseGvar() (*gvarTable, error) {
+	b, err := sfnt.Table("gvar")
+	if err != nil {
+		return nil, err
+	} else if len(b) < 20 {
+		return nil, fmt.Errorf("gvar: bad table")
+	}
+
+	r := NewBinaryReader(b)
+	majorVersion := r.ReadUint16()
+	_ = r.ReadUint16() // minorVersion
+	if majorVersion != 1 {
+		return nil, fmt.Errorf("gvar: bad version")
+	}
+	gvar := &gvarTable{}
+	gvar.axisCount = int(r.ReadUint16())
+	sharedTupleCount := r.ReadUint16()
+	sharedTuplesOffset := r.ReadUint32()
+	glyphCount := r.ReadUint16()
+	flags := r.ReadUint16()
+	glyphVariationDataArrayOffset := r.ReadUint32()
+	if sfnt.Fvar == nil || gvar.axisCount != len(sfnt.Fvar.Axes) {
+		return nil, fmt.Errorf("gvar: bad axis count")
+	}
+
+	gvar.offsets = make([]uint32, uint32(glyphCount)+1)
+	for i := range gvar.offsets {
+		if flags&0x0001 != 0 {
+			gvar.offsets[i] = r.ReadUint32()
+		} else {
+			gvar.offsets[i] = 2 * uint32(r.ReadUint16())
+		}
+	}
+	if r.EOF() || uint32(len(b)) < glyphVariationDataArrayOffset {
+		return nil, fmt.Errorf("gvar: bad table")
+	}
+	gvar.data = b[glyphVariationDataArrayOffset:]
+	if uint32(len(gvar.data)) < gvar.offsets[glyphCount] {
+		return nil, fmt.Errorf("gvar: bad table")
+	}
+
+	r.Seek(sharedTuplesOffset)
+	gvar.sharedTuples = make([][]float64, sharedTupleCount)
+	for i := range gvar.sharedTuples {
+		gvar.sharedTuples[i] = make([]float64, gvar.axisCount)
+		for j := range gvar.sharedTuples[i] {
+			gvar.sharedTuples[i][j] = float64(r.ReadInt16()) / (1 << 14)
+		}
+	}
+	if r.EOF() {
+		return nil, fmt.Errorf("gvar: bad shared tuples")
+	}
+	return gvar, nil
+}
+
+// Deltas returns the interpolated deltas of the glyph's points, including the four phantom points, for the normalized coordinates. The original coordinates and contour end points are used to infer the deltas of untouched points of simple glyphs.
+func (gvar *gvarTable) Deltas(glyphID uint16, coords []float64, xs, ys []float64, endPoints []uint16) ([]float64, []float64, error) {
+	numPoints := len(xs)
+	dx, dy := make([]float64, numPoints), make([]float64, numPoints)
+	if len(gvar.offsets) <= int(glyphID)+1 {
+		return dx, dy, nil
+	}
+	start, end := gvar.offsets[glyphID], gvar.offsets[glyphID+1]
+	if end <= start {
+		return dx, dy, nil
+	} else if end < start || uint32(len(gvar.data)) < end {
+		return nil, nil, fmt.Errorf("gvar: bad glyph variation data")
+	}
+
+	b := gvar.data[start:end]
+	r := NewBinaryReader(b)
+	tupleVariationCount := r.ReadUint16()
+	dataOffset := r.ReadUint16()
+	tuples, err := parseTupleVariations(b, r, tupleVariationCount, uint32(dataOffset), gvar.sharedTuples, coords, numPoints, 2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gvar: glyph %d: %w", glyphID, err)
+	}
+
+	tx, ty := make([]float64, numPoints), make([]float64, numPoints)
+	for _, tuple := range tuples {
+		if tuple.points == nil {
+			for i := 0; i < numPoints; i++ {
+				dx[i] += tuple.scalar * float64(tuple.deltas[0][i])
+				dy[i] += tuple.scalar * float64(tuple.deltas[1][i])
+			}
+			continue
+		}
+
+		touched := make([]bool, numPoints)
+		for i := range tx {
+			tx[i], ty[i] = 0.0, 0.0
+		}
+		for i, point := range tuple.points {
+			touched[point] = true
+			tx[point] = float64(tuple.deltas[0][i])
+			ty[point] = float64(tuple.deltas[1][i])
+		}
+		inferDeltas(tx, xs, touched, endPoints)
+		inferDeltas(ty, ys, touched, endPoints)
+		for i := 0; i < numPoints; i++ {
+			dx[i] += tuple.scalar * tx[i]
+			dy[i] += tuple.scalar * ty[i]
+		}
+	}
+	return dx, dy, nil
+}
+
+// inferDeltas interpolates the deltas of untouched points in each contour from the nearest touched points before and after (IUP), see https://docs.microsoft.com/en-us/typography/opentype/spec/gvar#inferred-deltas-for-un-referenced-point-numbers.
+func inferDeltas(deltas, coords []float64, touched []bool, endPoints []uint16) {
+	start := 0
+	for _, endPoint := range endPoints {
+		end := int(endPoint) + 1
+		if len(coords) < end {
+			return
+		}
+
+		first := -1
+		for i := start; i < end; i++ {
+			if touched[i] {
+				first = i
+				break
+			}
+		}
+		if first == -1 {
+			// no touched points in contour, deltas remain zero
+			start = end
+			continue
+		}
+
+		prev := first
+		for k := 1; k <= end-start; k++ {
+			i := start + (first-start+k)%(end-start)
+			if !touched[i] {
+				continue
+			}
+			// interpolate points strictly between prev and i, wrapping around the contour
+			for j := start + (prev-start+1)%(end-start); j != i; j = start + (j-start+1)%(end-start) {
+				deltas[j] = interpolateDelta(coords[j], coords[prev], coords[i], deltas[prev], deltas[i])
+			}
+			prev = i
+		}
+		start = end
+	}
+}
+
+func interpolateDelta(coord, coord1, coord2, delta1, delta2 float64) float64 {
+	if coord2 < coord1 {
+		coord1, coord2 = coord2, coord1
+		delta1, delta2 = delta2, delta1
+	}
+	if coord1 == coord2 {
+		if delta1 == delta2 {
+			return delta1
+		}
+		return 0.0
+	} else if coord <= coord1 {
+		return delta1
+	} else if coord2 <= coord {
+		return delta2
+	}
+	t := (coord - coord1) / (coord2 - coord1)
+	return delta1 + t*(delta2-delta1)
+}
@@ -7,13 +7,17 @@ import (
 	"time"
 )
 
-// Write writes out the SFNT file.
+// Write writes out the SFNT file. For fonts parsed with ParseSFNTReaderAt, tables that are not yet loaded are read from the source and tables that fail to be read are omitted.
 func (sfnt *SFNT) Write() []byte {
+	sfnt.mu.Lock()
+	defer sfnt.mu.Unlock()
+
 	tags := []string{}
-	for tag := range sfnt.Tables {
-		tags = append(tags, tag)
+	for _, tag := range sfnt.tableTags() {
+		if _, err := sfnt.table(tag); err == nil {
+			tags = append(tags, tag)
+		}
 	}
-	sort.Strings(tags)
 
 	// write header
 	w := NewBinaryWriter([]byte{})
@@ -72,15 +76,32 @@ func (sfnt *SFNT) Write() []byte {
 	return buf
 }
 
-// Subset regenerates a font file containing only the passed glyphIDs, thereby resulting in a significant size reduction. The glyphIDs will apear in the specified order in the file, and their dependencies are added to the end. It returns the compressed font file and the glyphIDs in the order in which they appear.
+// Subset regenerates a font file containing only the passed glyphIDs, thereby resulting in a significant size reduction. The glyphIDs will apear in the specified order in the file, and their dependencies are added to the end. It returns the compressed font file and the glyphIDs in the order in which they appear. It returns nil if the font could not be subsetted, or if EnforceEmbedding is set and the font's license does not allow embedding or subsetting, use SubsetChecked to obtain the error.
 func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
+	b, glyphIDs, err := sfnt.SubsetChecked(glyphIDs)
+	if err != nil {
+		return nil, nil
+	}
+	return b, glyphIDs
+}
+
+// SubsetChecked is like Subset but returns an error if the font could not be subsetted, or if EnforceEmbedding is set and the font's license does not allow embedding or subsetting.
+func (sfnt *SFNT) SubsetChecked(glyphIDs []uint16) ([]byte, []uint16, error) {
 	if sfnt.IsCFF {
 		// TODO: support CFF
+		if err := sfnt.CheckEmbedding(false); err != nil {
+			return nil, nil, err
+		}
 		glyphIDs = glyphIDs[:0]
 		for glyphID := uint16(0); glyphID < sfnt.Maxp.NumGlyphs; glyphID++ {
 			glyphIDs = append(glyphIDs, glyphID)
 		}
-		return sfnt.Data, glyphIDs
+		if sfnt.Data == nil {
+			return sfnt.Write(), glyphIDs, nil
+		}
+		return sfnt.Data, glyphIDs, nil
+	} else if err := sfnt.CheckEmbedding(true); err != nil {
+		return nil, nil, err
 	}
 
 	glyphMap := make(map[uint16]uint16, len(glyphIDs))
@@ -93,7 +114,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 	for i := 0; i < origLen; i++ {
 		deps, err := sfnt.Glyf.Dependencies(glyphIDs[i], 0)
 		if err != nil {
-			panic(err)
+			return nil, nil, err
 		}
 		for _, glyphID := range deps[1:] {
 			if _, ok := glyphMap[glyphID]; !ok {
@@ -109,7 +130,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 	if sfnt.IsTrueType {
 		tags = append(tags, "glyf", "loca")
 	} else if sfnt.IsCFF {
-		if _, ok := sfnt.Tables["CFF2"]; ok {
+		if sfnt.HasTable("CFF2") {
 			tags = append(tags, "CFF2")
 		} else {
 			tags = append(tags, "CFF ")
@@ -118,7 +139,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 
 	// preserve tables
 	for _, tag := range []string{"cvt ", "fpgm", "prep"} {
-		if _, ok := sfnt.Tables[tag]; ok {
+		if _, err := sfnt.Table(tag); err == nil {
 			tags = append(tags, tag)
 		}
 	}
@@ -151,6 +172,21 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 	}
 	sort.Strings(tags)
 
+	// read the tables that are copied, since Release may remove them concurrently
+	tables := make(map[string][]byte, len(tags))
+	for _, tag := range tags {
+		switch tag {
+		case "glyf", "loca", "hmtx", "cmap", "kern":
+			// regenerated from the parsed tables
+		default:
+			b, err := sfnt.Table(tag)
+			if err != nil {
+				return nil, nil, err
+			}
+			tables[tag] = b
+		}
+	}
+
 	// write header
 	w := NewBinaryWriter([]byte{})
 	if sfnt.IsTrueType {
@@ -177,7 +213,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 		offsets[i] = w.Len()
 		switch tag {
 		case "head":
-			head := sfnt.Tables["head"]
+			head := tables["head"]
 			w.WriteBytes(head[:8])
 			checksumAdjustmentPos = w.Len()
 			w.WriteUint32(0) // checksumAdjustment
@@ -256,7 +292,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 				w.WriteUint32(pos)
 			}
 		case "maxp":
-			maxp := sfnt.Tables["maxp"]
+			maxp := tables["maxp"]
 			w.WriteBytes(maxp[:4])
 			w.WriteUint16(uint16(len(glyphIDs))) // numGlyphs
 			w.WriteBytes(maxp[6:])
@@ -268,7 +304,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 				}
 				numberOfHMetrics++
 			}
-			hhea := sfnt.Tables["hhea"]
+			hhea := tables["hhea"]
 			w.WriteBytes(hhea[:34])
 			w.WriteUint16(numberOfHMetrics) // numberOfHMetrics
 		case "hmtx":
@@ -279,7 +315,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 				w.WriteInt16(sfnt.Hmtx.LeftSideBearing(glyphID))
 			}
 		case "post":
-			post := sfnt.Tables["post"]
+			post := tables["post"]
 			w.WriteBytes(post[:32])
 			if binary.BigEndian.Uint32(post) == 0x00020000 {
 				w.WriteUint16(uint16(len(glyphIDs))) // numGlyphs
@@ -378,7 +414,7 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 		default:
 			// TODO: compress name table
 			// TODO: GDEF, GSUB, GPOS tables for ligatures
-			w.WriteBytes(sfnt.Tables[tag])
+			w.WriteBytes(tables[tag])
 		}
 		lengths[i] = w.Len() - offsets[i]
 
@@ -400,5 +436,5 @@ func (sfnt *SFNT) Subset(glyphIDs []uint16) ([]byte, []uint16) {
 		binary.BigEndian.PutUint32(buf[pos+12:], lengths[i])
 	}
 	binary.BigEndian.PutUint32(buf[checksumAdjustmentPos:], 0xB1B0AFBA-calcChecksum(buf))
-	return buf, glyphIDs
+	return buf, glyphIDs, nil
 }
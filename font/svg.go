@@ -0,0 +1,160 @@
+package font
+
+import (
+	"math"
+	"strconv"
+)
+
+// SVGPather is a Pather that records path data as used in the d attribute of SVG path elements. Coordinates are written as given, without flipping the y-axis.
+type SVGPather struct {
+	Precision int  // number of decimals, negative for the shortest representation that is exact
+	Relative  bool // use relative commands
+
+	b            []byte
+	cmd          byte
+	x0, y0, x, y float64 // start of the subpath and current position, rounded
+}
+
+// NewSVGPather returns a new SVG pather that writes coordinates with the given number of decimals using either absolute or relative commands.
+func NewSVGPather(precision int, relative bool) *SVGPather {
+	return &SVGPather{
+		Precision: precision,
+		Relative:  relative,
+	}
+}
+
+// MoveTo adds a move to (x,y).
+func (p *SVGPather) MoveTo(x, y float64) {
+	p.command('M')
+	x, y = p.point(x, y)
+	p.x0, p.y0 = x, y
+	p.cmd = p.cmd + 'L' - 'M' // implicit line command after a move
+}
+
+// LineTo adds a line to (x,y).
+func (p *SVGPather) LineTo(x, y float64) {
+	p.command('L')
+	p.point(x, y)
+}
+
+// QuadTo adds a quadratic Bézier with control point (cpx,cpy) to (x,y).
+func (p *SVGPather) QuadTo(cpx, cpy, x, y float64) {
+	p.command('Q')
+	x0, y0 := p.x, p.y
+	p.coordinate(cpx, x0)
+	p.coordinate(cpy, y0)
+	p.point(x, y)
+}
+
+// CubeTo adds a cubic Bézier with control points (cpx1,cpy1) and (cpx2,cpy2) to (x,y).
+func (p *SVGPather) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	p.command('C')
+	x0, y0 := p.x, p.y
+	p.coordinate(cpx1, x0)
+	p.coordinate(cpy1, y0)
+	p.coordinate(cpx2, x0)
+	p.coordinate(cpy2, y0)
+	p.point(x, y)
+}
+
+// Close closes the current subpath.
+func (p *SVGPather) Close() {
+	p.command('Z')
+	p.x, p.y = p.x0, p.y0
+	p.cmd = 0
+}
+
+// String returns the path data.
+func (p *SVGPather) String() string {
+	return string(p.b)
+}
+
+// Bytes returns the path data.
+func (p *SVGPather) Bytes() []byte {
+	return p.b
+}
+
+// Reset clears the path data.
+func (p *SVGPather) Reset() {
+	*p = SVGPather{
+		Precision: p.Precision,
+		Relative:  p.Relative,
+		b:         p.b[:0],
+	}
+}
+
+// command writes the command if it differs from the previous one.
+func (p *SVGPather) command(cmd byte) {
+	if p.Relative {
+		cmd += 'a' - 'A'
+	}
+	if cmd != p.cmd || cmd == 'M' || cmd == 'm' {
+		p.b = append(p.b, cmd)
+		p.cmd = cmd
+	}
+}
+
+// point writes the end point of a segment and updates the current position.
+func (p *SVGPather) point(x, y float64) (float64, float64) {
+	x = p.coordinate(x, p.x)
+	y = p.coordinate(y, p.y)
+	p.x, p.y = x, y
+	return x, y
+}
+
+// coordinate writes a rounded coordinate, relative to the reference for relative commands, and returns the rounded coordinate.
+func (p *SVGPather) coordinate(v, ref float64) float64 {
+	v = p.round(v)
+	w := v
+	if p.Relative {
+		w = p.round(v - ref)
+	}
+
+	s := strconv.FormatFloat(w, 'f', p.Precision, 64)
+	if 0 < p.Precision {
+		for s[len(s)-1] == '0' {
+			s = s[:len(s)-1]
+		}
+		if s[len(s)-1] == '.' {
+			s = s[:len(s)-1]
+		}
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	if 2 < len(s) && s[0] == '0' && s[1] == '.' {
+		s = s[1:]
+	} else if 3 < len(s) && s[0] == '-' && s[1] == '0' && s[2] == '.' {
+		s = "-" + s[2:]
+	}
+
+	// separate numbers unless the sign or decimal point already separates them
+	if last := p.b[len(p.b)-1]; '0' <= last && last <= '9' || last == '.' {
+		if s[0] != '-' && !(s[0] == '.' && p.hasDecimalPoint()) {
+			p.b = append(p.b, ' ')
+		}
+	}
+	p.b = append(p.b, s...)
+	return v
+}
+
+// hasDecimalPoint returns true if the last number written contains a decimal point, so that a following number starting with a decimal point needs no separator.
+func (p *SVGPather) hasDecimalPoint() bool {
+	for i := len(p.b) - 1; 0 <= i; i-- {
+		c := p.b[i]
+		if c == '.' {
+			return true
+		} else if c < '0' || '9' < c {
+			return false
+		}
+	}
+	return false
+}
+
+func (p *SVGPather) round(v float64) float64 {
+	if p.Precision < 0 {
+		return v
+	}
+	scale := math.Pow10(p.Precision)
+	return math.Round(v*scale) / scale
+}
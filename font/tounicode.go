@@ -0,0 +1,115 @@
+package font
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// ToUnicodeMap maps glyphs to the text they represent, built from the clusters of shaped text. Unlike the cmap table, it maps ligatures to all their characters and includes glyphs that are not in the cmap table, such as contextual forms. Glyphs are identified by their glyph ID or by another character code, such as the CID in a PDF font.
+type ToUnicodeMap struct {
+	text   map[uint16]string
+	single map[uint16]bool // mapped from a cluster of a single glyph
+}
+
+// NewToUnicodeMap returns an empty glyph-to-text mapping.
+func NewToUnicodeMap() *ToUnicodeMap {
+	return &ToUnicodeMap{
+		text:   map[uint16]string{},
+		single: map[uint16]bool{},
+	}
+}
+
+// AddCluster maps the glyphs of a cluster to the cluster's text. A cluster of a single glyph maps the glyph to the entire text, which takes precedence over mappings from clusters of multiple glyphs. Otherwise the text is mapped to the first glyph of the cluster if it is not mapped yet, so that the text is extracted once. The first mapping of each glyph is kept.
+func (m *ToUnicodeMap) AddCluster(glyphIDs []uint16, text string) {
+	if len(glyphIDs) == 0 || text == "" {
+		return
+	} else if len(glyphIDs) == 1 {
+		if !m.single[glyphIDs[0]] {
+			m.text[glyphIDs[0]] = text
+			m.single[glyphIDs[0]] = true
+		}
+	} else if _, ok := m.text[glyphIDs[0]]; !ok {
+		m.text[glyphIDs[0]] = text
+	}
+}
+
+// Text returns the text of a glyph.
+func (m *ToUnicodeMap) Text(glyphID uint16) (string, bool) {
+	text, ok := m.text[glyphID]
+	return text, ok
+}
+
+// Len returns the number of mapped glyphs.
+func (m *ToUnicodeMap) Len() int {
+	return len(m.text)
+}
+
+// CMap returns the mapping as an Adobe ToUnicode CMap with two-byte codes, to be embedded as a stream in a PDF. Code returns the character code of each glyph, or is nil to use the glyph IDs. Consecutive codes that map to consecutive characters are written as ranges.
+func (m *ToUnicodeMap) CMap(code func(uint16) uint16) []byte {
+	type mapping struct {
+		code uint16
+		text []uint16
+	}
+	mappings := make([]mapping, 0, len(m.text))
+	for glyphID, text := range m.text {
+		if code != nil {
+			glyphID = code(glyphID)
+		}
+		mappings = append(mappings, mapping{glyphID, utf16.Encode([]rune(text))})
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].code < mappings[j].code })
+
+	// ranges must be within the same last byte of the code and of the text, and only the last byte of the text is incremented
+	chars, ranges := [][2]int{}, [][2]int{} // start and end indices into mappings
+	for i := 0; i < len(mappings); {
+		j := i + 1
+		if len(mappings[i].text) == 1 {
+			for j < len(mappings) && len(mappings[j].text) == 1 && mappings[j].code == mappings[j-1].code+1 && mappings[j].code&0xFF != 0 && mappings[j].text[0] == mappings[j-1].text[0]+1 && mappings[j].text[0]&0xFF != 0 {
+				j++
+			}
+		}
+		if j-i == 1 {
+			chars = append(chars, [2]int{i, j})
+		} else {
+			ranges = append(ranges, [2]int{i, j})
+		}
+		i = j
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for k := 0; k < len(chars); k += 100 {
+		n := len(chars) - k
+		if 100 < n {
+			n = 100
+		}
+		fmt.Fprintf(b, "%d beginbfchar\n", n)
+		for _, char := range chars[k : k+n] {
+			fmt.Fprintf(b, "<%04X> <%s>\n", mappings[char[0]].code, cmapHex(mappings[char[0]].text))
+		}
+		b.WriteString("endbfchar\n")
+	}
+	for k := 0; k < len(ranges); k += 100 {
+		n := len(ranges) - k
+		if 100 < n {
+			n = 100
+		}
+		fmt.Fprintf(b, "%d beginbfrange\n", n)
+		for _, r := range ranges[k : k+n] {
+			fmt.Fprintf(b, "<%04X> <%04X> <%s>\n", mappings[r[0]].code, mappings[r[1]-1].code, cmapHex(mappings[r[0]].text))
+		}
+		b.WriteString("endbfrange\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+func cmapHex(text []uint16) string {
+	b := make([]byte, 0, 4*len(text))
+	for _, c := range text {
+		b = append(b, fmt.Sprintf("%04X", c)...)
+	}
+	return string(b)
+}
@@ -0,0 +1,1118 @@
+package font
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Type1 is a PostScript Type 1 font, read from a PFA or PFB file, see https://adobe-type-tools.github.io/font-tech-notes/pdfs/T1_SPEC.pdf. Hints are ignored.
+type Type1 struct {
+	FontName           string
+	FamilyName         string
+	FullName           string
+	Weight             string
+	Notice             string
+	Version            string
+	ItalicAngle        float64
+	IsFixedPitch       bool
+	UnderlinePosition  float64
+	UnderlineThickness float64
+	FontMatrix         [6]float64
+	FontBBox           [4]float64
+	Encoding           [256]string // glyph names by character code, empty for undefined codes
+
+	// AFM holds optional font metrics, which take precedence over the widths of the charstrings and add kerning and vertical metrics. Set it to the result of ParseAFM.
+	AFM *AFM
+
+	glyphNames  []string
+	glyphIDs    map[string]uint16
+	charStrings [][]byte // decrypted
+	subrs       [][]byte // decrypted
+	opts        ParseOptions
+}
+
+// ParseType1 parses a Type 1 font in the PFB (binary) or PFA (ASCII) format. Optionally parse options can be passed to limit the number of glyphs and the nesting depth of subroutine calls.
+func ParseType1(b []byte, opts ...ParseOptions) (*Type1, error) {
+	cleartext, encrypted, err := type1Segments(b)
+	if err != nil {
+		return nil, err
+	}
+
+	t1 := &Type1{
+		UnderlinePosition:  -100,
+		UnderlineThickness: 50,
+		FontMatrix:         [6]float64{0.001, 0.0, 0.0, 0.001, 0.0, 0.0},
+		glyphIDs:           map[string]uint16{},
+		opts:               parseOptions(opts),
+	}
+	if err := t1.parseCleartext(cleartext); err != nil {
+		return nil, err
+	}
+	if err := t1.parsePrivate(decryptType1(encrypted, 55665, 4)); err != nil {
+		return nil, err
+	}
+	return t1, nil
+}
+
+// type1Segments returns the cleartext and the eexec encrypted part of a PFB or PFA file.
+func type1Segments(b []byte) ([]byte, []byte, error) {
+	if 2 <= len(b) && b[0] == 0x80 {
+		// PFB
+		var cleartext, encrypted []byte
+		r := NewBinaryReader(b)
+		for {
+			if r.Len() < 2 || r.ReadUint8() != 0x80 {
+				return nil, nil, fmt.Errorf("Type1: bad PFB segment")
+			}
+			segmentType := r.ReadUint8()
+			if segmentType == 3 {
+				break
+			} else if r.Len() < 4 {
+				return nil, nil, fmt.Errorf("Type1: bad PFB segment")
+			}
+			length := r.ReadUint32LE()
+			if r.Len() < length {
+				return nil, nil, fmt.Errorf("Type1: bad PFB segment length")
+			}
+			data := r.ReadBytes(length)
+			if segmentType == 1 {
+				if encrypted == nil {
+					cleartext = append(cleartext, data...)
+				}
+			} else if segmentType == 2 {
+				encrypted = append(encrypted, data...)
+			} else {
+				return nil, nil, fmt.Errorf("Type1: bad PFB segment type")
+			}
+			if r.Len() == 0 {
+				break
+			}
+		}
+		if len(encrypted) == 0 {
+			return nil, nil, fmt.Errorf("Type1: missing encrypted part")
+		}
+		return cleartext, encrypted, nil
+	}
+
+	// PFA
+	i := bytes.Index(b, []byte("eexec"))
+	if i == -1 {
+		return nil, nil, fmt.Errorf("Type1: missing encrypted part")
+	}
+	cleartext, encrypted := b[:i], b[i+5:]
+	for 0 < len(encrypted) && isPSWhitespace(encrypted[0]) {
+		encrypted = encrypted[1:]
+	}
+	if 4 <= len(encrypted) && isHexDigit(encrypted[0]) && isHexDigit(encrypted[1]) && isHexDigit(encrypted[2]) && isHexDigit(encrypted[3]) {
+		// hexadecimal encoding, ends at the first non-hexadecimal character other than whitespace such as the zeros trailer
+		digits := make([]byte, 0, len(encrypted))
+		for _, c := range encrypted {
+			if isHexDigit(c) {
+				digits = append(digits, c)
+			} else if !isPSWhitespace(c) {
+				break
+			}
+		}
+		digits = digits[:len(digits)&^1]
+		encrypted = make([]byte, len(digits)/2)
+		if _, err := hex.Decode(encrypted, digits); err != nil {
+			return nil, nil, fmt.Errorf("Type1: %w", err)
+		}
+	}
+	if len(encrypted) < 4 {
+		return nil, nil, fmt.Errorf("Type1: missing encrypted part")
+	}
+	return cleartext, encrypted, nil
+}
+
+// decryptType1 decrypts eexec (r=55665) or charstring (r=4330) encrypted data and discards the first n bytes.
+func decryptType1(b []byte, r uint16, n int) []byte {
+	const c1, c2 = 52845, 22719
+	dst := make([]byte, len(b))
+	for i, c := range b {
+		dst[i] = c ^ byte(r>>8)
+		r = (uint16(c)+r)*c1 + c2
+	}
+	if len(dst) < n {
+		return []byte{}
+	}
+	return dst[n:]
+}
+
+func (t1 *Type1) parseCleartext(b []byte) error {
+	customEncoding := false
+	l := newPSLexer(b)
+	for {
+		tok := l.Next()
+		if tok.kind == psEOF {
+			break
+		} else if tok.kind == psKeyword && tok.s == "dup" && customEncoding {
+			// dup code /name put
+			code, name, put := l.Next(), l.Next(), l.Next()
+			if code.kind == psNumber && name.kind == psName && put.kind == psKeyword && put.s == "put" && 0 <= code.num && code.num < 256 {
+				t1.Encoding[int(code.num)] = name.s
+			}
+			continue
+		} else if tok.kind != psName {
+			continue
+		}
+
+		switch tok.s {
+		case "FontName":
+			if v := l.Next(); v.kind == psName {
+				t1.FontName = v.s
+			}
+		case "FamilyName", "FullName", "Weight", "Notice", "version":
+			if v := l.Next(); v.kind == psString {
+				switch tok.s {
+				case "FamilyName":
+					t1.FamilyName = v.s
+				case "FullName":
+					t1.FullName = v.s
+				case "Weight":
+					t1.Weight = v.s
+				case "Notice":
+					t1.Notice = v.s
+				case "version":
+					t1.Version = v.s
+				}
+			}
+		case "ItalicAngle", "UnderlinePosition", "UnderlineThickness":
+			if v := l.Next(); v.kind == psNumber {
+				switch tok.s {
+				case "ItalicAngle":
+					t1.ItalicAngle = v.num
+				case "UnderlinePosition":
+					t1.UnderlinePosition = v.num
+				case "UnderlineThickness":
+					t1.UnderlineThickness = v.num
+				}
+			}
+		case "isFixedPitch":
+			if v := l.Next(); v.kind == psKeyword {
+				t1.IsFixedPitch = v.s == "true"
+			}
+		case "FontMatrix":
+			if nums := l.Numbers(); len(nums) == 6 {
+				copy(t1.FontMatrix[:], nums)
+			}
+		case "FontBBox":
+			if nums := l.Numbers(); len(nums) == 4 {
+				copy(t1.FontBBox[:], nums)
+			}
+		case "Encoding":
+			if v := l.Next(); v.kind == psKeyword && v.s == "StandardEncoding" {
+				t1.Encoding = standardEncoding
+			} else if v.kind == psNumber {
+				customEncoding = true
+			}
+		}
+	}
+	if t1.FontName == "" {
+		return fmt.Errorf("Type1: missing FontName")
+	} else if t1.FontMatrix[0] <= 0.0 || t1.FontMatrix[3] <= 0.0 {
+		return fmt.Errorf("Type1: bad FontMatrix")
+	}
+	return nil
+}
+
+func (t1 *Type1) parsePrivate(b []byte) error {
+	lenIV := 4
+	rd := map[string]bool{"RD": true, "-|": true}
+	inCharStrings := false
+	lastName := ""
+	prev := [3]psToken{} // last three tokens, most recent last
+	charStrings := map[string][]byte{}
+
+	l := newPSLexer(b)
+	for {
+		tok := l.Next()
+		if tok.kind == psEOF {
+			break
+		} else if tok.kind == psKeyword && tok.s == "closefile" {
+			break
+		}
+
+		if tok.kind == psName {
+			lastName = tok.s
+			switch tok.s {
+			case "lenIV":
+				if v := l.Next(); v.kind == psNumber {
+					lenIV = int(v.num)
+				}
+				tok = psToken{}
+			case "Subrs":
+				if v := l.Next(); v.kind == psNumber && 0 <= v.num && v.num <= math.MaxUint16 {
+					t1.subrs = make([][]byte, int(v.num))
+				}
+				tok = psToken{}
+			case "CharStrings":
+				inCharStrings = true
+				tok = psToken{}
+			}
+		} else if tok.kind == psKeyword && tok.s == "readstring" {
+			// procedure that reads binary data, such as /RD {string currentfile exch readstring pop} executeonly def
+			rd[lastName] = true
+		} else if tok.kind == psKeyword && rd[tok.s] && prev[2].kind == psNumber {
+			n := int(prev[2].num)
+			data, err := l.Binary(n)
+			if err != nil {
+				return fmt.Errorf("Type1: %w", err)
+			}
+			if 0 <= lenIV {
+				data = decryptType1(data, 4330, lenIV)
+			}
+
+			if prev[1].kind == psName && inCharStrings {
+				// /name n RD <data> ND
+				if _, ok := charStrings[prev[1].s]; !ok {
+					t1.glyphNames = append(t1.glyphNames, prev[1].s)
+				}
+				charStrings[prev[1].s] = data
+			} else if prev[1].kind == psNumber && prev[0].kind == psKeyword && prev[0].s == "dup" {
+				// dup i n RD <data> NP
+				if i := int(prev[1].num); 0 <= i && i < len(t1.subrs) {
+					t1.subrs[i] = data
+				}
+			}
+			tok = psToken{}
+		}
+		prev[0], prev[1], prev[2] = prev[1], prev[2], tok
+	}
+	if len(t1.glyphNames) == 0 {
+		return fmt.Errorf("Type1: missing CharStrings")
+	} else if int(t1.opts.MaxGlyphs) < len(t1.glyphNames)+1 {
+		return fmt.Errorf("Type1: too many glyphs")
+	}
+
+	// .notdef must be the first glyph
+	glyphNames := []string{".notdef"}
+	for _, name := range t1.glyphNames {
+		if name != ".notdef" {
+			glyphNames = append(glyphNames, name)
+		}
+	}
+	if _, ok := charStrings[".notdef"]; !ok {
+		charStrings[".notdef"] = []byte{139, 139, 13, 14} // 0 0 hsbw endchar
+	}
+	t1.glyphNames = glyphNames
+	t1.charStrings = make([][]byte, len(glyphNames))
+	for i, name := range glyphNames {
+		t1.glyphIDs[name] = uint16(i)
+		t1.charStrings[i] = charStrings[name]
+	}
+	return nil
+}
+
+// NumGlyphs returns the number of glyphs, the first glyph is always .notdef.
+func (t1 *Type1) NumGlyphs() uint16 {
+	return uint16(len(t1.glyphNames))
+}
+
+// GlyphName returns the name of the glyph.
+func (t1 *Type1) GlyphName(glyphID uint16) string {
+	if int(glyphID) < len(t1.glyphNames) {
+		return t1.glyphNames[glyphID]
+	}
+	return ""
+}
+
+// GlyphIndex returns the glyph ID for the glyph name, or zero if it doesn't exist.
+func (t1 *Type1) GlyphIndex(name string) uint16 {
+	return t1.glyphIDs[name]
+}
+
+// UnitsPerEm returns the number of units per em as given by the font matrix, usually 1000.
+func (t1 *Type1) UnitsPerEm() uint16 {
+	unitsPerEm := math.Round(1.0 / t1.FontMatrix[0])
+	if unitsPerEm < 16.0 || 16384.0 < unitsPerEm {
+		return 1000
+	}
+	return uint16(unitsPerEm)
+}
+
+// GlyphAdvance returns the advance width of the glyph in font units. The width of the AFM metrics is used when available.
+func (t1 *Type1) GlyphAdvance(glyphID uint16) float64 {
+	if t1.AFM != nil {
+		if width, ok := t1.AFM.Widths[t1.GlyphName(glyphID)]; ok {
+			return width * t1.afmScale()
+		}
+	}
+	interp := &type1Interpreter{t1: t1, p: &bboxPather{}, f: 1.0}
+	if err := interp.run(glyphID, 0.0, 0.0, false, 0); err != nil {
+		return 0.0
+	}
+	return interp.width
+}
+
+// GlyphPath draws the glyph's contour as a path to the pather interface. The path is drawn at the (x,y) coordinate in font units and scaled using the given scale factor.
+func (t1 *Type1) GlyphPath(p Pather, glyphID uint16, x, y int32, scale float64) error {
+	interp := &type1Interpreter{t1: t1, p: p, f: scale}
+	return interp.run(glyphID, float64(x), float64(y), false, 0)
+}
+
+// Kerning returns the kerning between two glyphs in font units, that is the horizontal adjustment for the right glyph. Kerning is only available with AFM metrics.
+func (t1 *Type1) Kerning(left, right uint16) float64 {
+	if t1.AFM != nil {
+		return t1.AFM.Kerning[[2]string{t1.GlyphName(left), t1.GlyphName(right)}] * t1.afmScale()
+	}
+	return 0.0
+}
+
+// afmScale returns the factor that converts AFM metrics, which are in 1/1000 em, to font units.
+func (t1 *Type1) afmScale() float64 {
+	return float64(t1.UnitsPerEm()) / 1000.0
+}
+
+// ToSFNT converts the font to an OpenType font with CFF outlines. Glyphs are mapped to Unicode by their names following the Adobe Glyph List, glyphs with other names that are in the font's encoding are mapped to the Private Use Area at U+F000 plus their character code.
+func (t1 *Type1) ToSFNT() ([]byte, error) {
+	unitsPerEm := t1.UnitsPerEm()
+	b := &sfntBuilder{
+		PostScriptName:     t1.FontName,
+		FamilyName:         t1.FamilyName,
+		FullName:           t1.FullName,
+		Version:            t1.Version,
+		Copyright:          t1.Notice,
+		Weight:             t1.Weight,
+		UnitsPerEm:         unitsPerEm,
+		ItalicAngle:        t1.ItalicAngle,
+		UnderlinePosition:  int16(math.Round(t1.UnderlinePosition)),
+		UnderlineThickness: int16(math.Round(t1.UnderlineThickness)),
+		IsFixedPitch:       t1.IsFixedPitch,
+		Glyphs:             make([]sfntBuilderGlyph, len(t1.glyphNames)),
+	}
+	if b.FamilyName == "" {
+		b.FamilyName = t1.FontName
+	}
+	if b.FullName == "" {
+		b.FullName = b.FamilyName
+	}
+	if math.Abs(t1.FontMatrix[0]-1.0/float64(unitsPerEm)) < 1e-9 && t1.FontMatrix[1] == 0.0 && t1.FontMatrix[2] == 0.0 && math.Abs(t1.FontMatrix[3]-1.0/float64(unitsPerEm)) < 1e-9 && t1.FontMatrix[4] == 0.0 && t1.FontMatrix[5] == 0.0 {
+		b.FontMatrix = [6]float64{}
+	} else {
+		b.FontMatrix = t1.FontMatrix
+	}
+
+	// glyphs
+	for glyphID, name := range t1.glyphNames {
+		bbox := &bboxPather{}
+		if err := t1.GlyphPath(bbox, uint16(glyphID), 0, 0, 1.0); err != nil {
+			return nil, fmt.Errorf("Type1: glyph %s: %w", name, err)
+		}
+		advance := t1.GlyphAdvance(uint16(glyphID))
+		cs := newType2CharString(math.Round(advance))
+		if err := t1.GlyphPath(cs, uint16(glyphID), 0, 0, 1.0); err != nil {
+			return nil, fmt.Errorf("Type1: glyph %s: %w", name, err)
+		}
+
+		glyph := sfntBuilderGlyph{
+			Name:       name,
+			Advance:    uint16(math.Max(0.0, math.Min(math.Round(advance), math.MaxUint16))),
+			CharString: cs.Bytes(),
+		}
+		if bbox.hasPoints {
+			glyph.XMin = int16(math.Floor(bbox.xMin))
+			glyph.YMin = int16(math.Floor(bbox.yMin))
+			glyph.XMax = int16(math.Ceil(bbox.xMax))
+			glyph.YMax = int16(math.Ceil(bbox.yMax))
+		}
+		if r, ok := glyphNameToRune(name); ok && 0 < glyphID {
+			glyph.Runes = append(glyph.Runes, r)
+		}
+		b.Glyphs[glyphID] = glyph
+	}
+	for code, name := range t1.Encoding {
+		if glyphID, ok := t1.glyphIDs[name]; ok && 0 < glyphID && len(b.Glyphs[glyphID].Runes) == 0 {
+			b.Glyphs[glyphID].Runes = append(b.Glyphs[glyphID].Runes, rune(0xF000+code))
+		}
+	}
+
+	// vertical metrics
+	ascender, descender := t1.FontBBox[3], t1.FontBBox[1]
+	capHeight, xHeight := float64(b.Glyphs[t1.glyphIDs["H"]].YMax), float64(b.Glyphs[t1.glyphIDs["x"]].YMax)
+	if t1.AFM != nil {
+		scale := t1.afmScale()
+		if t1.AFM.Ascender != 0.0 || t1.AFM.Descender != 0.0 {
+			ascender, descender = scale*t1.AFM.Ascender, scale*t1.AFM.Descender
+		}
+		if t1.AFM.CapHeight != 0.0 {
+			capHeight = scale * t1.AFM.CapHeight
+		}
+		if t1.AFM.XHeight != 0.0 {
+			xHeight = scale * t1.AFM.XHeight
+		}
+		for pair, kern := range t1.AFM.Kerning {
+			kern *= scale
+			left, okLeft := t1.glyphIDs[pair[0]]
+			right, okRight := t1.glyphIDs[pair[1]]
+			if okLeft && okRight && kern != 0.0 {
+				b.Kerning = append(b.Kerning, kernPair{
+					Key:   uint32(left)<<16 | uint32(right),
+					Value: int16(math.Round(kern)),
+				})
+			}
+		}
+	}
+	if ascender == 0.0 && descender == 0.0 {
+		_, yMin, _, yMax := b.bounds()
+		ascender, descender = float64(yMax), float64(yMin)
+	}
+	b.Ascender = int16(math.Round(ascender))
+	b.Descender = int16(math.Round(descender))
+	b.CapHeight = int16(math.Round(capHeight))
+	b.XHeight = int16(math.Round(xHeight))
+	return b.WriteCFF(), nil
+}
+
+////////////////////////////////////////////////////////////////
+
+type type1Interpreter struct {
+	t1    *Type1
+	p     Pather
+	f     float64
+	width float64
+	sbx   float64 // left sidebearing
+
+	x, y    float64 // current point in font units
+	stack   []float64
+	psStack []float64 // PostScript operand stack for othersubrs
+	flex    bool
+	flexPts [][2]float64
+}
+
+func (interp *type1Interpreter) moveTo(x, y float64) {
+	interp.x, interp.y = x, y
+	if interp.flex {
+		interp.flexPts = append(interp.flexPts, [2]float64{x, y})
+		return
+	}
+	interp.p.Close()
+	interp.p.MoveTo(interp.f*x, interp.f*y)
+}
+
+func (interp *type1Interpreter) lineTo(x, y float64) {
+	interp.x, interp.y = x, y
+	interp.p.LineTo(interp.f*x, interp.f*y)
+}
+
+func (interp *type1Interpreter) cubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	interp.x, interp.y = x, y
+	f := interp.f
+	interp.p.CubeTo(f*cpx1, f*cpy1, f*cpx2, f*cpy2, f*x, f*y)
+}
+
+// run interprets the charstring of a glyph with its origin at (x0,y0), accent is set for the components of seac so that they do not set the width.
+func (interp *type1Interpreter) run(glyphID uint16, x0, y0 float64, accent bool, depth int) error {
+	if int(glyphID) >= len(interp.t1.charStrings) {
+		return fmt.Errorf("Type1: bad glyphID %v", glyphID)
+	}
+	interp.stack = interp.stack[:0]
+	_, err := interp.exec(interp.t1.charStrings[glyphID], x0, y0, accent, depth)
+	interp.p.Close()
+	return err
+}
+
+// exec executes a charstring and returns true when endchar or seac was encountered.
+func (interp *type1Interpreter) exec(charString []byte, x0, y0 float64, accent bool, depth int) (bool, error) {
+	errBadNumOperands := fmt.Errorf("Type1: bad number of operands for operator")
+	if interp.t1.opts.MaxCFFNesting < depth {
+		return false, fmt.Errorf("Type1: subroutines nested too deeply")
+	}
+
+	r := NewBinaryReader(charString)
+	for 0 < r.Len() {
+		b0 := int(r.ReadUint8())
+		if 32 <= b0 {
+			var v float64
+			if b0 < 247 {
+				v = float64(b0 - 139)
+			} else if b0 < 251 {
+				if r.Len() < 1 {
+					return false, ErrInvalidFontData
+				}
+				v = float64((b0-247)*256 + int(r.ReadUint8()) + 108)
+			} else if b0 < 255 {
+				if r.Len() < 1 {
+					return false, ErrInvalidFontData
+				}
+				v = float64(-(b0-251)*256 - int(r.ReadUint8()) - 108)
+			} else {
+				if r.Len() < 4 {
+					return false, ErrInvalidFontData
+				}
+				v = float64(r.ReadInt32())
+			}
+			if 48 <= len(interp.stack) {
+				return false, fmt.Errorf("Type1: too many operands for operator")
+			}
+			interp.stack = append(interp.stack, v)
+			continue
+		}
+
+		if b0 == 12 {
+			if r.Len() < 1 {
+				return false, ErrInvalidFontData
+			}
+			b0 = 256 + int(r.ReadUint8())
+		}
+		stack := interp.stack
+		switch b0 {
+		case 13:
+			// hsbw
+			if len(stack) != 2 {
+				return false, errBadNumOperands
+			}
+			interp.x, interp.y = x0+stack[0], y0
+			if !accent {
+				interp.sbx, interp.width = stack[0], stack[1]
+			}
+		case 256 + 7:
+			// sbw
+			if len(stack) != 4 {
+				return false, errBadNumOperands
+			}
+			interp.x, interp.y = x0+stack[0], y0+stack[1]
+			if !accent {
+				interp.sbx, interp.width = stack[0], stack[2]
+			}
+		case 21:
+			// rmoveto
+			if len(stack) != 2 {
+				return false, errBadNumOperands
+			}
+			interp.moveTo(interp.x+stack[0], interp.y+stack[1])
+		case 22:
+			// hmoveto
+			if len(stack) != 1 {
+				return false, errBadNumOperands
+			}
+			interp.moveTo(interp.x+stack[0], interp.y)
+		case 4:
+			// vmoveto
+			if len(stack) != 1 {
+				return false, errBadNumOperands
+			}
+			interp.moveTo(interp.x, interp.y+stack[0])
+		case 5:
+			// rlineto
+			if len(stack) != 2 {
+				return false, errBadNumOperands
+			}
+			interp.lineTo(interp.x+stack[0], interp.y+stack[1])
+		case 6:
+			// hlineto
+			if len(stack) != 1 {
+				return false, errBadNumOperands
+			}
+			interp.lineTo(interp.x+stack[0], interp.y)
+		case 7:
+			// vlineto
+			if len(stack) != 1 {
+				return false, errBadNumOperands
+			}
+			interp.lineTo(interp.x, interp.y+stack[0])
+		case 8:
+			// rrcurveto
+			if len(stack) != 6 {
+				return false, errBadNumOperands
+			}
+			cpx1, cpy1 := interp.x+stack[0], interp.y+stack[1]
+			cpx2, cpy2 := cpx1+stack[2], cpy1+stack[3]
+			interp.cubeTo(cpx1, cpy1, cpx2, cpy2, cpx2+stack[4], cpy2+stack[5])
+		case 30:
+			// vhcurveto
+			if len(stack) != 4 {
+				return false, errBadNumOperands
+			}
+			cpx1, cpy1 := interp.x, interp.y+stack[0]
+			cpx2, cpy2 := cpx1+stack[1], cpy1+stack[2]
+			interp.cubeTo(cpx1, cpy1, cpx2, cpy2, cpx2+stack[3], cpy2)
+		case 31:
+			// hvcurveto
+			if len(stack) != 4 {
+				return false, errBadNumOperands
+			}
+			cpx1, cpy1 := interp.x+stack[0], interp.y
+			cpx2, cpy2 := cpx1+stack[1], cpy1+stack[2]
+			interp.cubeTo(cpx1, cpy1, cpx2, cpy2, cpx2, cpy2+stack[3])
+		case 9:
+			// closepath
+			interp.p.Close()
+		case 1, 3, 256 + 1, 256 + 2, 256 + 0:
+			// hstem, vstem, hstem3, vstem3, dotsection
+		case 10:
+			// callsubr
+			if len(stack) < 1 {
+				return false, errBadNumOperands
+			}
+			i := int(stack[len(stack)-1])
+			if i < 0 || len(interp.t1.subrs) <= i || interp.t1.subrs[i] == nil {
+				return false, fmt.Errorf("Type1: bad subroutine %d", i)
+			}
+			interp.stack = stack[:len(stack)-1]
+			if done, err := interp.exec(interp.t1.subrs[i], x0, y0, accent, depth+1); err != nil || done {
+				return done, err
+			}
+			continue
+		case 11:
+			// return
+			return false, nil
+		case 256 + 16:
+			// callothersubr
+			if len(stack) < 2 {
+				return false, errBadNumOperands
+			}
+			othersubr, n := int(stack[len(stack)-1]), int(stack[len(stack)-2])
+			if n < 0 || len(stack)-2 < n {
+				return false, errBadNumOperands
+			}
+			args := stack[len(stack)-2-n : len(stack)-2]
+			interp.stack = stack[:len(stack)-2-n]
+
+			// arguments are pushed in order on the PostScript stack
+			interp.psStack = interp.psStack[:0]
+			for i := len(args) - 1; 0 <= i; i-- {
+				interp.psStack = append(interp.psStack, args[i])
+			}
+			switch othersubr {
+			case 0:
+				// end flex
+				interp.flex = false
+				if len(interp.flexPts) == 7 {
+					pts := interp.flexPts
+					interp.cubeTo(pts[1][0], pts[1][1], pts[2][0], pts[2][1], pts[3][0], pts[3][1])
+					interp.cubeTo(pts[4][0], pts[4][1], pts[5][0], pts[5][1], pts[6][0], pts[6][1])
+				} else if 0 < len(interp.flexPts) {
+					pts := interp.flexPts
+					interp.lineTo(pts[len(pts)-1][0], pts[len(pts)-1][1])
+				}
+				interp.psStack = []float64{interp.x - x0, interp.y - y0}
+			case 1:
+				// start flex
+				interp.flex = true
+				interp.flexPts = interp.flexPts[:0]
+			case 3:
+				// hint replacement
+				interp.psStack = []float64{3}
+			}
+			continue
+		case 256 + 17:
+			// pop
+			v := 0.0
+			if 0 < len(interp.psStack) {
+				v = interp.psStack[0]
+				interp.psStack = interp.psStack[1:]
+			}
+			if 48 <= len(stack) {
+				return false, fmt.Errorf("Type1: too many operands for operator")
+			}
+			interp.stack = append(stack, v)
+			continue
+		case 256 + 12:
+			// div
+			if len(stack) < 2 {
+				return false, errBadNumOperands
+			}
+			a, b := stack[len(stack)-2], stack[len(stack)-1]
+			if b == 0.0 {
+				return false, fmt.Errorf("Type1: division by zero")
+			}
+			interp.stack = append(stack[:len(stack)-2], a/b)
+			continue
+		case 256 + 33:
+			// setcurrentpoint
+			if len(stack) != 2 {
+				return false, errBadNumOperands
+			}
+			interp.x, interp.y = x0+stack[0], y0+stack[1]
+		case 256 + 6:
+			// seac
+			if len(stack) != 5 {
+				return false, errBadNumOperands
+			}
+			asb, adx, ady, bchar, achar := stack[0], stack[1], stack[2], int(stack[3]), int(stack[4])
+			if bchar < 0 || 255 < bchar || achar < 0 || 255 < achar {
+				return false, fmt.Errorf("Type1: bad seac character")
+			}
+			base, okBase := interp.t1.glyphIDs[standardEncoding[bchar]]
+			acc, okAccent := interp.t1.glyphIDs[standardEncoding[achar]]
+			if !okBase || !okAccent {
+				return false, fmt.Errorf("Type1: missing seac component")
+			}
+			interp.stack = interp.stack[:0]
+			if _, err := interp.exec(interp.t1.charStrings[base], x0, y0, true, depth+1); err != nil {
+				return false, err
+			}
+			interp.p.Close()
+			interp.stack = interp.stack[:0]
+
+			// the accent's offset is relative to the left sidebearing of the composite glyph
+			if _, err := interp.exec(interp.t1.charStrings[acc], x0+interp.sbx+adx-asb, y0+ady, true, depth+1); err != nil {
+				return false, err
+			}
+			return true, nil
+		case 14:
+			// endchar
+			return true, nil
+		default:
+			return false, fmt.Errorf("Type1: unsupported operator %d", b0)
+		}
+		interp.stack = interp.stack[:0]
+	}
+	return false, nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// glyphNameToRune returns the Unicode code point for a glyph name, following the Adobe Glyph List for glyph names such as "A", "uni0041", or "u1F600". Glyph names with a suffix such as "a.sc" or ligatures such as "f_i" are not mapped.
+func glyphNameToRune(name string) (rune, bool) {
+	if r, ok := glyphNameUnicode[name]; ok {
+		return r, true
+	} else if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		if v, err := strconv.ParseUint(name[3:], 16, 16); err == nil && (v < 0xD800 || 0xDFFF < v) {
+			return rune(v), true
+		}
+	} else if strings.HasPrefix(name, "u") && 5 <= len(name) && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil && v <= 0x10FFFF && (v < 0xD800 || 0xDFFF < v) {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+////////////////////////////////////////////////////////////////
+
+type psTokenKind int
+
+const (
+	psEOF psTokenKind = iota
+	psNumber
+	psName    // literal name such as /FontName
+	psKeyword // executable name such as def
+	psString
+	psDelim // one of [ ] { } << >>
+)
+
+type psToken struct {
+	kind psTokenKind
+	s    string
+	num  float64
+}
+
+// psLexer tokenizes PostScript code as used in Type 1 fonts.
+type psLexer struct {
+	b   []byte
+	pos int
+}
+
+func newPSLexer(b []byte) *psLexer {
+	return &psLexer{b: b}
+}
+
+func isPSWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPSDelimiter(c byte) bool {
+	return c == '(' || c == ')' || c == '<' || c == '>' || c == '[' || c == ']' || c == '{' || c == '}' || c == '/' || c == '%'
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// Next returns the next token.
+func (l *psLexer) Next() psToken {
+	// skip whitespace and comments
+	for l.pos < len(l.b) {
+		if c := l.b[l.pos]; isPSWhitespace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.b) && l.b[l.pos] != '\n' && l.b[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			break
+		}
+	}
+	if len(l.b) <= l.pos {
+		return psToken{kind: psEOF}
+	}
+
+	start := l.pos
+	c := l.b[l.pos]
+	l.pos++
+	switch c {
+	case '(':
+		s := []byte{}
+		for depth := 1; l.pos < len(l.b); l.pos++ {
+			c := l.b[l.pos]
+			if c == '\\' && l.pos+1 < len(l.b) {
+				l.pos++
+				switch c = l.b[l.pos]; c {
+				case 'n':
+					c = '\n'
+				case 'r':
+					c = '\r'
+				case 't':
+					c = '\t'
+				case 'b':
+					c = '\b'
+				case 'f':
+					c = '\f'
+				case '\r', '\n':
+					continue
+				default:
+					if '0' <= c && c <= '7' {
+						v := 0
+						for i := 0; i < 3 && l.pos < len(l.b) && '0' <= l.b[l.pos] && l.b[l.pos] <= '7'; i++ {
+							v = v*8 + int(l.b[l.pos]-'0')
+							l.pos++
+						}
+						l.pos--
+						c = byte(v)
+					}
+				}
+			} else if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+				if depth == 0 {
+					l.pos++
+					break
+				}
+			}
+			s = append(s, c)
+		}
+		return psToken{kind: psString, s: string(s)}
+	case '<':
+		if l.pos < len(l.b) && l.b[l.pos] == '<' {
+			l.pos++
+			return psToken{kind: psDelim, s: "<<"}
+		}
+		digits := []byte{}
+		for ; l.pos < len(l.b) && l.b[l.pos] != '>'; l.pos++ {
+			if isHexDigit(l.b[l.pos]) {
+				digits = append(digits, l.b[l.pos])
+			}
+		}
+		l.pos++
+		if len(digits)%2 == 1 {
+			digits = append(digits, '0')
+		}
+		s := make([]byte, len(digits)/2)
+		_, _ = hex.Decode(s, digits)
+		return psToken{kind: psString, s: string(s)}
+	case '>':
+		if l.pos < len(l.b) && l.b[l.pos] == '>' {
+			l.pos++
+		}
+		return psToken{kind: psDelim, s: ">>"}
+	case '[', ']', '{', '}':
+		return psToken{kind: psDelim, s: string(c)}
+	case '/':
+		start = l.pos
+	}
+
+	for l.pos < len(l.b) && !isPSWhitespace(l.b[l.pos]) && !isPSDelimiter(l.b[l.pos]) {
+		l.pos++
+	}
+	s := string(l.b[start:l.pos])
+	if c == '/' {
+		return psToken{kind: psName, s: s}
+	} else if num, ok := parsePSNumber(s); ok {
+		return psToken{kind: psNumber, s: s, num: num}
+	}
+	return psToken{kind: psKeyword, s: s}
+}
+
+// Numbers returns the numbers of an array or procedure such as [0.001 0 0 0.001 0 0] or {-10 -250 1000 900}.
+func (l *psLexer) Numbers() []float64 {
+	tok := l.Next()
+	if tok.kind != psDelim || tok.s != "[" && tok.s != "{" {
+		return nil
+	}
+	nums := []float64{}
+	for {
+		tok = l.Next()
+		if tok.kind == psNumber {
+			nums = append(nums, tok.num)
+		} else if tok.kind == psDelim && (tok.s == "]" || tok.s == "}") {
+			return nums
+		} else {
+			return nil
+		}
+	}
+}
+
+// Binary returns the next n bytes of binary data, which follow after a single space.
+func (l *psLexer) Binary(n int) ([]byte, error) {
+	l.pos++ // skip space
+	if n < 0 || len(l.b) < l.pos || len(l.b)-l.pos < n {
+		return nil, fmt.Errorf("bad binary data length")
+	}
+	b := l.b[l.pos : l.pos+n]
+	l.pos += n
+	return b, nil
+}
+
+func parsePSNumber(s string) (float64, bool) {
+	if len(s) == 0 || !(s[0] == '-' || s[0] == '+' || s[0] == '.' || '0' <= s[0] && s[0] <= '9') {
+		return 0.0, false
+	}
+	if i := strings.IndexByte(s, '#'); i != -1 {
+		// radix number such as 16#FFFE
+		radix, err := strconv.Atoi(s[:i])
+		if err != nil || radix < 2 || 36 < radix {
+			return 0.0, false
+		}
+		v, err := strconv.ParseUint(s[i+1:], radix, 32)
+		if err != nil {
+			return 0.0, false
+		}
+		return float64(v), true
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0.0, false
+	}
+	return v, true
+}
+
+////////////////////////////////////////////////////////////////
+
+// AFM are the font metrics of an Adobe Font Metrics file, see https://adobe-type-tools.github.io/font-tech-notes/pdfs/5004.AFM_Spec.pdf. All values are in units of 1/1000 em.
+type AFM struct {
+	FontName           string
+	FamilyName         string
+	FullName           string
+	Weight             string
+	ItalicAngle        float64
+	IsFixedPitch       bool
+	UnderlinePosition  float64
+	UnderlineThickness float64
+	FontBBox           [4]float64
+	CapHeight          float64
+	XHeight            float64
+	Ascender           float64
+	Descender          float64
+
+	Codes   map[string]int        // character codes by glyph name, -1 for unencoded glyphs
+	Widths  map[string]float64    // advance widths by glyph name
+	Bounds  map[string][4]float64 // bounding boxes by glyph name
+	Kerning map[[2]string]float64 // horizontal kerning of glyph name pairs
+}
+
+// ParseAFM parses an Adobe Font Metrics file, which can be assigned to Type1.AFM.
+func ParseAFM(b []byte) (*AFM, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(b, "\xEF\xBB\xBF \t\r\n"), []byte("StartFontMetrics")) {
+		return nil, fmt.Errorf("AFM: missing StartFontMetrics")
+	}
+
+	afm := &AFM{
+		Codes:   map[string]int{},
+		Widths:  map[string]float64{},
+		Bounds:  map[string][4]float64{},
+		Kerning: map[[2]string]float64{},
+	}
+	number := func(s string) float64 {
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+	for _, line := range strings.FieldsFunc(string(b), func(r rune) bool { return r == '\n' || r == '\r' }) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		switch fields[0] {
+		case "FontName":
+			afm.FontName = value
+		case "FamilyName":
+			afm.FamilyName = value
+		case "FullName":
+			afm.FullName = value
+		case "Weight":
+			afm.Weight = value
+		case "ItalicAngle":
+			afm.ItalicAngle = number(value)
+		case "IsFixedPitch":
+			afm.IsFixedPitch = value == "true"
+		case "UnderlinePosition":
+			afm.UnderlinePosition = number(value)
+		case "UnderlineThickness":
+			afm.UnderlineThickness = number(value)
+		case "FontBBox":
+			if len(fields) == 5 {
+				for i := 0; i < 4; i++ {
+					afm.FontBBox[i] = number(fields[i+1])
+				}
+			}
+		case "CapHeight":
+			afm.CapHeight = number(value)
+		case "XHeight":
+			afm.XHeight = number(value)
+		case "Ascender":
+			afm.Ascender = number(value)
+		case "Descender":
+			afm.Descender = number(value)
+		case "C", "CH":
+			// C 65 ; WX 722 ; N A ; B 15 0 706 674 ;
+			code, name, width, bounds, hasBounds := -1, "", 0.0, [4]float64{}, false
+			for _, s := range strings.Split(line, ";") {
+				item := strings.Fields(s)
+				if len(item) < 2 {
+					continue
+				}
+				switch item[0] {
+				case "C":
+					if v, err := strconv.Atoi(item[1]); err == nil {
+						code = v
+					}
+				case "CH":
+					if v, err := strconv.ParseInt(strings.Trim(item[1], "<>"), 16, 32); err == nil {
+						code = int(v)
+					}
+				case "WX", "W0X":
+					width = number(item[1])
+				case "W", "W0":
+					width = number(item[1])
+				case "N":
+					name = item[1]
+				case "B":
+					if len(item) == 5 {
+						for i := 0; i < 4; i++ {
+							bounds[i] = number(item[i+1])
+						}
+						hasBounds = true
+					}
+				}
+			}
+			if name != "" {
+				afm.Codes[name] = code
+				afm.Widths[name] = width
+				if hasBounds {
+					afm.Bounds[name] = bounds
+				}
+			}
+		case "KPX", "KP":
+			// KPX A V -70
+			if 4 <= len(fields) {
+				afm.Kerning[[2]string{fields[1], fields[2]}] = number(fields[3])
+			}
+		}
+	}
+	return afm, nil
+}
+
+// isType1 returns true if the file is a Type 1 font in the PFB or PFA format.
+func isType1(b []byte) bool {
+	if 6 <= len(b) && b[0] == 0x80 && b[1] == 0x01 {
+		return bytes.HasPrefix(b[6:], []byte("%!PS-AdobeFont")) || bytes.HasPrefix(b[6:], []byte("%!FontType1"))
+	}
+	return bytes.HasPrefix(b, []byte("%!PS-AdobeFont")) || bytes.HasPrefix(b, []byte("%!FontType1"))
+}
@@ -0,0 +1,96 @@
+package font
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// encryptType1 encrypts eexec (r=55665) or charstring (r=4330) data, prefixed by four zero bytes.
+func encryptType1(b []byte, r uint16) []byte {
+	const c1, c2 = 52845, 22719
+	dst := make([]byte, 4+len(b))
+	for i, p := range append([]byte{0, 0, 0, 0}, b...) {
+		dst[i] = p ^ byte(r>>8)
+		r = (uint16(dst[i])+r)*c1 + c2
+	}
+	return dst
+}
+
+// type1CharString encodes an unencrypted Type 1 charstring, each command is given as its operands followed by the operator.
+func type1CharString(commands ...[]int) []byte {
+	b := []byte{}
+	for _, command := range commands {
+		for _, v := range command[:len(command)-1] {
+			b = append(b, 255, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+		}
+		b = append(b, byte(command[len(command)-1]))
+	}
+	return b
+}
+
+// testType1 returns a PFA font with units per em of 2048 and a rectangle glyph for 'a'.
+func testType1() []byte {
+	charString := type1CharString(
+		[]int{0, 1200, 13}, // hsbw
+		[]int{100, 0, 21},  // rmoveto
+		[]int{1000, 0, 5},  // rlineto
+		[]int{0, 1400, 5},  // rlineto
+		[]int{-1000, 0, 5}, // rlineto
+		[]int{9},           // closepath
+		[]int{14},          // endchar
+	)
+	private := fmt.Sprintf("dup /Private 8 dict dup begin /RD{string currentfile exch readstring pop}executeonly def /ND{noaccess def}executeonly def /lenIV -1 def end\n2 index /CharStrings 1 dict dup begin\n/a %d RD %sND\nend\nmark currentfile closefile\n", len(charString), charString)
+	return []byte("%!PS-AdobeFont-1.0: Test 001.000\n" +
+		"/FontName /Test def\n" +
+		"/FontMatrix [0.00048828125 0 0 0.00048828125 0 0] readonly def\n" +
+		"/Encoding StandardEncoding def\n" +
+		"currentfile eexec\n" + hex.EncodeToString(encryptType1([]byte(private), 55665)) + "\n")
+}
+
+func TestType1UnitsPerEm(t *testing.T) {
+	t1, err := ParseType1(testType1())
+	if err != nil {
+		t.Fatal(err)
+	} else if t1.UnitsPerEm() != 2048 {
+		t.Fatalf("units per em is %d, expected 2048", t1.UnitsPerEm())
+	}
+	b, err := t1.ToSFNT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfnt, err := ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if sfnt.Head.UnitsPerEm != 2048 {
+		t.Fatalf("units per em is %d, expected 2048", sfnt.Head.UnitsPerEm)
+	} else if advance := sfnt.GlyphAdvance(sfnt.GlyphIndex('a')); advance != 1200 {
+		t.Fatalf("advance is %d, expected 1200", advance)
+	}
+
+	// the CFF table must set the FontMatrix, since its default is 1/1000
+	r := NewBinaryReader(sfnt.Tables["CFF "])
+	_ = r.ReadBytes(4) // header
+	if _, err := parseINDEX(r, false); err != nil {
+		t.Fatal(err)
+	}
+	topINDEX, err := parseINDEX(r, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stringINDEX, err := parseINDEX(r, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topDICT, err := parseTopDICT(topINDEX.Get(0), stringINDEX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [6]float64{1.0 / 2048.0, 0.0, 0.0, 1.0 / 2048.0, 0.0, 0.0}
+	for i := range expected {
+		if 1e-9 < math.Abs(topDICT.FontMatrix[i]-expected[i]) {
+			t.Fatalf("FontMatrix is %v, expected %v", topDICT.FontMatrix, expected)
+		}
+	}
+}
@@ -6,9 +6,76 @@ import (
 	"math"
 )
 
-// MaxMemory is the maximum memory that can be allocated by a font.
+// MaxMemory is the maximum memory that can be allocated by a font. It is the default for ParseOptions.MaxMemory.
 var MaxMemory uint32 = 30 * 1024 * 1024
 
+// MaxOutlineCache is the maximum memory used by the glyph outline cache of a font. It is the default for ParseOptions.MaxOutlineCache.
+var MaxOutlineCache uint32 = 4 * 1024 * 1024
+
+// MaxCFFNesting is the default maximum nesting depth of CFF subroutine calls.
+const MaxCFFNesting = 10
+
+// DefaultRequiredTables are the tables that must be present in a font by default, TrueType fonts additionally require the glyf and loca tables and CFF fonts the CFF or CFF2 table.
+var DefaultRequiredTables = []string{"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post"}
+
+// ParseOptions are the limits and requirements used when parsing or decoding fonts. Zero values use the defaults, so that a zero ParseOptions behaves the same as passing no options.
+type ParseOptions struct {
+	MaxMemory       uint32   // maximum memory allocated when decoding a font, defaults to MaxMemory
+	MaxGlyphs       uint16   // maximum number of glyphs, defaults to no limit
+	MaxCmapSegments uint32   // maximum number of segments or groups in a cmap subtable, defaults to MaxCmapSegments
+	MaxCFFNesting   int      // maximum nesting depth of CFF subroutine calls, defaults to MaxCFFNesting
+	RequiredTables  []string // tables that must be present, defaults to DefaultRequiredTables. The head, hhea, hmtx, maxp, and outline tables are always required
+	MaxOutlineCache uint32   // maximum memory used by cached glyph outlines, defaults to MaxOutlineCache
+}
+
+// parseOptions returns the options with defaults filled in, it accepts the variadic options of the parse functions and uses only the first.
+func parseOptions(opts []ParseOptions) ParseOptions {
+	var o ParseOptions
+	if 0 < len(opts) {
+		o = opts[0]
+	}
+	if o.MaxMemory == 0 {
+		o.MaxMemory = MaxMemory
+	}
+	if o.MaxGlyphs == 0 {
+		o.MaxGlyphs = math.MaxUint16
+	}
+	if o.MaxCmapSegments == 0 {
+		o.MaxCmapSegments = MaxCmapSegments
+	}
+	if o.MaxCFFNesting == 0 {
+		o.MaxCFFNesting = MaxCFFNesting
+	}
+	if o.MaxOutlineCache == 0 {
+		o.MaxOutlineCache = MaxOutlineCache
+	}
+	if o.RequiredTables == nil {
+		o.RequiredTables = DefaultRequiredTables
+	}
+	return o
+}
+
+// requiredTables returns the tables that must be present in the font.
+func (o ParseOptions) requiredTables(isTrueType bool) []string {
+	requiredTables := []string{"head", "hhea", "hmtx", "maxp"}
+	if isTrueType {
+		requiredTables = append(requiredTables, "glyf", "loca")
+	}
+	for _, tag := range o.RequiredTables {
+		found := false
+		for _, requiredTable := range requiredTables {
+			if tag == requiredTable {
+				found = true
+				break
+			}
+		}
+		if !found {
+			requiredTables = append(requiredTables, tag)
+		}
+	}
+	return requiredTables
+}
+
 // ErrExceedsMemory is returned if the font is malformed.
 var ErrExceedsMemory = fmt.Errorf("memory limit exceded")
 
@@ -316,3 +383,17 @@ func (w *BinaryWriter) WriteInt32(v int32) {
 func (w *BinaryWriter) WriteInt64(v int64) {
 	w.WriteUint64(uint64(v))
 }
+
+// WriteUint16LE writes the given uint16 little endian to the buffer.
+func (w *BinaryWriter) WriteUint16LE(v uint16) {
+	pos := len(w.buf)
+	w.buf = append(w.buf, make([]byte, 2)...)
+	binary.LittleEndian.PutUint16(w.buf[pos:], v)
+}
+
+// WriteUint32LE writes the given uint32 little endian to the buffer.
+func (w *BinaryWriter) WriteUint32LE(v uint32) {
+	pos := len(w.buf)
+	w.buf = append(w.buf, make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(w.buf[pos:], v)
+}
@@ -0,0 +1,756 @@
+package font
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Severity is the severity of a validation issue.
+type Severity int
+
+// see Severity
+const (
+	SeverityWarning Severity = iota // font can be used, but deviates from the specification
+	SeverityError                   // font or table can not be used
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Invalid(%d)", int(severity))
+}
+
+// Issue is a problem found while validating a font.
+type Issue struct {
+	Severity Severity
+	Table    string // empty for the file header
+	Message  string
+}
+
+func (issue Issue) String() string {
+	if issue.Table == "" {
+		return fmt.Sprintf("%v: %v", issue.Severity, issue.Message)
+	}
+	return fmt.Sprintf("%v: %v: %v", issue.Severity, issue.Table, issue.Message)
+}
+
+// Report is the result of validating a font. Repaired is only set by Sanitize.
+type Report struct {
+	Issues   []Issue
+	Repaired []byte
+}
+
+// Valid returns true if there are no errors, warnings are allowed.
+func (report Report) Valid() bool {
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Errors returns all issues of error severity.
+func (report Report) Errors() []Issue {
+	return report.filter(SeverityError)
+}
+
+// Warnings returns all issues of warning severity.
+func (report Report) Warnings() []Issue {
+	return report.filter(SeverityWarning)
+}
+
+func (report Report) filter(severity Severity) []Issue {
+	issues := []Issue{}
+	for _, issue := range report.Issues {
+		if issue.Severity == severity {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func (report Report) String() string {
+	var sb strings.Builder
+	for _, issue := range report.Issues {
+		sb.WriteString(issue.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// tableHasErrors returns true if there is an error for the given table.
+func (report *Report) tableHasErrors(tag string) bool {
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError && issue.Table == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// maxIssuesPerTable limits the number of per-glyph warnings and errors reported for a single table. Warnings and errors are counted separately so that errors are never omitted because of warnings.
+const maxIssuesPerTable = 20
+
+type validator struct {
+	*Report
+	prefix string // font index prefix for collections
+	counts map[issueCount]int
+}
+
+type issueCount struct {
+	severity Severity
+	table    string
+}
+
+func (v *validator) add(severity Severity, table, format string, args ...interface{}) {
+	if v.counts == nil {
+		v.counts = map[issueCount]int{}
+	}
+	key := issueCount{severity, table}
+	v.counts[key]++
+	if v.counts[key] == maxIssuesPerTable+1 {
+		v.Issues = append(v.Issues, Issue{severity, table, v.prefix + fmt.Sprintf("too many %ss, omitting the rest", severity)})
+		return
+	} else if maxIssuesPerTable < v.counts[key] {
+		return
+	}
+	v.Issues = append(v.Issues, Issue{severity, table, v.prefix + fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warn(table, format string, args ...interface{}) {
+	v.add(SeverityWarning, table, format, args...)
+}
+
+func (v *validator) error(table, format string, args ...interface{}) {
+	v.add(SeverityError, table, format, args...)
+}
+
+// run calls f and turns returned errors or panics into an error for the table. It returns true on success.
+func (v *validator) run(table string, f func() error) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.error(table, "malformed data: %v", r)
+			ok = false
+		}
+	}()
+	if err := f(); err != nil {
+		msg := err.Error()
+		msg = strings.TrimPrefix(msg, strings.TrimSpace(table)+": ")
+		v.error(table, "%s", msg)
+		return false
+	}
+	return true
+}
+
+// Validate checks a font (TTF, OTF, TTC, WOFF, WOFF2, or EOT) for problems in the spirit of the OpenType Sanitizer. It checks the table directory and checksums, table overlaps, the consistency between maxp, loca, glyf, hhea and hmtx, the cmap subtables, the CFF charstrings, and the encoding of name records. Fonts without errors in the report can be parsed by ParseFont.
+func Validate(b []byte) Report {
+	report := Report{}
+	validateFont(&report, b)
+	return report
+}
+
+// Sanitize validates the font like Validate and returns a repaired SFNT font in Report.Repaired when possible. Optional tables with errors are dropped, and the table directory, table checksums and the file checksum are regenerated. Repaired is nil if a required table has errors or for font collections.
+func Sanitize(b []byte) Report {
+	report := Report{}
+	sfnt := validateFont(&report, b)
+	if sfnt == nil {
+		return report
+	}
+
+	required := []string{"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post"}
+	if sfnt.IsTrueType {
+		required = append(required, "glyf", "loca")
+	} else if _, ok := sfnt.Tables["CFF2"]; ok {
+		required = append(required, "CFF2")
+	} else {
+		required = append(required, "CFF ")
+	}
+	for _, tag := range required {
+		if _, ok := sfnt.Tables[tag]; !ok || report.tableHasErrors(tag) {
+			return report
+		}
+	}
+
+	tables := make(map[string][]byte, len(sfnt.Tables))
+	for tag, table := range sfnt.Tables {
+		if report.tableHasErrors(tag) {
+			report.Issues = append(report.Issues, Issue{SeverityWarning, tag, "table dropped"})
+			continue
+		}
+		tables[tag] = table
+	}
+	if _, ok := tables["vmtx"]; ok {
+		if _, ok := tables["vhea"]; !ok {
+			report.Issues = append(report.Issues, Issue{SeverityWarning, "vmtx", "table dropped"})
+			delete(tables, "vmtx")
+		}
+	}
+	sfnt.Tables = tables
+	report.Repaired = sfnt.Write()
+	return report
+}
+
+// validateFont validates the font and returns the (partially) parsed SFNT, or nil if the font could not be parsed or if it is a collection.
+func validateFont(report *Report, b []byte) *SFNT {
+	v := &validator{Report: report}
+	if _, err := MediaType(b); err != nil {
+		v.error("", "%v", err)
+		return nil
+	}
+	sfntBytes, err := ToSFNT(b)
+	if err != nil {
+		v.error("", "%v", err)
+		return nil
+	} else if len(sfntBytes) < 12 || math.MaxUint32 < len(sfntBytes) {
+		v.error("", "file too small or too large")
+		return nil
+	}
+
+	if string(sfntBytes[:4]) != "ttcf" {
+		return v.validateSFNT(sfntBytes, 0, true)
+	}
+
+	r := NewBinaryReader(sfntBytes)
+	_ = r.ReadString(4) // ttcTag
+	majorVersion := r.ReadUint16()
+	minorVersion := r.ReadUint16()
+	if majorVersion != 1 && majorVersion != 2 || minorVersion != 0 {
+		v.error("", "bad TTC version")
+		return nil
+	}
+	numFonts := r.ReadUint32()
+	if numFonts == 0 || r.Len() < 4*numFonts {
+		v.error("", "bad number of fonts in collection")
+		return nil
+	}
+	offsets := make([]uint32, numFonts)
+	for i := range offsets {
+		offsets[i] = r.ReadUint32()
+	}
+	for i, offset := range offsets {
+		v.prefix = fmt.Sprintf("font %d: ", i)
+		v.counts = nil
+		v.validateSFNT(sfntBytes, offset, false)
+	}
+	return nil
+}
+
+type tableRecord struct {
+	tag      string
+	checksum uint32
+	offset   uint32
+	length   uint32
+}
+
+// validateSFNT validates the font starting at offset in b and returns the parsed tables. The file checksum is only checked for fonts that are not in a collection.
+func (v *validator) validateSFNT(b []byte, offset uint32, checkFileChecksum bool) *SFNT {
+	if uint32(len(b)) < offset || uint32(len(b))-offset < 12 {
+		v.error("", "bad font offset")
+		return nil
+	}
+
+	r := NewBinaryReader(b)
+	r.Seek(offset)
+	sfntVersion := r.ReadString(4)
+	if sfntVersion != "OTTO" && binary.BigEndian.Uint32([]byte(sfntVersion)) != 0x00010000 {
+		if sfntVersion == "true" {
+			v.warn("", "Apple TrueType sfntVersion 'true' is not supported by OpenType")
+		} else {
+			v.error("", "bad SFNT version")
+			return nil
+		}
+	}
+	numTables := r.ReadUint16()
+	searchRange := r.ReadUint16()
+	entrySelector := r.ReadUint16()
+	rangeShift := r.ReadUint16()
+	if numTables == 0 {
+		v.error("", "font has no tables")
+		return nil
+	} else if r.Len() < 16*uint32(numTables) {
+		v.error("", "table directory exceeds file size")
+		return nil
+	}
+	expEntrySelector := uint16(math.Log2(float64(numTables)))
+	expSearchRange := uint16(1 << (expEntrySelector + 4))
+	if searchRange != expSearchRange || entrySelector != expEntrySelector || rangeShift != numTables<<4-expSearchRange {
+		v.warn("", "bad searchRange, entrySelector, or rangeShift")
+	}
+
+	records := make([]tableRecord, 0, numTables)
+	tables := make(map[string][]byte, numTables)
+	headOffset := uint32(0) // offset of the head table in tables
+	for i := 0; i < int(numTables); i++ {
+		record := tableRecord{
+			tag:      r.ReadString(4),
+			checksum: r.ReadUint32(),
+			offset:   r.ReadUint32(),
+			length:   r.ReadUint32(),
+		}
+		for _, c := range []byte(record.tag) {
+			if c < 0x20 || 0x7E < c {
+				v.error("", "bad table tag %q", record.tag)
+				break
+			}
+		}
+		if 0 < i && record.tag <= records[i-1].tag {
+			if record.tag == records[i-1].tag {
+				v.error(record.tag, "table defined more than once")
+			} else {
+				v.warn("", "table directory is not sorted by tag")
+			}
+		}
+		records = append(records, record)
+
+		if uint32(len(b)) < record.offset || uint32(len(b))-record.offset < record.length {
+			v.error(record.tag, "table extends beyond file size")
+			continue
+		} else if record.offset%4 != 0 {
+			v.warn(record.tag, "table offset is not 4-byte aligned")
+		}
+		table := b[record.offset : record.offset+record.length : record.offset+record.length]
+		if checksum := tableChecksum(record.tag, table); checksum != record.checksum {
+			v.warn(record.tag, "bad checksum %08X, expected %08X", record.checksum, checksum)
+		}
+		if _, ok := tables[record.tag]; !ok {
+			tables[record.tag] = table
+			if record.tag == "head" {
+				headOffset = record.offset
+			}
+		}
+	}
+
+	// check overlaps between tables and the table directory
+	sorted := make([]tableRecord, len(records))
+	copy(sorted, records)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].offset < sorted[j].offset })
+	dirEnd := offset + 12 + 16*uint32(numTables)
+	maxEnd, maxEndTag := uint64(0), "" // furthest end of the preceding tables
+	for _, record := range sorted {
+		if record.length == 0 {
+			continue
+		} else if record.offset < dirEnd && offset < record.offset+record.length {
+			v.error(record.tag, "table overlaps the table directory")
+		}
+		if uint64(record.offset) < maxEnd {
+			v.error(record.tag, "table overlaps %s table", maxEndTag)
+		}
+		if end := uint64(record.offset) + uint64(record.length); maxEnd < end {
+			maxEnd, maxEndTag = end, record.tag
+		}
+	}
+
+	// check file checksum
+	if head, ok := tables["head"]; ok && 12 <= len(head) && checkFileChecksum {
+		padded := make([]byte, (len(b)+3)&^3)
+		copy(padded, b)
+		binary.BigEndian.PutUint32(padded[headOffset+8:], 0)
+		checksumAdjustment := binary.BigEndian.Uint32(head[8:])
+		if expected := 0xB1B0AFBA - calcChecksum(padded); checksumAdjustment != expected {
+			v.warn("head", "bad checksumAdjustment %08X, expected %08X", checksumAdjustment, expected)
+		}
+	}
+
+	sfnt := &SFNT{
+		Data:       b,
+		Version:    sfntVersion,
+		IsCFF:      sfntVersion == "OTTO",
+		IsTrueType: sfntVersion != "OTTO",
+		Tables:     tables,
+		opts:       parseOptions(nil),
+	}
+	v.validateTables(sfnt)
+	return sfnt
+}
+
+// tableChecksum returns the checksum of a table, for the head table it is calculated with checksumAdjustment set to zero.
+func tableChecksum(tag string, table []byte) uint32 {
+	padded := make([]byte, (len(table)+3)&^3)
+	copy(padded, table)
+	if tag == "head" && 12 <= len(padded) {
+		binary.BigEndian.PutUint32(padded[8:], 0)
+	}
+	return calcChecksum(padded)
+}
+
+func (v *validator) validateTables(sfnt *SFNT) {
+	required := []string{"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post"}
+	if sfnt.IsTrueType {
+		required = append(required, "glyf", "loca")
+	}
+	for _, tag := range required {
+		if _, ok := sfnt.Tables[tag]; !ok {
+			v.error(tag, "missing table")
+		}
+	}
+	if sfnt.IsCFF {
+		_, hasCFF := sfnt.Tables["CFF "]
+		_, hasCFF2 := sfnt.Tables["CFF2"]
+		if !hasCFF && !hasCFF2 {
+			v.error("CFF ", "missing table")
+		} else if hasCFF && hasCFF2 {
+			v.error("CFF2", "CFF table already exists")
+		}
+	} else {
+		if _, ok := sfnt.Tables["CFF "]; ok {
+			v.warn("CFF ", "table present in TrueType font")
+		}
+	}
+
+	// tables that others depend on
+	if _, ok := sfnt.Tables["head"]; ok && v.run("head", sfnt.parseHead) {
+		v.validateHead(sfnt)
+	} else {
+		sfnt.Head = nil
+	}
+	if _, ok := sfnt.Tables["maxp"]; !ok || !v.run("maxp", sfnt.parseMaxp) {
+		sfnt.Maxp = nil
+		return // all other tables depend on maxp
+	} else if sfnt.Maxp.NumGlyphs == 0 {
+		v.error("maxp", "font has no glyphs")
+		return
+	}
+	if _, ok := sfnt.Tables["hhea"]; !ok || !v.run("hhea", sfnt.parseHhea) {
+		sfnt.Hhea = nil
+	}
+	if sfnt.IsTrueType && sfnt.Head != nil {
+		if _, ok := sfnt.Tables["loca"]; ok && v.run("loca", sfnt.parseLoca) && v.validateLoca(sfnt) {
+			if _, ok := sfnt.Tables["glyf"]; ok && v.run("glyf", sfnt.parseGlyf) {
+				v.validateGlyf(sfnt)
+			}
+		}
+	}
+
+	tags := []string{}
+	for tag := range sfnt.Tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		switch tag {
+		case "head", "maxp", "hhea", "loca", "glyf":
+			continue
+		case "hmtx":
+			if sfnt.Hhea == nil || !v.validateHmtxLength(sfnt) {
+				continue
+			}
+		case "vmtx":
+			if sfnt.Vhea == nil {
+				v.error("vmtx", "vhea table missing or invalid")
+				continue
+			}
+		case "CFF ", "CFF2":
+			if !sfnt.IsCFF {
+				continue
+			}
+		}
+		if v.run(tag, func() error { return sfnt.parseTable(tag) }) {
+			switch tag {
+			case "cmap":
+				v.validateCmap(sfnt)
+			case "hmtx":
+				v.validateHmtx(sfnt)
+			case "name":
+				v.validateName(sfnt)
+			case "OS/2":
+				v.validateOS2(sfnt)
+			case "CFF ", "CFF2":
+				v.validateCFF(sfnt, tag)
+			}
+		}
+	}
+}
+
+func (v *validator) validateHead(sfnt *SFNT) {
+	if sfnt.Head.UnitsPerEm < 16 || 16384 < sfnt.Head.UnitsPerEm {
+		v.error("head", "unitsPerEm %d must be between 16 and 16384", sfnt.Head.UnitsPerEm)
+	}
+	if sfnt.Head.XMax < sfnt.Head.XMin || sfnt.Head.YMax < sfnt.Head.YMin {
+		v.warn("head", "bad font bounding box")
+	}
+	if sfnt.Head.Modified.Before(sfnt.Head.Created) {
+		v.warn("head", "modified date is before created date")
+	}
+}
+
+func (v *validator) validateLoca(sfnt *SFNT) bool {
+	b := sfnt.Tables["loca"]
+	n := uint32(sfnt.Maxp.NumGlyphs) + 1
+	if sfnt.Head.IndexToLocFormat == 0 && uint32(len(b)) != 2*n || sfnt.Head.IndexToLocFormat == 1 && uint32(len(b)) != 4*n {
+		if uint32(len(b)) < 2*n || sfnt.Head.IndexToLocFormat == 1 && uint32(len(b)) < 4*n {
+			v.error("loca", "table too small for numGlyphs in maxp")
+			return false
+		}
+		v.warn("loca", "table larger than required for numGlyphs in maxp")
+	}
+
+	glyfLength := uint32(len(sfnt.Tables["glyf"]))
+	prev := uint32(0)
+	for glyphID := uint32(0); glyphID < n; glyphID++ {
+		offset, _ := sfnt.Loca.Get(uint16(glyphID))
+		if offset < prev {
+			v.error("loca", "offsets not in ascending order at glyph %d", glyphID)
+			return false
+		} else if glyfLength < offset {
+			v.error("loca", "offset of glyph %d exceeds glyf table", glyphID)
+			return false
+		}
+		prev = offset
+	}
+	return true
+}
+
+func (v *validator) validateGlyf(sfnt *SFNT) {
+	maxPoints, maxContours := 0, 0
+	for glyphID := uint16(0); glyphID < sfnt.Maxp.NumGlyphs; glyphID++ {
+		var contour *glyfContour
+		if !v.run("glyf", func() error {
+			var err error
+			contour, err = sfnt.Glyf.Contour(glyphID, 0)
+			return err
+		}) {
+			continue
+		}
+		if len(contour.EndPoints) == 0 {
+			continue
+		}
+		for i := 1; i < len(contour.EndPoints); i++ {
+			if contour.EndPoints[i] < contour.EndPoints[i-1] {
+				v.error("glyf", "endPtsOfContours not in ascending order for glyph %d", glyphID)
+				break
+			}
+		}
+		if sfnt.Head != nil && (contour.XMin < sfnt.Head.XMin || sfnt.Head.XMax < contour.XMax || contour.YMin < sfnt.Head.YMin || sfnt.Head.YMax < contour.YMax) {
+			v.warn("glyf", "bounding box of glyph %d exceeds font bounding box", glyphID)
+		}
+		if b := sfnt.Glyf.Get(glyphID); 2 <= len(b) && 0 <= int16(binary.BigEndian.Uint16(b)) {
+			if maxPoints < len(contour.XCoordinates) {
+				maxPoints = len(contour.XCoordinates)
+			}
+			if maxContours < len(contour.EndPoints) {
+				maxContours = len(contour.EndPoints)
+			}
+		}
+	}
+	if int(sfnt.Maxp.MaxPoints) < maxPoints {
+		v.warn("maxp", "maxPoints is %d, but a glyph has %d points", sfnt.Maxp.MaxPoints, maxPoints)
+	}
+	if int(sfnt.Maxp.MaxContours) < maxContours {
+		v.warn("maxp", "maxContours is %d, but a glyph has %d contours", sfnt.Maxp.MaxContours, maxContours)
+	}
+}
+
+// validateHmtxLength checks numberOfHMetrics against numGlyphs and the length of the hmtx table, which must be checked before parsing.
+func (v *validator) validateHmtxLength(sfnt *SFNT) bool {
+	numberOfHMetrics, numGlyphs := uint32(sfnt.Hhea.NumberOfHMetrics), uint32(sfnt.Maxp.NumGlyphs)
+	if numGlyphs < numberOfHMetrics {
+		v.error("hhea", "numberOfHMetrics %d exceeds numGlyphs %d", numberOfHMetrics, numGlyphs)
+		return false
+	} else if length, expected := uint32(len(sfnt.Tables["hmtx"])), 4*numberOfHMetrics+2*(numGlyphs-numberOfHMetrics); length != expected {
+		v.error("hmtx", "table length is %d, expected %d", length, expected)
+		return false
+	}
+	return true
+}
+
+func (v *validator) validateHmtx(sfnt *SFNT) {
+	for glyphID := uint16(0); glyphID < sfnt.Hhea.NumberOfHMetrics; glyphID++ {
+		if advance := sfnt.Hmtx.Advance(glyphID); sfnt.Hhea.AdvanceWidthMax < advance {
+			v.warn("hhea", "advanceWidthMax is %d, but glyph %d has an advance of %d", sfnt.Hhea.AdvanceWidthMax, glyphID, advance)
+			break
+		}
+	}
+}
+
+func (v *validator) validateCmap(sfnt *SFNT) {
+	hasUnicode := false
+	for i, record := range sfnt.Cmap.EncodingRecords {
+		if 0 < i {
+			prev := sfnt.Cmap.EncodingRecords[i-1]
+			if record.PlatformID < prev.PlatformID || record.PlatformID == prev.PlatformID && record.EncodingID <= prev.EncodingID {
+				v.warn("cmap", "encoding records not sorted by platform and encoding")
+			}
+		}
+		if PlatformID(record.PlatformID) == PlatformUnicode || PlatformID(record.PlatformID) == PlatformWindows && (EncodingID(record.EncodingID) == EncodingWindowsUnicodeBMP || EncodingID(record.EncodingID) == EncodingWindowsUnicodeFullRepertoir || EncodingID(record.EncodingID) == EncodingWindowsSymbol) {
+			hasUnicode = true
+		}
+		if record.Format != 0 && record.Format != 4 && record.Format != 6 && record.Format != 12 {
+			v.warn("cmap", "subtable format %d for platform %d encoding %d is not supported", record.Format, record.PlatformID, record.EncodingID)
+		}
+	}
+	if !hasUnicode {
+		v.error("cmap", "no Unicode or Windows symbol subtable")
+	}
+	numGlyphs := sfnt.Maxp.NumGlyphs
+	for _, subtable := range sfnt.Cmap.Subtables {
+		switch subtable := subtable.(type) {
+		case *cmapFormat4:
+			if !validCmapFormat4(subtable, numGlyphs) {
+				v.error("cmap", "bad glyphID in format 4 subtable")
+			}
+		case *cmapFormat6:
+			for _, glyphID := range subtable.GlyphIdArray {
+				if numGlyphs <= glyphID {
+					v.error("cmap", "bad glyphID in format 6 subtable")
+					break
+				}
+			}
+		case *cmapFormat12:
+			for i := range subtable.StartCharCode {
+				if subtable.EndCharCode[i] < subtable.StartCharCode[i] {
+					continue
+				}
+				last := uint64(subtable.StartGlyphID[i]) + uint64(subtable.EndCharCode[i]-subtable.StartCharCode[i])
+				if uint64(numGlyphs) <= last {
+					v.error("cmap", "bad glyphID in format 12 subtable")
+					break
+				}
+			}
+		}
+	}
+}
+
+// validCmapFormat4 returns true if all glyph IDs of the format 4 subtable are valid. Glyph IDs are computed per segment as in cmapFormat4.Get, without looping over each character code.
+func validCmapFormat4(subtable *cmapFormat4, numGlyphs uint16) bool {
+	// invalid[k] is the number of glyph IDs in GlyphIdArray[:k] that are out of range
+	invalid := make([]int, len(subtable.GlyphIdArray)+1)
+	for k, glyphID := range subtable.GlyphIdArray {
+		invalid[k+1] = invalid[k]
+		if numGlyphs <= glyphID {
+			invalid[k+1]++
+		}
+	}
+
+	n := len(subtable.StartCode)
+	for i := 0; i < n; i++ {
+		if subtable.EndCode[i] < subtable.StartCode[i] {
+			continue
+		}
+		length := int(subtable.EndCode[i]-subtable.StartCode[i]) + 1
+		if subtable.IdRangeOffset[i] == 0 {
+			// glyph IDs are a contiguous range modulo 65536
+			first := int(uint16(subtable.IdDelta[i]) + subtable.StartCode[i])
+			if int(numGlyphs) < first+length {
+				return false
+			}
+		} else {
+			first := int(subtable.IdRangeOffset[i]/2) - (n - i)
+			if first < 0 || len(subtable.GlyphIdArray) < first+length || invalid[first] != invalid[first+length] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (v *validator) validateName(sfnt *SFNT) {
+	has := map[NameID]bool{}
+	for _, record := range sfnt.Name.NameRecord {
+		switch record.Platform {
+		case PlatformUnicode:
+			if len(record.Value)%2 != 0 {
+				v.error("name", "odd length UTF-16 string for name %d", record.Name)
+				continue
+			}
+		case PlatformWindows:
+			if record.Encoding != EncodingWindowsSymbol && record.Encoding != EncodingWindowsUnicodeBMP && record.Encoding != EncodingWindowsUnicodeFullRepertoir {
+				v.warn("name", "unsupported Windows encoding %d for name %d", record.Encoding, record.Name)
+				continue
+			} else if len(record.Value)%2 != 0 {
+				v.error("name", "odd length UTF-16 string for name %d", record.Name)
+				continue
+			}
+			if 0x8000 <= record.Language && len(sfnt.Name.LangTag) <= int(record.Language-0x8000) {
+				v.error("name", "bad language tag ID %d for name %d", record.Language, record.Name)
+			}
+		case PlatformMacintosh:
+			if record.Encoding != EncodingMacintoshRoman {
+				v.warn("name", "unsupported Macintosh encoding %d for name %d", record.Encoding, record.Name)
+				continue
+			}
+		default:
+			v.warn("name", "unsupported platform %d for name %d", record.Platform, record.Name)
+			continue
+		}
+		if record.Platform != PlatformMacintosh {
+			units := make([]uint16, len(record.Value)/2)
+			for i := range units {
+				units[i] = binary.BigEndian.Uint16(record.Value[2*i:])
+			}
+			for i := 0; i < len(units); i++ {
+				if utf16.IsSurrogate(rune(units[i])) {
+					if units[i] < 0xDC00 && i+1 < len(units) && 0xDC00 <= units[i+1] && units[i+1] < 0xE000 {
+						i++
+					} else {
+						v.error("name", "invalid UTF-16 surrogate in name %d", record.Name)
+						break
+					}
+				}
+			}
+		}
+		if record.Name == NamePostScript {
+			s := record.String()
+			if 63 < len(s) {
+				v.warn("name", "PostScript name longer than 63 characters")
+			}
+			for _, c := range s {
+				if c < 33 || 126 < c || strings.ContainsRune("[](){}<>/%", c) {
+					v.warn("name", "PostScript name contains invalid character %q", c)
+					break
+				}
+			}
+		}
+		has[record.Name] = true
+	}
+	for _, name := range []NameID{NameFontFamily, NameFontSubfamily, NameFull, NamePostScript} {
+		if !has[name] {
+			v.warn("name", "missing name %d", name)
+		}
+	}
+}
+
+func (v *validator) validateOS2(sfnt *SFNT) {
+	if sfnt.OS2.UsWeightClass < 1 || 1000 < sfnt.OS2.UsWeightClass {
+		v.warn("OS/2", "usWeightClass %d must be between 1 and 1000", sfnt.OS2.UsWeightClass)
+	}
+	if sfnt.OS2.UsWidthClass < 1 || 9 < sfnt.OS2.UsWidthClass {
+		v.warn("OS/2", "usWidthClass %d must be between 1 and 9", sfnt.OS2.UsWidthClass)
+	}
+	if sfnt.OS2.FsType&0x000E != 0 && bitCount(sfnt.OS2.FsType&0x000E) != 1 {
+		v.warn("OS/2", "fsType has more than one usage permission bit set")
+	}
+}
+
+func bitCount(v uint16) (n int) {
+	for ; v != 0; v &= v - 1 {
+		n++
+	}
+	return
+}
+
+func (v *validator) validateCFF(sfnt *SFNT, tag string) {
+	if sfnt.CFF == nil {
+		return
+	}
+	numCharStrings := len(sfnt.CFF.charStrings.offset) - 1
+	if numCharStrings != int(sfnt.Maxp.NumGlyphs) {
+		v.error(tag, "number of charstrings %d does not match numGlyphs %d in maxp", numCharStrings, sfnt.Maxp.NumGlyphs)
+	}
+	for glyphID := 0; glyphID < numCharStrings; glyphID++ {
+		v.run(tag, func() error {
+			if err := sfnt.CFF.ToPath(&bboxPather{}, uint16(glyphID), 0, 0, 0, 1.0, NoHinting); err != nil {
+				return fmt.Errorf("glyph %d: %w", glyphID, err)
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package font
+
+import (
+	"testing"
+)
+
+// testCmapFormat4 returns a cmap table with a format 4 subtable that maps 'a' and 'b' to the given glyph ID and the next.
+func testCmapFormat4(glyphID uint16) []byte {
+	w := NewBinaryWriter([]byte{})
+	w.WriteUint16(0)  // version
+	w.WriteUint16(1)  // numTables
+	w.WriteUint16(3)  // platformID
+	w.WriteUint16(1)  // encodingID
+	w.WriteUint32(12) // subtableOffset
+
+	w.WriteUint16(4)  // format
+	w.WriteUint16(32) // length
+	w.WriteUint16(0)  // language
+	w.WriteUint16(4)  // segCountX2
+	w.WriteUint16(4)  // searchRange
+	w.WriteUint16(1)  // entrySelector
+	w.WriteUint16(0)  // rangeShift
+	w.WriteUint16('b')
+	w.WriteUint16(0xFFFF) // endCode
+	w.WriteUint16(0)      // reservedPad
+	w.WriteUint16('a')
+	w.WriteUint16(0xFFFF) // startCode
+	w.WriteUint16(glyphID - 'a')
+	w.WriteUint16(1) // idDelta
+	w.WriteUint16(0)
+	w.WriteUint16(0) // idRangeOffset
+	return w.Bytes()
+}
+
+func TestValidateCmapFormat4(t *testing.T) {
+	sfnt := testLayoutFont(t, 1000, []rune{'a', 'b'}, []float64{100, 200}, -50)
+	sfnt.Tables["cmap"] = testCmapFormat4(1)
+	if report := Validate(sfnt.Write()); !report.Valid() {
+		t.Fatal(report)
+	}
+
+	sfnt.Tables["cmap"] = testCmapFormat4(2) // 'b' maps to glyph 3
+	if report := Validate(sfnt.Write()); report.Valid() {
+		t.Fatal("expected bad glyphID in format 4 subtable")
+	} else if errors := report.Errors(); len(errors) != 1 || errors[0].Table != "cmap" {
+		t.Fatal(report)
+	}
+}
+
+func TestValidatorIssueLimit(t *testing.T) {
+	v := &validator{Report: &Report{}}
+	for i := 0; i < 2*maxIssuesPerTable; i++ {
+		v.warn("glyf", "warning %d", i)
+		v.error("glyf", "error %d", i)
+	}
+	if n := len(v.Warnings()); n != maxIssuesPerTable+1 {
+		t.Fatalf("has %d warnings, expected %d", n, maxIssuesPerTable+1)
+	} else if n := len(v.Errors()); n != maxIssuesPerTable+1 {
+		t.Fatalf("has %d errors, expected %d", n, maxIssuesPerTable+1)
+	} else if !v.tableHasErrors("glyf") {
+		t.Fatal("expected errors for glyf")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 )
 
 type woffTable struct {
@@ -15,6 +16,7 @@ type woffTable struct {
 	length       uint32
 	origLength   uint32
 	origChecksum uint32
+	data         []byte // only used by ToWOFF
 }
 
 type tablePositions struct {
@@ -44,8 +46,9 @@ func (table *tablePositions) HasOverlap() bool {
 	return false
 }
 
-// ParseWOFF parses the WOFF font format and returns its contained SFNT font format (TTF or OTF). See https://www.w3.org/TR/WOFF/
-func ParseWOFF(b []byte) ([]byte, error) {
+// ParseWOFF parses the WOFF font format and returns its contained SFNT font format (TTF or OTF). Optionally, parse options can be passed to set the memory limit. See https://www.w3.org/TR/WOFF/
+func ParseWOFF(b []byte, opts ...ParseOptions) ([]byte, error) {
+	o := parseOptions(opts)
 	if len(b) < 44 {
 		return nil, ErrInvalidFontData
 	}
@@ -139,7 +142,7 @@ func ParseWOFF(b []byte) ([]byte, error) {
 	rangeShift = numTables*16 - searchRange
 
 	// write offset table
-	if MaxMemory < totalSfntSize {
+	if o.MaxMemory < totalSfntSize {
 		return nil, ErrExceedsMemory
 	}
 	w := NewBinaryWriter(make([]byte, totalSfntSize))
@@ -223,3 +226,86 @@ func ParseWOFF(b []byte) ([]byte, error) {
 	binary.BigEndian.PutUint32(buf[checksumAdjustmentPos:], checksumAdjustment)
 	return buf, nil
 }
+
+// ToWOFF converts an SFNT font file (TTF or OTF) to the WOFF font format. Tables are compressed with zlib when that makes them smaller.
+func ToWOFF(b []byte) ([]byte, error) {
+	if len(b) < 12 {
+		return nil, ErrInvalidFontData
+	}
+	r := NewBinaryReader(b)
+	flavor := r.ReadUint32()
+	if flavor != 0x00010000 && uint32ToString(flavor) != "OTTO" {
+		return nil, fmt.Errorf("bad SFNT version")
+	}
+	numTables := r.ReadUint16()
+	_ = r.ReadBytes(6) // searchRange, entrySelector, rangeShift
+
+	tables := make([]woffTable, numTables)
+	totalSfntSize := 12 + 16*uint32(numTables)
+	for i := range tables {
+		tag := r.ReadString(4)
+		_ = r.ReadUint32() // checksum
+		offset := r.ReadUint32()
+		length := r.ReadUint32()
+		if r.EOF() || uint32(len(b)) < offset || uint32(len(b))-offset < length {
+			return nil, ErrInvalidFontData
+		}
+		table := b[offset : offset+length : offset+length]
+		tables[i] = woffTable{
+			tag:          tag,
+			length:       length,
+			origLength:   length,
+			origChecksum: tableChecksum(tag, table),
+			data:         table,
+		}
+
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(table); err != nil {
+			return nil, fmt.Errorf("%s: %v", tag, err)
+		} else if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("%s: %v", tag, err)
+		}
+		if buf.Len() < len(table) {
+			tables[i].length = uint32(buf.Len())
+			tables[i].data = buf.Bytes()
+		}
+		totalSfntSize += (length + 3) &^ 3
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	offset := 44 + 20*uint32(numTables)
+	for i := range tables {
+		tables[i].offset = offset
+		offset += (tables[i].length + 3) &^ 3
+	}
+
+	w := NewBinaryWriter(make([]byte, 0, offset))
+	w.WriteString("wOFF")
+	w.WriteUint32(flavor)
+	w.WriteUint32(offset) // length
+	w.WriteUint16(numTables)
+	w.WriteUint16(0) // reserved
+	w.WriteUint32(totalSfntSize)
+	w.WriteUint16(1) // majorVersion
+	w.WriteUint16(0) // minorVersion
+	w.WriteUint32(0) // metaOffset
+	w.WriteUint32(0) // metaLength
+	w.WriteUint32(0) // metaOrigLength
+	w.WriteUint32(0) // privOffset
+	w.WriteUint32(0) // privLength
+	for _, table := range tables {
+		w.WriteString(table.tag)
+		w.WriteUint32(table.offset)
+		w.WriteUint32(table.length)
+		w.WriteUint32(table.origLength)
+		w.WriteUint32(table.origChecksum)
+	}
+	for _, table := range tables {
+		w.WriteBytes(table.data)
+		for w.Len()%4 != 0 {
+			w.WriteByte(0x00)
+		}
+	}
+	return w.Bytes(), nil
+}
@@ -49,8 +49,9 @@ var woff2TableTags = []string{
 	"Gloc", "Feat", "Sill",
 }
 
-// ParseWOFF2 parses the WOFF2 font format and returns its contained SFNT font format (TTF or OTF). See https://www.w3.org/TR/WOFF2/
-func ParseWOFF2(b []byte) ([]byte, error) {
+// ParseWOFF2 parses the WOFF2 font format and returns its contained SFNT font format (TTF or OTF). Optionally, parse options can be passed to set the memory limit and maximum number of glyphs. See https://www.w3.org/TR/WOFF2/
+func ParseWOFF2(b []byte, opts ...ParseOptions) ([]byte, error) {
+	o := parseOptions(opts)
 	if len(b) < 48 {
 		return nil, ErrInvalidFontData
 	}
@@ -164,7 +165,7 @@ func ParseWOFF2(b []byte) ([]byte, error) {
 	compData := r.ReadBytes(totalCompressedSize)
 	if r.EOF() {
 		return nil, ErrInvalidFontData
-	} else if MaxMemory < uncompressedSize {
+	} else if o.MaxMemory < uncompressedSize {
 		return nil, ErrExceedsMemory
 	}
 	rBrotli, _ := brotli.NewReader(bytes.NewReader(compData), nil) // err is always nil
@@ -199,6 +200,10 @@ func ParseWOFF2(b []byte) ([]byte, error) {
 	// detransform font data tables
 	if hasGlyf {
 		if tables[iGlyf].transformVersion == 0 {
+			if 6 <= len(tables[iGlyf].data) && o.MaxGlyphs < binary.BigEndian.Uint16(tables[iGlyf].data[4:]) {
+				return nil, fmt.Errorf("glyf: too many glyphs")
+			}
+
 			var err error
 			tables[iGlyf].data, tables[iLoca].data, err = reconstructGlyfLoca(tables[iGlyf].data, tables[iLoca].origLength)
 			if err != nil {
@@ -277,7 +282,7 @@ func ParseWOFF2(b []byte) ([]byte, error) {
 	rangeShift = numTables*16 - searchRange
 
 	// write offset table
-	if MaxMemory < totalSfntSize {
+	if o.MaxMemory < totalSfntSize {
 		return nil, ErrExceedsMemory
 	}
 	w := NewBinaryWriter(make([]byte, totalSfntSize)) // initial guess, will be bigger
@@ -769,3 +774,137 @@ func read255Uint16(r *BinaryReader) uint16 {
 		return uint16(code)
 	}
 }
+
+// ToWOFF2 converts an SFNT font file (TTF or OTF) to the WOFF2 font format. Tables are stored untransformed in a valid Brotli stream of uncompressed blocks, since there is no Brotli encoder available, so that the result is about as large as the original. The DSIG table is removed as it is invalidated by the conversion.
+func ToWOFF2(b []byte) ([]byte, error) {
+	if len(b) < 12 {
+		return nil, ErrInvalidFontData
+	}
+	r := NewBinaryReader(b)
+	flavor := r.ReadUint32()
+	if flavor != 0x00010000 && uint32ToString(flavor) != "OTTO" {
+		return nil, fmt.Errorf("bad SFNT version")
+	}
+	numTables := r.ReadUint16()
+	_ = r.ReadBytes(6) // searchRange, entrySelector, rangeShift
+
+	tables := make([]woff2Table, 0, numTables)
+	for i := 0; i < int(numTables); i++ {
+		tag := r.ReadString(4)
+		_ = r.ReadUint32() // checksum
+		offset := r.ReadUint32()
+		length := r.ReadUint32()
+		if r.EOF() || uint32(len(b)) < offset || uint32(len(b))-offset < length {
+			return nil, ErrInvalidFontData
+		} else if tag == "DSIG" {
+			// the signature is invalidated by the conversion and must be removed
+			continue
+		}
+		table := woff2Table{
+			tag:        tag,
+			origLength: length,
+			data:       b[offset : offset+length],
+		}
+		if tag == "head" {
+			if length < 18 {
+				return nil, fmt.Errorf("head: bad table")
+			}
+			// set bit 11 of flags to indicate a lossless conversion
+			head := append([]byte{}, table.data...)
+			binary.BigEndian.PutUint16(head[16:], binary.BigEndian.Uint16(head[16:])|0x0800)
+			table.data = head
+		}
+		tables = append(tables, table)
+	}
+	numTables = uint16(len(tables))
+	totalSfntSize := 12 + 16*uint32(numTables)
+	for _, table := range tables {
+		totalSfntSize += (table.origLength + 3) &^ 3
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag }) // glyf before loca
+
+	data := []byte{}
+	directory := NewBinaryWriter([]byte{})
+	for _, table := range tables {
+		flags := byte(63)
+		for i, tag := range woff2TableTags {
+			if tag == table.tag {
+				flags = byte(i)
+				break
+			}
+		}
+		if table.tag == "glyf" || table.tag == "loca" {
+			flags |= 3 << 6 // null transform
+		}
+		directory.WriteByte(flags)
+		if flags&0x3F == 63 {
+			directory.WriteString(table.tag)
+		}
+		writeUintBase128(directory, table.origLength)
+		data = append(data, table.data...)
+	}
+	compData := brotliStored(data)
+
+	w := NewBinaryWriter([]byte{})
+	w.WriteString("wOF2")
+	w.WriteUint32(flavor)
+	w.WriteUint32(0) // length
+	w.WriteUint16(numTables)
+	w.WriteUint16(0) // reserved
+	w.WriteUint32(totalSfntSize)
+	w.WriteUint32(uint32(len(compData)))
+	w.WriteUint16(1) // majorVersion
+	w.WriteUint16(0) // minorVersion
+	w.WriteUint32(0) // metaOffset
+	w.WriteUint32(0) // metaLength
+	w.WriteUint32(0) // metaOrigLength
+	w.WriteUint32(0) // privOffset
+	w.WriteUint32(0) // privLength
+	w.WriteBytes(directory.Bytes())
+	w.WriteBytes(compData)
+	for w.Len()%4 != 0 {
+		w.WriteByte(0x00)
+	}
+
+	buf := w.Bytes()
+	binary.BigEndian.PutUint32(buf[8:], uint32(len(buf)))
+	return buf, nil
+}
+
+// brotliStored returns a Brotli stream that stores the data in uncompressed meta-blocks of at most 65536 bytes.
+func brotliStored(data []byte) []byte {
+	if len(data) == 0 {
+		return []byte{0x06} // WBITS=16, ISLAST, ISLASTEMPTY
+	}
+	b := []byte{}
+	for i := 0; i < len(data); i += 65536 {
+		n := len(data) - i
+		if 65536 < n {
+			n = 65536
+		}
+
+		// ISLAST=0, MNIBBLES=4, MLEN-1, ISUNCOMPRESSED=1, padded to a byte boundary
+		header := uint32(n-1)<<3 | 1<<19
+		if i == 0 {
+			header <<= 1 // WBITS=16
+		}
+		b = append(b, byte(header), byte(header>>8), byte(header>>16))
+		b = append(b, data[i:i+n]...)
+	}
+	return append(b, 0x03) // ISLAST, ISLASTEMPTY
+}
+
+func writeUintBase128(w *BinaryWriter, v uint32) {
+	// see https://www.w3.org/TR/WOFF2/#DataTypes
+	n := 1
+	for tmp := v >> 7; tmp != 0; tmp >>= 7 {
+		n++
+	}
+	for i := n - 1; 0 <= i; i-- {
+		b := byte(v>>(7*uint(i))) & 0x7F
+		if i != 0 {
+			b |= 0x80
+		}
+		w.WriteByte(b)
+	}
+}
@@ -0,0 +1,54 @@
+package font
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToWOFF2(t *testing.T) {
+	builder := NewBuilder("Test", 1000)
+	glyph := builder.AddGlyph("a", 600, 'a')
+	glyph.MoveTo(100, 0)
+	glyph.LineTo(500, 0)
+	glyph.LineTo(500, 700)
+	glyph.LineTo(100, 700)
+	glyph.Close()
+	b, err := builder.WriteTrueType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfnt := addTables(t, b, map[string][]byte{
+		"DSIG": {0, 0, 0, 1, 0, 0, 0, 0}, // version, numSignatures, flags
+	})
+
+	woff2, err := ToWOFF2(sfnt.Write())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = ParseWOFF2(woff2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := Validate(b); !report.Valid() {
+		t.Fatal(report)
+	}
+	sfnt2, err := ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sfnt2.Tables["DSIG"]; ok {
+		t.Fatal("DSIG table not removed")
+	} else if len(sfnt2.Tables) != len(sfnt.Tables)-1 {
+		t.Fatalf("has %d tables, expected %d", len(sfnt2.Tables), len(sfnt.Tables)-1)
+	}
+	for tag, table := range sfnt.Tables {
+		if tag == "DSIG" || tag == "head" {
+			continue
+		} else if !bytes.Equal(sfnt2.Tables[tag], table) {
+			t.Fatalf("%s: table differs", tag)
+		}
+	}
+	if sfnt2.GlyphIndex('a') != 1 {
+		t.Fatal("bad cmap")
+	}
+}
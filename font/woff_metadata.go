@@ -0,0 +1,247 @@
+package font
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dsnet/compress/brotli"
+	"golang.org/x/text/language"
+)
+
+// WOFFExtendedData returns the decompressed extended metadata and the private data blocks of a WOFF or WOFF2 font. The metadata is an XML document that can be parsed with ParseWOFFMetadata. Either is nil when not present. Optionally, parse options can be passed to set the memory limit.
+func WOFFExtendedData(b []byte, opts ...ParseOptions) ([]byte, []byte, error) {
+	o := parseOptions(opts)
+	if len(b) < 48 {
+		return nil, nil, ErrInvalidFontData
+	}
+
+	r := NewBinaryReader(b)
+	signature := r.ReadString(4)
+	if signature != "wOFF" && signature != "wOF2" {
+		return nil, nil, fmt.Errorf("bad signature")
+	}
+	_ = r.ReadUint32() // flavor
+	length := r.ReadUint32()
+	_ = r.ReadUint16() // numTables
+	_ = r.ReadUint16() // reserved
+	_ = r.ReadUint32() // totalSfntSize
+	if signature == "wOF2" {
+		_ = r.ReadUint32() // totalCompressedSize
+	}
+	_ = r.ReadUint16() // majorVersion
+	_ = r.ReadUint16() // minorVersion
+	metaOffset := r.ReadUint32()
+	metaLength := r.ReadUint32()
+	metaOrigLength := r.ReadUint32()
+	privOffset := r.ReadUint32()
+	privLength := r.ReadUint32()
+	if r.EOF() {
+		return nil, nil, ErrInvalidFontData
+	} else if length != uint32(len(b)) {
+		return nil, nil, fmt.Errorf("length in header must match file size")
+	}
+
+	var metadata, private []byte
+	if metaOffset != 0 && metaLength != 0 {
+		if length < metaOffset || length-metaOffset < metaLength {
+			return nil, nil, fmt.Errorf("metadata: extends beyond file size")
+		} else if o.MaxMemory < metaOrigLength {
+			return nil, nil, ErrExceedsMemory
+		}
+
+		var err error
+		var rc io.ReadCloser
+		data := b[metaOffset : metaOffset+metaLength]
+		if signature == "wOFF" {
+			if rc, err = zlib.NewReader(bytes.NewReader(data)); err != nil {
+				return nil, nil, fmt.Errorf("metadata: %v", err)
+			}
+		} else if rc, err = brotli.NewReader(bytes.NewReader(data), nil); err != nil {
+			return nil, nil, fmt.Errorf("metadata: %v", err)
+		}
+		if metadata, err = ioutil.ReadAll(io.LimitReader(rc, int64(metaOrigLength)+1)); err != nil {
+			return nil, nil, fmt.Errorf("metadata: %v", err)
+		} else if err = rc.Close(); err != nil {
+			return nil, nil, fmt.Errorf("metadata: %v", err)
+		} else if uint32(len(metadata)) != metaOrigLength {
+			return nil, nil, fmt.Errorf("metadata: decompressed length must be equal to metaOrigLength")
+		}
+	}
+	if privOffset != 0 && privLength != 0 {
+		if length < privOffset || length-privOffset < privLength {
+			return nil, nil, fmt.Errorf("private data: extends beyond file size")
+		}
+		private = b[privOffset : privOffset+privLength : privOffset+privLength]
+	}
+	return metadata, private, nil
+}
+
+// WOFFMetadata is the extended metadata of a WOFF or WOFF2 font, see https://www.w3.org/TR/WOFF/#Metadata. Localized texts contain one entry per language.
+type WOFFMetadata struct {
+	Version     string          `xml:"version,attr"`
+	UniqueID    string          `xml:"-"`
+	Vendor      WOFFVendor      `xml:"vendor"`
+	Credits     []WOFFCredit    `xml:"credits>credit"`
+	Description WOFFTexts       `xml:"description>text"`
+	License     WOFFLicense     `xml:"license"`
+	Copyright   WOFFTexts       `xml:"copyright>text"`
+	Trademark   WOFFTexts       `xml:"trademark>text"`
+	Licensee    string          `xml:"-"`
+	Extensions  []WOFFExtension `xml:"extension"`
+}
+
+// WOFFVendor is the vendor of a font in the WOFF metadata.
+type WOFFVendor struct {
+	Name  string `xml:"name,attr"`
+	URL   string `xml:"url,attr"`
+	Dir   string `xml:"dir,attr"`
+	Class string `xml:"class,attr"`
+}
+
+// WOFFCredit is a credited person or organization in the WOFF metadata.
+type WOFFCredit struct {
+	Name  string `xml:"name,attr"`
+	URL   string `xml:"url,attr"`
+	Role  string `xml:"role,attr"`
+	Dir   string `xml:"dir,attr"`
+	Class string `xml:"class,attr"`
+}
+
+// WOFFLicense is the license of a font in the WOFF metadata, with an optional URL and identifier and the localized license text.
+type WOFFLicense struct {
+	URL  string    `xml:"url,attr"`
+	ID   string    `xml:"id,attr"`
+	Text WOFFTexts `xml:"text"`
+}
+
+// WOFFExtension is a vendor-specific extension in the WOFF metadata.
+type WOFFExtension struct {
+	ID    string              `xml:"id,attr"`
+	Name  WOFFTexts           `xml:"name"`
+	Items []WOFFExtensionItem `xml:"item"`
+}
+
+// WOFFExtensionItem is a named value of an extension in the WOFF metadata.
+type WOFFExtensionItem struct {
+	ID    string    `xml:"id,attr"`
+	Name  WOFFTexts `xml:"name"`
+	Value WOFFTexts `xml:"value"`
+}
+
+// WOFFText is a localized text in the WOFF metadata. The contents of nested div and span elements are included in the text.
+type WOFFText struct {
+	Lang  string
+	Dir   string
+	Class string
+	Text  string
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (text *WOFFText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "lang":
+			text.Lang = attr.Value
+		case "dir":
+			text.Dir = attr.Value
+		case "class":
+			text.Class = attr.Value
+		}
+	}
+
+	sb := strings.Builder{}
+	for depth := 0; ; {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "div" && 0 < sb.Len() {
+				sb.WriteString("\n")
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				text.Text = strings.TrimSpace(sb.String())
+				return nil
+			}
+			depth--
+		case xml.CharData:
+			sb.Write(t)
+		}
+	}
+}
+
+// WOFFTexts are the translations of a text in the WOFF metadata.
+type WOFFTexts []WOFFText
+
+// Get returns the text for the given BCP-47 language tags in order of preference, falling back to the text without a language, then to English, and then to the first text. It returns an empty string if there are no texts.
+func (texts WOFFTexts) Get(languages ...string) string {
+	if len(texts) == 0 {
+		return ""
+	}
+
+	// the first supported tag is the fallback when no language matches
+	fallback := -1
+	for i, text := range texts {
+		if text.Lang == "" {
+			fallback = i
+			break
+		} else if tag, err := language.Parse(text.Lang); err == nil && fallback == -1 {
+			if base, _ := tag.Base(); base.String() == "en" {
+				fallback = i
+			}
+		}
+	}
+	if fallback == -1 {
+		fallback = 0
+	}
+	supported := []language.Tag{language.Make(texts[fallback].Lang)}
+	indices := []int{fallback}
+	for i, text := range texts {
+		if i != fallback {
+			supported = append(supported, language.Make(text.Lang))
+			indices = append(indices, i)
+		}
+	}
+
+	preferred := make([]language.Tag, 0, len(languages))
+	for _, lang := range languages {
+		if tag, err := language.Parse(lang); err == nil {
+			preferred = append(preferred, tag)
+		}
+	}
+	i := 0
+	if 0 < len(preferred) {
+		_, index, confidence := language.NewMatcher(supported).Match(preferred...)
+		if confidence != language.No {
+			i = index
+		}
+	}
+	return texts[indices[i]].Text
+}
+
+// ParseWOFFMetadata parses the extended metadata XML of a WOFF or WOFF2 font as returned by WOFFExtendedData.
+func ParseWOFFMetadata(b []byte) (*WOFFMetadata, error) {
+	raw := struct {
+		*WOFFMetadata
+		UniqueID struct {
+			ID string `xml:"id,attr"`
+		} `xml:"uniqueid"`
+		Licensee struct {
+			Name string `xml:"name,attr"`
+		} `xml:"licensee"`
+	}{WOFFMetadata: &WOFFMetadata{}}
+	if err := xml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("metadata: %v", err)
+	}
+	raw.WOFFMetadata.UniqueID = raw.UniqueID.ID
+	raw.WOFFMetadata.Licensee = raw.Licensee.Name
+	return raw.WOFFMetadata, nil
+}
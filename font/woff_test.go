@@ -0,0 +1,32 @@
+package font
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToWOFF(t *testing.T) {
+	sfnt := testLayoutFont(t, 1000, []rune{'a', 'b'}, []float64{100, 200}, -50)
+	woff, err := ToWOFF(sfnt.Write())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseWOFF(woff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := Validate(b); !report.Valid() {
+		t.Fatal(report)
+	}
+	sfnt2, err := ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sfnt2.Tables) != len(sfnt.Tables) {
+		t.Fatalf("has %d tables, expected %d", len(sfnt2.Tables), len(sfnt.Tables))
+	}
+	for tag, table := range sfnt.Tables {
+		if tag != "head" && !bytes.Equal(sfnt2.Tables[tag], table) {
+			t.Fatalf("%s: table differs", tag)
+		}
+	}
+}
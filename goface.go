@@ -0,0 +1,143 @@
+package canvas
+
+import (
+	"image"
+	"math"
+
+	"github.com/blackss2/canvas/font"
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// goFaceSubpixels is the number of horizontal subpixel positions per pixel at which glyphs are rasterized and cached.
+const goFaceSubpixels = 4
+
+// GoFontFace implements the golang.org/x/image/font.Face interface for a font face at a given resolution, so that it can be used with font.Drawer. Glyphs are rasterized without hinting and include the faux styles and offsets of the font face. Rasterized glyphs are cached per subpixel position. It is not safe for concurrent use.
+type GoFontFace struct {
+	Face       *FontFace
+	Resolution Resolution
+	Options    font.RasterOptions // LCD mode is not supported, call Close after changing the options to clear the cache
+
+	masks map[goFaceKey]*image.Alpha
+}
+
+type goFaceKey struct {
+	glyphID  uint16
+	subpixel int
+}
+
+// NewGoFontFace returns a golang.org/x/image/font.Face for the font face at the given resolution.
+func NewGoFontFace(face *FontFace, resolution Resolution) *GoFontFace {
+	return &GoFontFace{
+		Face:       face,
+		Resolution: resolution,
+		masks:      map[goFaceKey]*image.Alpha{},
+	}
+}
+
+// Close clears the glyph cache.
+func (f *GoFontFace) Close() error {
+	f.masks = map[goFaceKey]*image.Alpha{}
+	return nil
+}
+
+// Glyph returns the draw rectangle, the mask and its origin, and the advance of the glyph of a rune drawn with its origin at dot. It returns false if the font has no glyph for the rune.
+func (f *GoFontFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	glyphID := f.Face.Font.GlyphIndex(r)
+	if glyphID == 0 {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	// the origin is rounded to subpixels horizontally and to whole pixels vertically
+	x := int(math.Floor(float64(dot.X) * goFaceSubpixels / 64.0))
+	subpixel := x % goFaceSubpixels
+	if subpixel < 0 {
+		subpixel += goFaceSubpixels
+	}
+	origin := image.Pt((x-subpixel)/goFaceSubpixels, dot.Y.Round())
+
+	key := goFaceKey{glyphID, subpixel}
+	mask, ok := f.masks[key]
+	if !ok {
+		if f.masks == nil {
+			f.masks = map[goFaceKey]*image.Alpha{}
+		}
+		opts := f.Options
+		opts.LCD = false
+		var err error
+		mask, err = f.Face.GlyphMask(glyphID, f.Resolution, float64(subpixel)/goFaceSubpixels, 0.0, opts)
+		if err != nil {
+			return image.Rectangle{}, nil, image.Point{}, 0, false
+		} else if mask == nil {
+			mask = &image.Alpha{}
+		}
+		f.masks[key] = mask
+	}
+	return mask.Rect.Add(origin), mask, mask.Rect.Min, f.advance(glyphID), true
+}
+
+// GlyphBounds returns the bounding box of the glyph of a rune relative to its origin with the y-axis pointing down, and its advance. It returns false if the font has no glyph for the rune.
+func (f *GoFontFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	glyphID := f.Face.Font.GlyphIndex(r)
+	if glyphID == 0 {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	metrics := f.Face.GlyphMetrics(glyphID)
+	if metrics.Empty() {
+		return fixed.Rectangle26_6{}, f.advance(glyphID), true
+	}
+	dx := f.Face.mmPerEm * float64(f.Face.XOffset)
+	dy := f.Face.mmPerEm * float64(f.Face.YOffset)
+	bounds := fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: f.fixed(metrics.XMin + dx), Y: f.fixed(-metrics.YMax - dy)},
+		Max: fixed.Point26_6{X: f.fixed(metrics.XMax + dx), Y: f.fixed(-metrics.YMin - dy)},
+	}
+	return bounds, f.advance(glyphID), true
+}
+
+// GlyphAdvance returns the advance of the glyph of a rune. It returns false if the font has no glyph for the rune.
+func (f *GoFontFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	glyphID := f.Face.Font.GlyphIndex(r)
+	if glyphID == 0 {
+		return 0, false
+	}
+	return f.advance(glyphID), true
+}
+
+// Kern returns the kerning between two runes from the kern table.
+func (f *GoFontFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	sfnt := f.Face.Font.SFNT
+	return f.fixed(f.Face.mmPerEm * float64(sfnt.Kerning(sfnt.GlyphIndex(r0), sfnt.GlyphIndex(r1))))
+}
+
+// Metrics returns the metrics of the font face.
+func (f *GoFontFace) Metrics() xfont.Metrics {
+	metrics := f.Face.Metrics()
+	hhea := f.Face.Font.Hhea
+	caretSlope := image.Pt(int(hhea.CaretSlopeRun), int(hhea.CaretSlopeRise))
+	if caretSlope.Y == 0 {
+		caretSlope = image.Pt(0, 1)
+	}
+	if f.Face.FauxItalic != 0.0 {
+		// shear the caret, scaled up to keep precision
+		caretSlope = caretSlope.Mul(1000)
+		caretSlope.X += int(math.Round(f.Face.FauxItalic * float64(caretSlope.Y)))
+	}
+	return xfont.Metrics{
+		Height:     f.fixed(metrics.LineHeight),
+		Ascent:     f.fixed(metrics.Ascent),
+		Descent:    f.fixed(metrics.Descent),
+		XHeight:    f.fixed(metrics.XHeight),
+		CapHeight:  f.fixed(metrics.CapHeight),
+		CaretSlope: caretSlope,
+	}
+}
+
+func (f *GoFontFace) advance(glyphID uint16) fixed.Int26_6 {
+	return f.fixed(f.Face.GlyphMetrics(glyphID).Advance)
+}
+
+// fixed converts millimeters to pixels in 26.6 fixed point.
+func (f *GoFontFace) fixed(v float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(v * f.Resolution.DPMM() * 64.0))
+}
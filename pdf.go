@@ -0,0 +1,376 @@
+package canvas
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blackss2/canvas/font"
+	canvasText "github.com/blackss2/canvas/text"
+)
+
+// PDFWriter writes a PDF document with one or more pages of text. The fonts used are embedded with a ToUnicode CMap so that the text can be extracted, TrueType fonts are subsetted to the glyphs used in the document while CFF fonts are embedded entirely.
+type PDFWriter struct {
+	w     io.Writer
+	pages []*pdfPage
+}
+
+type pdfPage struct {
+	width, height float64
+	texts         []pdfText
+}
+
+type pdfText struct {
+	x, y float64
+	*Text
+}
+
+// pdfFont is an embedded font, where glyphs are referenced by their CID.
+type pdfFont struct {
+	*Font
+	name      string            // resource name
+	cids      map[uint16]uint16 // CIDs by glyph ID
+	glyphIDs  []uint16          // glyph IDs by CID for TrueType fonts
+	toUnicode *font.ToUnicodeMap
+}
+
+// NewPDFWriter returns a new PDF writer.
+func NewPDFWriter(w io.Writer) *PDFWriter {
+	return &PDFWriter{
+		w: w,
+	}
+}
+
+// NewPage starts a new page of the given size in millimeters.
+func (pw *PDFWriter) NewPage(width, height float64) {
+	pw.pages = append(pw.pages, &pdfPage{
+		width:  width,
+		height: height,
+	})
+}
+
+// DrawText draws text on the current page, where the origin of the text is placed at (x,y) in millimeters from the bottom-left of the page. A page of A4 size is started if there are no pages.
+func (pw *PDFWriter) DrawText(x, y float64, text *Text) {
+	if len(pw.pages) == 0 {
+		pw.NewPage(210.0, 297.0)
+	}
+	page := pw.pages[len(pw.pages)-1]
+	page.texts = append(page.texts, pdfText{x, y, text})
+}
+
+// Close writes the PDF document.
+func (pw *PDFWriter) Close() error {
+	if len(pw.pages) == 0 {
+		pw.NewPage(210.0, 297.0)
+	}
+	objs := &pdfObjects{}
+	catalog := objs.reserve()
+	pages := objs.reserve()
+
+	// collect the glyphs of each font
+	fonts := []*pdfFont{}
+	fontMap := map[*Font]*pdfFont{}
+	for _, page := range pw.pages {
+		for _, text := range page.texts {
+			for _, line := range text.lines {
+				for _, span := range line.spans {
+					f, ok := fontMap[span.Face.Font]
+					if !ok {
+						f = &pdfFont{
+							Font:      span.Face.Font,
+							name:      "F" + strconv.Itoa(len(fonts)),
+							cids:      map[uint16]uint16{0: 0},
+							glyphIDs:  []uint16{0}, // .notdef
+							toUnicode: font.NewToUnicodeMap(),
+						}
+						fonts = append(fonts, f)
+						fontMap[span.Face.Font] = f
+					}
+					for _, glyph := range span.Glyphs {
+						f.cid(glyph.ID)
+					}
+					canvasText.AddToUnicode(f.toUnicode, span.Glyphs)
+				}
+			}
+		}
+	}
+
+	fontRefs := make([]int, len(fonts))
+	for i, f := range fonts {
+		ref, err := f.write(objs)
+		if err != nil {
+			return err
+		}
+		fontRefs[i] = ref
+	}
+	resources := &strings.Builder{}
+	resources.WriteString("<< /Font <<")
+	for i, f := range fonts {
+		fmt.Fprintf(resources, " /%s %d 0 R", f.name, fontRefs[i])
+	}
+	resources.WriteString(" >> >>")
+
+	kids := []string{}
+	for _, page := range pw.pages {
+		content := &bytes.Buffer{}
+		fmt.Fprintf(content, "%s 0 0 %s 0 0 cm\n", pdfNum(1.0/mmPerPt), pdfNum(1.0/mmPerPt))
+		for _, text := range page.texts {
+			for _, line := range text.lines {
+				for _, span := range line.spans {
+					writePDFSpan(content, fontMap[span.Face.Font], span, text.x+span.x, text.y-line.y)
+				}
+			}
+		}
+		contentRef := objs.add(pdfStream("", content.Bytes(), true))
+		pageRef := objs.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>", pages, pdfNum(page.width/mmPerPt), pdfNum(page.height/mmPerPt), resources, contentRef))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageRef))
+	}
+	objs.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	objs.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	return objs.writeTo(pw.w, catalog)
+}
+
+// writePDFSpan writes a text span with its origin at (x,y) in millimeters. Glyphs are positioned by adjusting the glyph widths of the font, and glyph offsets by the text rise. Faux bold is drawn by stroking the glyphs, a negative faux bold only affects the glyph positions since outlines cannot be thinned by stroking.
+func writePDFSpan(w *bytes.Buffer, f *pdfFont, span TextSpan, x, y float64) {
+	face := span.Face
+	unitsPerEm := float64(f.Head.UnitsPerEm)
+	x += face.mmPerEm * float64(face.XOffset)
+	y += face.mmPerEm * float64(face.YOffset)
+
+	// the text state is restored after the span
+	w.WriteString("q BT\n")
+	c := color.NRGBAModel.Convert(face.Color).(color.NRGBA)
+	rgb := fmt.Sprintf("%s %s %s", pdfNum(float64(c.R)/255.0), pdfNum(float64(c.G)/255.0), pdfNum(float64(c.B)/255.0))
+	fmt.Fprintf(w, "%s rg\n", rgb)
+	if 0.0 < face.FauxBold {
+		// stroke the outline to offset it on each side
+		fmt.Fprintf(w, "%s RG %s w 2 Tr\n", rgb, pdfNum(2.0*face.fauxBold()))
+	}
+	x += face.fauxBold()
+	fmt.Fprintf(w, "/%s %s Tf\n", f.name, pdfNum(face.Size))
+	fmt.Fprintf(w, "1 0 %s 1 %s %s Tm\n", pdfNum(face.FauxItalic), pdfNum(x), pdfNum(y))
+
+	rise := int32(0)
+	inArray := false
+	adjust := 0.0 // in thousandths of an em
+	for _, glyph := range span.Glyphs {
+		if glyph.YOffset != rise {
+			if inArray {
+				w.WriteString("] TJ\n")
+				inArray = false
+			}
+			rise = glyph.YOffset
+			fmt.Fprintf(w, "%s Ts\n", pdfNum(face.mmPerEm*float64(rise)))
+		}
+		if !inArray {
+			w.WriteString("[")
+			inArray = true
+		}
+
+		// move to the glyph's offset, and from the glyph's width to its advance
+		adjust -= float64(glyph.XOffset) * 1000.0 / unitsPerEm
+		if adjust != 0.0 {
+			fmt.Fprintf(w, "%s", pdfNum(-adjust))
+		}
+		fmt.Fprintf(w, "<%04X>", f.cid(glyph.ID))
+		advance := face.glyphAdvance(glyph.XAdvance) / face.mmPerEm
+		adjust = (advance - float64(f.GlyphAdvance(glyph.ID)) + float64(glyph.XOffset)) * 1000.0 / unitsPerEm
+	}
+	if inArray {
+		w.WriteString("] TJ\n")
+	}
+	w.WriteString("ET Q\n")
+}
+
+// cid returns the CID of a glyph. For TrueType fonts the CIDs are the glyph IDs of the subsetted font in the order of first use in the document, while CFF fonts are embedded entirely and use the original glyph IDs.
+func (f *pdfFont) cid(glyphID uint16) uint16 {
+	if cid, ok := f.cids[glyphID]; ok {
+		return cid
+	}
+	cid := glyphID
+	if !f.IsCFF {
+		cid = uint16(len(f.glyphIDs))
+		f.glyphIDs = append(f.glyphIDs, glyphID)
+	}
+	f.cids[glyphID] = cid
+	return cid
+}
+
+// write writes the font objects and returns the reference to the Type0 font.
+func (f *pdfFont) write(objs *pdfObjects) (int, error) {
+	var glyphIDs []uint16
+	if !f.IsCFF {
+		glyphIDs = f.glyphIDs
+	}
+	fontProgram, _, err := f.SFNT.SubsetChecked(glyphIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	// CIDs in increasing order with their glyph IDs
+	cids := make([]int, 0, len(f.cids))
+	cidGlyphIDs := make(map[int]uint16, len(f.cids))
+	for glyphID, cid := range f.cids {
+		cids = append(cids, int(cid))
+		cidGlyphIDs[int(cid)] = glyphID
+	}
+	sort.Ints(cids)
+
+	// only subsetted fonts are tagged
+	baseFont := pdfName(f.NameString(font.NamePostScript), f.name)
+	if !f.IsCFF {
+		baseFont = subsetTag(f.glyphIDs) + "+" + baseFont
+	}
+
+	var fontFile int
+	if f.IsCFF {
+		fontFile = objs.add(pdfStream("/Subtype /OpenType", fontProgram, true))
+	} else {
+		fontFile = objs.add(pdfStream(fmt.Sprintf("/Length1 %d", len(fontProgram)), fontProgram, true))
+	}
+
+	// font descriptor
+	toEm := 1000.0 / float64(f.Head.UnitsPerEm)
+	flags := 1 << 2 // symbolic
+	if f.Post.IsFixedPitch != 0 {
+		flags |= 1 << 0
+	}
+	if f.Post.ItalicAngle != 0 {
+		flags |= 1 << 6
+	}
+	fontFileKey := "FontFile2"
+	if f.IsCFF {
+		fontFileKey = "FontFile3"
+	}
+	descriptor := objs.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%s %s %s %s] /ItalicAngle %s /Ascent %s /Descent %s /CapHeight %s /StemV %d /%s %d 0 R >>",
+		baseFont, flags,
+		pdfNum(float64(f.Head.XMin)*toEm), pdfNum(float64(f.Head.YMin)*toEm), pdfNum(float64(f.Head.XMax)*toEm), pdfNum(float64(f.Head.YMax)*toEm),
+		pdfNum(float64(int32(f.Post.ItalicAngle))/65536.0), pdfNum(float64(f.Hhea.Ascender)*toEm), pdfNum(float64(f.Hhea.Descender)*toEm), pdfNum(float64(f.OS2.SCapHeight)*toEm),
+		10+int(f.OS2.UsWeightClass)/10, fontFileKey, fontFile))
+
+	// widths of runs of consecutive CIDs
+	widths := &strings.Builder{}
+	for i, cid := range cids {
+		if i == 0 || cids[i-1]+1 != cid {
+			if i != 0 {
+				widths.WriteString("] ")
+			}
+			fmt.Fprintf(widths, "%d [", cid)
+		} else {
+			widths.WriteString(" ")
+		}
+		widths.WriteString(pdfNum(float64(f.GlyphAdvance(cidGlyphIDs[cid])) * toEm))
+	}
+	if 0 < len(cids) {
+		widths.WriteString("]")
+	}
+
+	subtype, cidToGIDMap := "CIDFontType2", " /CIDToGIDMap /Identity"
+	if f.IsCFF {
+		subtype, cidToGIDMap = "CIDFontType0", ""
+	}
+	cidFont := objs.add(fmt.Sprintf("<< /Type /Font /Subtype /%s /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s]%s >>", subtype, baseFont, descriptor, widths.String(), cidToGIDMap))
+	toUnicode := objs.add(pdfStream("", f.toUnicode.CMap(f.cid), true))
+	return objs.add(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", baseFont, cidFont, toUnicode)), nil
+}
+
+// subsetTag returns a tag of six uppercase letters that is derived from the glyphs of a font subset, to distinguish subsets of the same font.
+func subsetTag(glyphIDs []uint16) string {
+	hash := fnv.New32a()
+	for _, glyphID := range glyphIDs {
+		hash.Write([]byte{byte(glyphID >> 8), byte(glyphID)})
+	}
+	tag := make([]byte, 6)
+	for i, h := 0, hash.Sum32(); i < len(tag); i, h = i+1, h/26 {
+		tag[i] = byte('A' + h%26)
+	}
+	return string(tag)
+}
+
+// pdfName returns a valid PDF name without delimiters or whitespace, or the fallback if empty.
+func pdfName(name, fallback string) string {
+	if name == "" {
+		name = fallback
+	}
+	sb := &strings.Builder{}
+	for _, r := range name {
+		if '!' <= r && r <= '~' && !strings.ContainsRune("()<>[]{}/%#", r) {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return "Font"
+	}
+	return sb.String()
+}
+
+func pdfNum(v float64) string {
+	v = math.Round(v*1e5) / 1e5
+	if v == 0.0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// pdfStream returns a stream object with the extra dictionary entries, optionally compressed.
+func pdfStream(dict string, data []byte, compress bool) string {
+	if compress {
+		b := &bytes.Buffer{}
+		zw := zlib.NewWriter(b)
+		zw.Write(data)
+		zw.Close()
+		data = b.Bytes()
+		dict = strings.TrimSpace(dict + " /Filter /FlateDecode")
+	}
+	if dict != "" {
+		dict = " " + dict
+	}
+	return fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(data), dict, data)
+}
+
+////////////////////////////////////////////////////////////////
+
+// pdfObjects holds the indirect objects of a PDF document, numbered from one.
+type pdfObjects struct {
+	objs []string
+}
+
+func (objs *pdfObjects) reserve() int {
+	objs.objs = append(objs.objs, "")
+	return len(objs.objs)
+}
+
+func (objs *pdfObjects) set(ref int, obj string) {
+	objs.objs[ref-1] = obj
+}
+
+func (objs *pdfObjects) add(obj string) int {
+	objs.objs = append(objs.objs, obj)
+	return len(objs.objs)
+}
+
+// writeTo writes the document with its cross-reference table.
+func (objs *pdfObjects) writeTo(w io.Writer, root int) error {
+	b := &bytes.Buffer{}
+	b.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objs.objs))
+	for i, obj := range objs.objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(b, "xref\n0 %d\n0000000000 65535 f\r\n", len(objs.objs)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(b, "%010d 00000 n\r\n", offset)
+	}
+	fmt.Fprintf(b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs.objs)+1, root, xref)
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,186 @@
+package canvas
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/blackss2/canvas/font"
+	canvasText "github.com/blackss2/canvas/text"
+)
+
+// SVGOptions are options for writing text as SVG.
+type SVGOptions struct {
+	EmbedFonts bool // write text elements and embed the subsetted fonts as WOFF2, instead of writing glyph outlines as paths
+	Precision  int  // number of decimals of coordinates in millimeters, zero is equal to three and negative writes exact coordinates
+}
+
+// ToSVG writes the text as an SVG image with coordinates in millimeters. By default, the glyphs of each text span are written as the outlines of a single path element, including the faux styles of the font face. If EmbedFonts is set, each text span is written as a text element instead, and the fonts are embedded as subsetted WOFF2 in @font-face rules so that the text remains selectable. The embedded fonts are not compressed since there is no Brotli encoder available, so that each takes about the size of the subsetted TTF plus the base64 overhead. Note that text elements are shaped by the SVG viewer and may differ slightly from the text layout.
+func (t *Text) ToSVG(w io.Writer, opts ...SVGOptions) error {
+	o := SVGOptions{}
+	if 0 < len(opts) {
+		o = opts[0]
+	}
+	if o.Precision == 0 {
+		o.Precision = 3
+	}
+	num := func(v float64) string {
+		if 0 <= o.Precision {
+			scale := math.Pow10(o.Precision)
+			v = math.Round(v*scale) / scale
+		}
+		if v == 0.0 {
+			v = 0.0 // remove negative zero
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	rect := t.Bounds().Add(t.InkBounds())
+	wb := bufio.NewWriter(w)
+	fmt.Fprintf(wb, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%smm" height="%smm" viewBox="%s %s %s %s">`, num(rect.W), num(rect.H), num(rect.X), num(-rect.Y-rect.H), num(rect.W), num(rect.H))
+
+	families := map[*Font]string{}
+	if o.EmbedFonts {
+		if err := t.writeSVGFonts(wb, families); err != nil {
+			return err
+		}
+	}
+
+	p := &svgPather{font.NewSVGPather(o.Precision, false)}
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			// TODO: vertical text
+			face := span.Face
+			fill := svgFill(face.Color)
+			if o.EmbedFonts {
+				x := span.x + face.mmPerEm*float64(face.XOffset) + face.fauxBold()
+				y := line.y - face.mmPerEm*float64(face.YOffset)
+				fmt.Fprintf(wb, `<text x="%s" y="%s" font-family="%s" font-size="%s"%s`, num(x), num(y), families[face.Font], num(face.Size), fill)
+				if span.Direction == canvasText.RightToLeft {
+					fmt.Fprintf(wb, ` direction="rtl" text-anchor="end"`)
+				}
+				if face.FauxItalic != 0.0 {
+					// skew around the origin of the span
+					angle := -math.Atan(face.FauxItalic) * 180.0 / math.Pi
+					fmt.Fprintf(wb, ` transform="translate(%s %s) skewX(%s) translate(%s %s)"`, num(x), num(y), num(angle), num(-x), num(-y))
+				}
+				if 0.0 < face.FauxBold {
+					fmt.Fprintf(wb, ` stroke="%s" stroke-width="%s"`, svgColor(face.Color), num(2.0*face.fauxBold()))
+				}
+				fmt.Fprintf(wb, ` xml:space="preserve">`)
+				xml.EscapeText(wb, []byte(span.Text))
+				fmt.Fprintf(wb, `</text>`)
+				continue
+			}
+
+			p.Reset()
+			x, y := span.x, -line.y
+			for _, glyph := range span.Glyphs {
+				gx := x + face.mmPerEm*float64(glyph.XOffset)
+				gy := y + face.mmPerEm*float64(glyph.YOffset)
+				if err := face.GlyphPath(p, glyph.ID, gx, gy); err != nil {
+					return err
+				}
+				x += face.glyphAdvance(glyph.XAdvance)
+			}
+			if 0 < len(p.Bytes()) {
+				fmt.Fprintf(wb, `<path d="%s"%s/>`, p.Bytes(), fill)
+			}
+		}
+	}
+	fmt.Fprintf(wb, `</svg>`)
+	return wb.Flush()
+}
+
+// writeSVGFonts writes a style element with the fonts used by the text, subsetted to the used glyphs and characters, and sets the font family name of each font.
+func (t *Text) writeSVGFonts(w io.Writer, families map[*Font]string) error {
+	fonts := []*Font{}
+	glyphIDs := map[*Font]map[uint16]bool{}
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			f := span.Face.Font
+			if _, ok := glyphIDs[f]; !ok {
+				fonts = append(fonts, f)
+				glyphIDs[f] = map[uint16]bool{}
+			}
+			for _, glyph := range span.Glyphs {
+				glyphIDs[f][glyph.ID] = true
+			}
+			for _, r := range span.Text {
+				glyphIDs[f][f.GlyphIndex(r)] = true
+			}
+		}
+	}
+	if len(fonts) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, `<style>`)
+	for i, f := range fonts {
+		ids := []uint16{0}
+		for glyphID := range glyphIDs[f] {
+			if glyphID != 0 {
+				ids = append(ids, glyphID)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		subset, _, err := f.SFNT.SubsetChecked(ids)
+		if err != nil {
+			return err
+		}
+		woff2, err := font.ToWOFF2(subset)
+		if err != nil {
+			return err
+		}
+		families[f] = "f" + strconv.Itoa(i)
+		fmt.Fprintf(w, `@font-face{font-family:"%s";src:url("data:font/woff2;base64,%s") format("woff2");}`, families[f], base64.StdEncoding.EncodeToString(woff2))
+	}
+	fmt.Fprintf(w, `</style>`)
+	return nil
+}
+
+// svgPather flips the y-axis for SVG.
+type svgPather struct {
+	*font.SVGPather
+}
+
+func (p *svgPather) MoveTo(x, y float64) {
+	p.SVGPather.MoveTo(x, -y)
+}
+
+func (p *svgPather) LineTo(x, y float64) {
+	p.SVGPather.LineTo(x, -y)
+}
+
+func (p *svgPather) QuadTo(cpx, cpy, x, y float64) {
+	p.SVGPather.QuadTo(cpx, -cpy, x, -y)
+}
+
+func (p *svgPather) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	p.SVGPather.CubeTo(cpx1, -cpy1, cpx2, -cpy2, x, -y)
+}
+
+// svgColor returns the color as a hexadecimal RGB value.
+func svgColor(c color.RGBA) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+}
+
+// svgFill returns the fill attributes of the color, omitting the default of opaque black.
+func svgFill(c color.RGBA) string {
+	if c == (color.RGBA{0, 0, 0, 255}) {
+		return ""
+	}
+	s := fmt.Sprintf(` fill="%s"`, svgColor(c))
+	if c.A != 255 {
+		s += fmt.Sprintf(` fill-opacity="%s"`, strconv.FormatFloat(float64(c.A)/255.0, 'g', 3, 64))
+	}
+	return s
+}
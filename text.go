@@ -604,6 +604,28 @@ func (t *Text) Bounds() Rect {
 	return rect
 }
 
+// InkBounds returns the bounding rectangle of the glyph outlines, which is generally tighter than the text box returned by Bounds but may also extend beyond it.
+func (t *Text) InkBounds() Rect {
+	rect := Rect{}
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			// TODO: vertical text
+			x := span.x + span.Face.mmPerEm*float64(span.Face.XOffset)
+			y := -line.y + span.Face.mmPerEm*float64(span.Face.YOffset)
+			for _, glyph := range span.Glyphs {
+				m := span.Face.GlyphMetrics(glyph.ID)
+				if !m.Empty() {
+					gx := x + span.Face.mmPerEm*float64(glyph.XOffset)
+					gy := y + span.Face.mmPerEm*float64(glyph.YOffset)
+					rect = rect.Add(Rect{gx + m.XMin, gy + m.YMin, m.XMax - m.XMin, m.YMax - m.YMin})
+				}
+				x += span.Face.glyphAdvance(glyph.XAdvance)
+			}
+		}
+	}
+	return rect
+}
+
 // Fonts returns the list of fonts used.
 func (t *Text) Fonts() []*Font {
 	fonts := []*Font{}
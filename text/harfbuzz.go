@@ -5,8 +5,12 @@ package text
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"unicode/utf8"
 
+	"github.com/benoitkugler/textlayout/fonts"
 	"github.com/benoitkugler/textlayout/fonts/truetype"
 	"github.com/benoitkugler/textlayout/harfbuzz"
 	"github.com/benoitkugler/textlayout/language"
@@ -29,10 +33,61 @@ func NewShaper(b []byte, index int) (Shaper, error) {
 	}, nil
 }
 
-// NewShaperSFNT returns a new text shaper using a SFNT structure.
+// NewShaperSFNT returns a new text shaper using a SFNT structure. It reads from the same source as the SFNT, which does not need to be held in memory. Metrics and the character mapping are taken from the SFNT, and only the layout tables and, for fonts with Unicode variation sequences, the cmap table are read and parsed from the source.
 func NewShaperSFNT(sfnt *font.SFNT) (Shaper, error) {
-	// TODO: add interface to SFNT for use in this harfbuzz implementation
-	return NewShaper(sfnt.Data, 0)
+	r, index := sfnt.Reader()
+	r, err := sfntResource(r, index)
+	if err != nil {
+		return Shaper{}, err
+	}
+	font, err := truetype.Parse(r, false)
+	if err != nil {
+		return Shaper{}, err
+	}
+	face := &sfntFace{Font: font, sfnt: sfnt}
+	for _, record := range sfnt.Cmap.EncodingRecords {
+		if record.Format == 14 {
+			if face.cmap, err = cmapFont(sfnt); err != nil {
+				return Shaper{}, err
+			}
+			break
+		}
+	}
+	return Shaper{
+		font: harfbuzz.NewFont(face),
+	}, nil
+}
+
+// cmapFont returns a truetype font with only the cmap, head, and maxp tables of the SFNT, which is used to look up Unicode variation sequences from the format 14 cmap subtable.
+func cmapFont(sfnt *font.SFNT) (*truetype.Font, error) {
+	tags := []string{"cmap", "head", "maxp"}
+	tables := make([][]byte, len(tags))
+	offset := uint32(12 + 16*len(tags))
+	w := font.NewBinaryWriter([]byte{})
+	w.WriteUint32(0x00010000)        // sfntVersion
+	w.WriteUint16(uint16(len(tags))) // numTables
+	w.WriteUint16(0)                 // searchRange
+	w.WriteUint16(0)                 // entrySelector
+	w.WriteUint16(0)                 // rangeShift
+	for i, tag := range tags {
+		table, err := sfnt.Table(tag)
+		if err != nil {
+			return nil, err
+		}
+		tables[i] = table
+		w.WriteString(tag)
+		w.WriteUint32(0) // checksum
+		w.WriteUint32(offset)
+		w.WriteUint32(uint32(len(table)))
+		offset += (uint32(len(table)) + 3) &^ 3
+	}
+	for _, table := range tables {
+		w.WriteBytes(table)
+		for w.Len()%4 != 0 {
+			w.WriteByte(0x00)
+		}
+	}
+	return truetype.Parse(bytes.NewReader(w.Bytes()), true)
 }
 
 // Destroy destroys the allocated C memory.
@@ -92,6 +147,215 @@ func (s Shaper) Shape(text string, ppem uint16, direction Direction, script Scri
 	return glyphs
 }
 
+// sfntFace is a HarfBuzz font face that takes the metrics, glyph bounds, and character mapping from the SFNT. The truetype font is parsed without its tables, which are read on demand from the source. Unicode variation sequences are looked up in a separate truetype font that holds the cmap table.
+type sfntFace struct {
+	*truetype.Font
+	sfnt *font.SFNT
+	cmap *truetype.Font // only set for fonts with Unicode variation sequences
+}
+
+// LayoutTables reads and parses the layout tables, it is called once when creating the HarfBuzz font.
+func (f *sfntFace) LayoutTables() truetype.LayoutTables {
+	tables := truetype.LayoutTables{}
+	if f.HasTable(truetype.TagGdef) {
+		tables.GDEF, _ = f.GDEFTable()
+	}
+	if f.HasTable(truetype.TagGsub) {
+		tables.GSUB, _ = f.GSUBTable()
+	}
+	if f.HasTable(truetype.TagGpos) {
+		tables.GPOS, _ = f.GPOSTable()
+	}
+	if f.HasTable(truetype.MustNewTag("kern")) {
+		tables.Kern, _ = f.KernTable()
+	}
+	if f.HasTable(truetype.MustNewTag("morx")) {
+		tables.Morx, _ = f.MorxTable()
+	}
+	if f.HasTable(truetype.MustNewTag("kerx")) {
+		tables.Kerx, _ = f.KerxTable()
+	}
+	if f.HasTable(truetype.MustNewTag("ankr")) {
+		tables.Ankr, _ = f.AnkrTable()
+	}
+	if f.HasTable(truetype.MustNewTag("trak")) {
+		tables.Trak, _ = f.TrakTable()
+	}
+	if f.HasTable(truetype.MustNewTag("feat")) {
+		tables.Feat, _ = f.FeatTable()
+	}
+	return tables
+}
+
+func (f *sfntFace) Upem() uint16 {
+	return f.sfnt.Head.UnitsPerEm
+}
+
+func (f *sfntFace) GlyphName(glyph fonts.GID) string {
+	return f.sfnt.GlyphName(uint16(glyph))
+}
+
+func (f *sfntFace) LineMetric(metric fonts.LineMetric) (float32, bool) {
+	switch metric {
+	case fonts.UnderlinePosition:
+		return float32(f.sfnt.Post.UnderlinePosition), true
+	case fonts.UnderlineThickness:
+		return float32(f.sfnt.Post.UnderlineThickness), true
+	case fonts.StrikethroughPosition:
+		return float32(f.sfnt.OS2.YStrikeoutPosition), true
+	case fonts.StrikethroughThickness:
+		return float32(f.sfnt.OS2.YStrikeoutSize), true
+	case fonts.SuperscriptEmYSize:
+		return float32(f.sfnt.OS2.YSuperscriptYSize), true
+	case fonts.SuperscriptEmXOffset:
+		return float32(f.sfnt.OS2.YSuperscriptXOffset), true
+	case fonts.SubscriptEmYSize:
+		return float32(f.sfnt.OS2.YSubscriptYSize), true
+	case fonts.SubscriptEmYOffset:
+		return float32(f.sfnt.OS2.YSubscriptYOffset), true
+	case fonts.SubscriptEmXOffset:
+		return float32(f.sfnt.OS2.YSubscriptXOffset), true
+	}
+	return 0, false
+}
+
+func (f *sfntFace) FontHExtents() (fonts.FontExtents, bool) {
+	os2 := f.sfnt.OS2
+	if os2.FsSelection&0x0080 != 0 && (os2.UsWeightClass != 0 || os2.UsWidthClass != 0 || os2.UsFirstCharIndex != 0 || os2.UsLastCharIndex != 0) {
+		// USE_TYPO_METRICS
+		return fonts.FontExtents{
+			Ascender:  float32(abs16(os2.STypoAscender)),
+			Descender: -float32(abs16(os2.STypoDescender)),
+			LineGap:   float32(os2.STypoLineGap),
+		}, true
+	}
+	return fonts.FontExtents{
+		Ascender:  float32(abs16(f.sfnt.Hhea.Ascender)),
+		Descender: -float32(abs16(f.sfnt.Hhea.Descender)),
+		LineGap:   float32(f.sfnt.Hhea.LineGap),
+	}, true
+}
+
+func (f *sfntFace) FontVExtents() (fonts.FontExtents, bool) {
+	if f.sfnt.Vhea == nil {
+		return fonts.FontExtents{}, false
+	}
+	return fonts.FontExtents{
+		Ascender:  float32(abs16(f.sfnt.Vhea.Ascent)),
+		Descender: -float32(abs16(f.sfnt.Vhea.Descent)),
+		LineGap:   float32(f.sfnt.Vhea.LineGap),
+	}, true
+}
+
+func (f *sfntFace) NominalGlyph(ch rune) (fonts.GID, bool) {
+	glyphID := f.sfnt.GlyphIndex(ch)
+	return fonts.GID(glyphID), glyphID != 0
+}
+
+func (f *sfntFace) VariationGlyph(ch, varSelector rune) (fonts.GID, bool) {
+	if f.cmap == nil {
+		return 0, false
+	}
+	return f.cmap.VariationGlyph(ch, varSelector)
+}
+
+func (f *sfntFace) HorizontalAdvance(glyph fonts.GID) float32 {
+	return float32(f.sfnt.GlyphAdvance(uint16(glyph)))
+}
+
+func (f *sfntFace) VerticalAdvance(glyph fonts.GID) float32 {
+	return -float32(f.sfnt.GlyphVerticalAdvance(uint16(glyph)))
+}
+
+func (f *sfntFace) GlyphHOrigin(glyph fonts.GID) (int32, int32, bool) {
+	return 0, 0, true
+}
+
+func (f *sfntFace) GlyphVOrigin(glyph fonts.GID) (int32, int32, bool) {
+	x := int32(f.HorizontalAdvance(glyph) / 2)
+	if f.sfnt.Vmtx != nil {
+		if _, _, _, yMax, err := f.sfnt.GlyphBounds(uint16(glyph)); err == nil {
+			return x, int32(yMax) + int32(f.sfnt.Vmtx.TopSideBearing(uint16(glyph))), true
+		}
+	}
+	extents, ok := f.FontHExtents()
+	return x, int32(extents.Ascender), ok
+}
+
+func (f *sfntFace) GlyphExtents(glyph fonts.GID, xPpem, yPpem uint16) (fonts.GlyphExtents, bool) {
+	xMin, yMin, xMax, yMax, err := f.sfnt.GlyphBounds(uint16(glyph))
+	if err != nil {
+		return fonts.GlyphExtents{}, false
+	}
+	return fonts.GlyphExtents{
+		XBearing: float32(xMin),
+		YBearing: float32(yMax),
+		Width:    float32(xMax) - float32(xMin),
+		Height:   float32(yMin) - float32(yMax),
+	}, true
+}
+
+func abs16(v int16) int32 {
+	if v < 0 {
+		return -int32(v)
+	}
+	return int32(v)
+}
+
+// sfntResource returns a reader of the font at the given index as a single font file. Fonts in a collection are prefixed by a copy of their table directory, so that their tables can be read on demand.
+func sfntResource(r *io.SectionReader, index int) (*io.SectionReader, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	} else if string(header[:4]) != "ttcf" {
+		if index != 0 {
+			return nil, fmt.Errorf("bad font index %d", index)
+		}
+		return r, nil
+	}
+
+	numFonts := binary.BigEndian.Uint32(header[8:])
+	if index < 0 || numFonts <= uint32(index) {
+		return nil, fmt.Errorf("bad font index %d", index)
+	}
+	if _, err := r.ReadAt(header[:4], 12+4*int64(index)); err != nil {
+		return nil, err
+	}
+	offset := int64(binary.BigEndian.Uint32(header))
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+	numTables := int(binary.BigEndian.Uint16(header[4:]))
+	directory := make([]byte, 12+16*numTables)
+	if _, err := r.ReadAt(directory, offset); err != nil {
+		return nil, err
+	}
+	for i := 0; i < numTables; i++ {
+		record := directory[12+16*i:]
+		binary.BigEndian.PutUint32(record[8:], binary.BigEndian.Uint32(record[8:])+uint32(len(directory)))
+	}
+	prefixed := &prefixReaderAt{directory, r}
+	return io.NewSectionReader(prefixed, 0, int64(len(directory))+r.Size()), nil
+}
+
+// prefixReaderAt reads from a prefix followed by a reader.
+type prefixReaderAt struct {
+	prefix []byte
+	r      io.ReaderAt
+}
+
+func (r *prefixReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	n := 0
+	if off < int64(len(r.prefix)) {
+		n = copy(b, r.prefix[off:])
+	}
+	if n < len(b) {
+		m, err := r.r.ReadAt(b[n:], off+int64(n)-int64(len(r.prefix)))
+		return n + m, err
+	}
+	return n, nil
+}
+
 // ScriptItemizer divides the string in parts for each different script.
 func ScriptItemizer(text string) []string {
 	i := 0
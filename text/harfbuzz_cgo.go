@@ -16,6 +16,8 @@ hb_glyph_info_t *get_glyph_info(hb_glyph_info_t *info, unsigned int i) {
 hb_glyph_position_t *get_glyph_position(hb_glyph_position_t *pos, unsigned int i) {
 	return &pos[i];
 }
+
+extern hb_blob_t *referenceSFNTTable(hb_face_t *face, hb_tag_t tag, void *user_data);
 */
 import "C"
 import (
@@ -30,10 +32,11 @@ import (
 
 // Shaper is a text shaper formatting a string in properly positioned glyphs.
 type Shaper struct {
-	cb    *C.char
-	blob  *C.struct_hb_blob_t
-	face  *C.struct_hb_face_t
-	fonts map[uint16]*C.struct_hb_font_t
+	cb     *C.char
+	blob   *C.struct_hb_blob_t
+	face   *C.struct_hb_face_t
+	fonts  map[uint16]*C.struct_hb_font_t
+	tables unsafe.Pointer
 }
 
 // NewShaper returns a new text shaper.
@@ -49,9 +52,15 @@ func NewShaper(b []byte, index int) (Shaper, error) {
 	}, nil
 }
 
-// NewShaperSFNT returns a new text shaper using a SFNT structure.
+// NewShaperSFNT returns a new text shaper using a SFNT structure. HarfBuzz reads the tables of the font on demand through the SFNT, so that the font file does not need to be held in memory.
 func NewShaperSFNT(sfnt *font.SFNT) (Shaper, error) {
-	return NewShaper(sfnt.Data, 0)
+	tables := newSFNTTables(sfnt)
+	face := C.hb_face_create_for_tables(C.hb_reference_table_func_t(C.referenceSFNTTable), tables, nil)
+	return Shaper{
+		face:   face,
+		fonts:  map[uint16]*C.struct_hb_font_t{},
+		tables: tables,
+	}, nil
 }
 
 // Destroy destroys the allocated C memory.
@@ -60,8 +69,13 @@ func (s Shaper) Destroy() {
 		C.hb_font_destroy(font)
 	}
 	C.hb_face_destroy(s.face)
-	C.hb_blob_destroy(s.blob)
-	C.free(unsafe.Pointer(s.cb))
+	if s.blob != nil {
+		C.hb_blob_destroy(s.blob)
+		C.free(unsafe.Pointer(s.cb))
+	}
+	if s.tables != nil {
+		freeSFNTTables(s.tables)
+	}
 }
 
 // Shape shapes the string for a given direction, script, and language.
@@ -0,0 +1,58 @@
+//go:build harfbuzz && !js
+// +build harfbuzz,!js
+
+package text
+
+//#include <stdlib.h>
+//#include <stdint.h>
+//#include <hb.h>
+import "C"
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/blackss2/canvas/font"
+)
+
+// sfntTables holds the fonts that HarfBuzz reads tables from, by the identifier that is passed as user data to referenceSFNTTable. Go pointers cannot be held by C code.
+var sfntTables = struct {
+	sync.Mutex
+	fonts  map[uintptr]*font.SFNT
+	nextID uintptr
+}{fonts: map[uintptr]*font.SFNT{}}
+
+// newSFNTTables registers the font and returns C memory with its identifier, to be freed by freeSFNTTables.
+func newSFNTTables(sfnt *font.SFNT) unsafe.Pointer {
+	sfntTables.Lock()
+	defer sfntTables.Unlock()
+	sfntTables.nextID++
+	sfntTables.fonts[sfntTables.nextID] = sfnt
+
+	userData := C.malloc(C.size_t(unsafe.Sizeof(C.uintptr_t(0))))
+	*(*C.uintptr_t)(userData) = C.uintptr_t(sfntTables.nextID)
+	return userData
+}
+
+func freeSFNTTables(userData unsafe.Pointer) {
+	sfntTables.Lock()
+	delete(sfntTables.fonts, uintptr(*(*C.uintptr_t)(userData)))
+	sfntTables.Unlock()
+	C.free(userData)
+}
+
+//export referenceSFNTTable
+func referenceSFNTTable(face *C.hb_face_t, tag C.hb_tag_t, userData unsafe.Pointer) *C.hb_blob_t {
+	sfntTables.Lock()
+	sfnt := sfntTables.fonts[uintptr(*(*C.uintptr_t)(userData))]
+	sfntTables.Unlock()
+	if sfnt == nil || tag == 0 {
+		return C.hb_blob_get_empty()
+	}
+
+	b, err := sfnt.Table(string([]byte{byte(tag >> 24), byte(tag >> 16), byte(tag >> 8), byte(tag)}))
+	if err != nil || len(b) == 0 {
+		return C.hb_blob_get_empty()
+	}
+	cb := C.CBytes(b)
+	return C.hb_blob_create((*C.char)(cb), C.uint(len(b)), C.HB_MEMORY_MODE_WRITABLE, cb, C.hb_destroy_func_t(C.free))
+}
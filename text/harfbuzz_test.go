@@ -0,0 +1,103 @@
+//go:build !harfbuzz || js
+// +build !harfbuzz js
+
+package text
+
+import (
+	"testing"
+
+	"github.com/blackss2/canvas/font"
+)
+
+// testVariationCmap returns a cmap table that maps 'a' and 'b' to glyphs 1 and 2, and the variation sequence 'a' U+FE00 to the default glyph and 'a' U+FE01 to glyph 2.
+func testVariationCmap() []byte {
+	w := font.NewBinaryWriter([]byte{})
+	w.WriteUint16(0)  // version
+	w.WriteUint16(2)  // numTables
+	w.WriteUint16(0)  // platformID
+	w.WriteUint16(3)  // encodingID
+	w.WriteUint32(20) // subtableOffset
+	w.WriteUint16(0)  // platformID
+	w.WriteUint16(5)  // encodingID
+	w.WriteUint32(52) // subtableOffset
+
+	// format 4
+	w.WriteUint16(4)  // format
+	w.WriteUint16(32) // length
+	w.WriteUint16(0)  // language
+	w.WriteUint16(4)  // segCountX2
+	w.WriteUint16(4)  // searchRange
+	w.WriteUint16(1)  // entrySelector
+	w.WriteUint16(0)  // rangeShift
+	w.WriteUint16('b')
+	w.WriteUint16(0xFFFF) // endCode
+	w.WriteUint16(0)      // reservedPad
+	w.WriteUint16('a')
+	w.WriteUint16(0xFFFF) // startCode
+	w.WriteInt16(1 - 'a')
+	w.WriteInt16(1) // idDelta
+	w.WriteUint16(0)
+	w.WriteUint16(0) // idRangeOffset
+
+	// format 14
+	w.WriteUint16(14) // format
+	w.WriteUint32(49) // length
+	w.WriteUint32(2)  // numVarSelectorRecords
+	w.WriteBytes([]byte{0x00, 0xFE, 0x00})
+	w.WriteUint32(32) // defaultUVSOffset
+	w.WriteUint32(0)  // nonDefaultUVSOffset
+	w.WriteBytes([]byte{0x00, 0xFE, 0x01})
+	w.WriteUint32(0)  // defaultUVSOffset
+	w.WriteUint32(40) // nonDefaultUVSOffset
+	w.WriteUint32(1)  // numUnicodeValueRanges
+	w.WriteBytes([]byte{0x00, 0x00, 'a'})
+	w.WriteUint8(0)  // additionalCount
+	w.WriteUint32(1) // numUVSMappings
+	w.WriteBytes([]byte{0x00, 0x00, 'a'})
+	w.WriteUint16(2) // glyphID
+	return w.Bytes()
+}
+
+func TestShaperSFNTVariationSelector(t *testing.T) {
+	builder := font.NewBuilder("Test", 1000)
+	for _, r := range []rune{'a', 'b'} {
+		glyph := builder.AddGlyph(string(r), 600, r)
+		glyph.MoveTo(100, 0)
+		glyph.LineTo(500, 0)
+		glyph.LineTo(500, 700)
+		glyph.Close()
+	}
+	b, err := builder.WriteTrueType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfnt, err := font.ParseSFNT(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfnt.Tables["cmap"] = testVariationCmap()
+	if sfnt, err = font.ParseSFNT(sfnt.Write(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	shaper, err := NewShaperSFNT(sfnt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shaper.Destroy()
+
+	var tests = []struct {
+		text    string
+		glyphID uint16
+	}{
+		{"a", 1},
+		{"a\uFE00", 1},
+		{"a\uFE01", 2},
+	}
+	for _, tt := range tests {
+		glyphs := shaper.Shape(tt.text, 1000, LeftToRight, Latin, "en", "", "")
+		if len(glyphs) == 0 || glyphs[0].ID != tt.glyphID {
+			t.Fatalf("%+q: glyphs are %v, expected glyph %d first", tt.text, glyphs, tt.glyphID)
+		}
+	}
+}
@@ -23,3 +23,18 @@ func IsParagraphSeparator(r rune) bool {
 	// line feed, vertical tab, form feed, carriage return, next line, line separator, paragraph separator
 	return 0x0A <= r && r <= 0x0D || r == 0x85 || r == '\u2008' || r == '\u2009'
 }
+
+// AddToUnicode adds the clusters of the shaped glyphs to the glyph-to-text mapping, so that ligatures map to all their characters. Consecutive glyphs with the same cluster ID form a cluster, unless they both have text, such as inserted spaces and hyphens.
+func AddToUnicode(m *font.ToUnicodeMap, glyphs []Glyph) {
+	for i := 0; i < len(glyphs); {
+		glyphIDs := []uint16{glyphs[i].ID}
+		text := glyphs[i].Text
+		j := i + 1
+		for ; j < len(glyphs) && glyphs[j].Cluster == glyphs[i].Cluster && (text == "" || glyphs[j].Text == ""); j++ {
+			glyphIDs = append(glyphIDs, glyphs[j].ID)
+			text += glyphs[j].Text
+		}
+		m.AddCluster(glyphIDs, text)
+		i = j
+	}
+}